@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/workflows"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
 	"github.com/rs/zerolog/log"
@@ -15,6 +18,9 @@ import (
 
 func setupExecuteCommands(rootCmd *cobra.Command) {
 	params := new(workflows.RunParams)
+	retry := new(retryFlags)
+	stubs := new(stubFlags)
+	secrets := new(workflows.SecretProviderParams)
 
 	var defaultOutputMode workflows.OutputMode
 	if os.Getenv("CI") != "true" && term.IsTerminal(int(os.Stdout.Fd())) {
@@ -25,22 +31,61 @@ func setupExecuteCommands(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().BoolVarP(&params.Reuse, "reuse", "R", false, "Reuse containers between executions")
 	rootCmd.PersistentFlags().StringVarP(&params.WorkingDir, "working-dir", "w", ".", "directory to run workflow against")
 	rootCmd.PersistentFlags().StringVarP(&params.WorkflowPath, "workflow-file", "f", "", "path to workflow to run")
-	rootCmd.PersistentFlags().StringVarP(&params.Action, "action", "a", "", "action to run (default: *)")
+	rootCmd.PersistentFlags().StringVarP(&params.Action, "action", "a", "", "actions to run, e.g. 'Build+', '+Deploy', 'Test@*' or '!Deploy*' (default: *)")
 	rootCmd.PersistentFlags().BoolVarP(&params.BindWorkingDir, "bind", "b", false, "bind working directory rather than create a copy")
 	rootCmd.PersistentFlags().BoolVarP(&params.NoOutput, "quiet", "q", false, "disable logging of output from actions")
 	rootCmd.PersistentFlags().BoolVarP(&params.Dryrun, "dryrun", "n", false, "dry run")
 	rootCmd.PersistentFlags().BoolVarP(&params.NoCache, "no-cache", "C", false, "disable file caches")
 	rootCmd.PersistentFlags().StringVarP((*string)(&params.ExecutionType), "executor", "x", string(runner.DefaultExecutionType()), "executor type [docker,finch,shell]")
+	rootCmd.PersistentFlags().DurationVar(&params.ActionTimeout, "timeout", 0, "maximum duration of each action, on top of its own Timeout, e.g. 30m (default: no limit)")
+	rootCmd.PersistentFlags().BoolVar(&params.FailFast, "fail-fast", false, "cancel the running actions and skip the rest once any action fails")
+	rootCmd.PersistentFlags().StringVar(&params.SARIFReport, "sarif-report", "", "path of the SARIF file to write the vulnerabilities found by the actions to")
+	rootCmd.PersistentFlags().StringVar(&params.SBOMReport, "sbom-report", "", "path of the CycloneDX file to write the merged SBOM of the actions to")
+	rootCmd.PersistentFlags().StringVar(&params.JUnitReport, "junit-report", "", "path of the JUnit XML file to write the result of each action to")
+	rootCmd.PersistentFlags().StringVar(&params.Resume, "resume", "", "ID of a previous run to resume, only running the actions that didn't succeed")
+	rootCmd.PersistentFlags().BoolVar(&params.RerunFailed, "rerun-failed", false, "resume the most recent run of the workflow, only running the actions that didn't succeed")
+	rootCmd.PersistentFlags().StringVar(&params.ContinueOnFailure, "continue-on-failure", "", "actions whose failure doesn't skip the actions that depend on them, e.g. 'Test@*'")
+	rootCmd.PersistentFlags().StringToIntVar(&retry.Attempts, "retry", make(map[string]int), "maximum attempts of actions that fail, e.g. 'Test@Integration=3'")
+	rootCmd.PersistentFlags().DurationVar(&retry.Backoff, "retry-backoff", 5*time.Second, "delay before the second attempt of an action, doubled before each further attempt")
+	rootCmd.PersistentFlags().IntSliceVar(&retry.ExitCodes, "retry-exit-codes", nil, "only retry actions that fail with these exit codes (default: any failure)")
+	rootCmd.PersistentFlags().StringVar(&retry.ConfigPath, "retry-config", "", "path to a file with the retry policies of actions")
+	rootCmd.PersistentFlags().StringToStringVar(&stubs.Paths, "stub", make(map[string]string), "stub actions with the outputs and artifacts recorded in a file rather than run them, e.g. Deploy=stub.yaml")
+	rootCmd.PersistentFlags().StringVar(&stubs.ConfigPath, "stub-config", "", "path to a file with the stub files of actions")
+	rootCmd.PersistentFlags().BoolVar(&params.RecordStubs, "record", false, "run the stubbed actions and record their outputs and artifacts to their stub files")
+	rootCmd.PersistentFlags().StringSliceVar(&secrets.SecretsFiles, "secrets-file", nil, "dotenv or YAML files to read secrets from, in priority order, optionally encrypted with 'ccr secrets encrypt' (.enc) or age (.age)")
+	rootCmd.PersistentFlags().StringVar(&secrets.SecretsExec, "secrets-exec", "", "helper command to run with the name of each secret that prints its value, e.g. 'pass show ci'")
+	rootCmd.PersistentFlags().StringVar(&secrets.AgeIdentity, "age-identity", "", "path of the age identity to decrypt .age secrets files with")
 	rootCmd.PersistentFlags().IntVarP(&params.Concurrency, "concurrency", "c", runtime.NumCPU(), "number of policies to execute concurrently")
 	rootCmd.PersistentFlags().StringToStringVarP(&params.EnvironmentProfiles, "environments", "e", make(map[string]string), "map workflow environment names to AWS CLI profile names")
-	rootCmd.PersistentFlags().StringVarP((*string)(&params.OutputMode), "output-format", "t", string(defaultOutputMode), "output mode [tui,text]")
+	rootCmd.PersistentFlags().StringToStringVar(&params.Variables, "var", make(map[string]string), "provide output variables of actions that aren't run, e.g. Build.IMAGE_TAG=abc123")
+	rootCmd.PersistentFlags().StringToStringVar(&params.Artifacts, "artifact", make(map[string]string), "provide artifacts of actions that aren't run from a directory or zip file, e.g. BuildOutput=./dist")
+	rootCmd.PersistentFlags().StringVarP((*string)(&params.OutputMode), "output-format", "t", string(defaultOutputMode), "output mode [tui,text,json]")
 
 	executeCommand := func(cmd *cobra.Command, args []string) error {
 		if len(args) == 1 {
 			params.WorkflowName = args[0]
 		}
 		ctx := cmd.Context()
-		err := workflows.Run(ctx, params)
+		if params.OutputMode == workflows.OutputModeJSON {
+			// keep stdout for the events
+			log.Logger = log.Output(features.NewConsoleWriter(cmd.ErrOrStderr()))
+			ctx = log.Logger.WithContext(ctx)
+			params.Events = features.NewEventStream(cmd.OutOrStdout())
+		}
+		retryPolicies, err := retry.policies()
+		if err != nil {
+			return err
+		}
+		params.RetryPolicies = retryPolicies
+		stubPaths, err := stubs.paths()
+		if err != nil {
+			return err
+		}
+		params.Stubs = stubPaths
+		if params.SecretProvider, err = workflows.NewSecretProvider(ctx, secrets); err != nil {
+			return err
+		}
+		err = workflows.Run(ctx, params)
 		log.Ctx(ctx).Debug().Err(err).Msg("execute complete")
 		return err
 	}
@@ -54,3 +99,54 @@ func setupExecuteCommands(rootCmd *cobra.Command) {
 	rootCmd.RunE = executeCommand
 	rootCmd.Args = cobra.MaximumNArgs(1)
 }
+
+// retryFlags are the flags that configure the retry policies of actions
+type retryFlags struct {
+	Attempts   map[string]int
+	Backoff    time.Duration
+	ExitCodes  []int
+	ConfigPath string
+}
+
+// policies returns the retry policies from the config file, overridden by the policies from the flags
+func (rf *retryFlags) policies() (map[string]*features.RetryPolicy, error) {
+	policies := make(map[string]*features.RetryPolicy)
+	if rf.ConfigPath != "" {
+		var err error
+		if policies, err = workflows.ReadRetryPolicies(rf.ConfigPath); err != nil {
+			return nil, err
+		}
+	}
+	for selector, attempts := range rf.Attempts {
+		if attempts < 1 {
+			return nil, fmt.Errorf("invalid retry '%s=%d': attempts must be at least 1", selector, attempts)
+		}
+		policies[selector] = &features.RetryPolicy{
+			MaxAttempts: attempts,
+			Backoff:     rf.Backoff,
+			ExitCodes:   rf.ExitCodes,
+		}
+	}
+	return policies, nil
+}
+
+// stubFlags are the flags that configure the stub files of actions
+type stubFlags struct {
+	Paths      map[string]string
+	ConfigPath string
+}
+
+// paths returns the stub files from the config file, overridden by the stub files from the flags
+func (sf *stubFlags) paths() (map[string]string, error) {
+	paths := make(map[string]string)
+	if sf.ConfigPath != "" {
+		var err error
+		if paths, err = workflows.ReadStubConfig(sf.ConfigPath); err != nil {
+			return nil, err
+		}
+	}
+	for actionID, stubPath := range sf.Paths {
+		paths[actionID] = stubPath
+	}
+	return paths, nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/workflows"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/spf13/cobra"
+)
+
+func setupGraphCommand(rootCmd *cobra.Command) {
+	var format string
+	graphCmd := &cobra.Command{
+		Use:   "graph [workflow-name]",
+		Short: "Export the dependencies between the actions of a workflow",
+		Long:  "Export the dependencies between the actions of a workflow. Action groups are rendered as clusters and each edge is labelled with the DependsOn, artifact or variable that caused it.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params := new(workflows.NewActionGraphParams)
+			if len(args) == 1 {
+				params.WorkflowName = args[0]
+			}
+			params.WorkflowPath, _ = cmd.Flags().GetString("workflow-file")
+			params.WorkingDir, _ = cmd.Flags().GetString("working-dir")
+			params.Action, _ = cmd.Flags().GetString("action")
+			executionType, _ := cmd.Flags().GetString("executor")
+			params.ExecutionType = runner.ExecutionType(executionType)
+			graph, err := workflows.NewActionGraph(cmd.Context(), params)
+			if err != nil {
+				return err
+			}
+			return graph.Write(cmd.OutOrStdout(), workflows.GraphFormat(format))
+		},
+	}
+	graphCmd.Flags().StringVar(&format, "format", string(workflows.GraphFormatDOT), "graph format [dot,mermaid,json]")
+	rootCmd.AddCommand(graphCmd)
+}
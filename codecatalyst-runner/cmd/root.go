@@ -3,6 +3,8 @@ package cmd
 import (
 	"strings"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -31,6 +33,10 @@ func NewRootCmd(version string) *cobra.Command {
 	rootCmd.InitDefaultVersionFlag()
 	rootCmd.PersistentFlags().BoolVarP(&params.Verbose, "verbose", "V", false, "verbose output")
 	setupExecuteCommands(rootCmd)
+	setupValidateCommand(rootCmd)
+	setupGraphCommand(rootCmd)
+	setupRunsCommands(rootCmd)
+	setupSecretsCommands(rootCmd)
 	return rootCmd
 }
 
@@ -40,7 +46,7 @@ func setup(params *commonParams) func(*cobra.Command, []string) {
 		if params.Verbose {
 			zerolog.SetGlobalLevel(zerolog.DebugLevel)
 		}
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: cmd.OutOrStdout()})
+		log.Logger = log.Output(features.NewConsoleWriter(cmd.OutOrStdout()))
 		if params.Verbose {
 			log.Logger = log.Logger.With().Caller().Stack().Logger()
 		}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/workflows"
+
+	"github.com/spf13/cobra"
+)
+
+func setupRunsCommands(rootCmd *cobra.Command) {
+	runsCmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Browse the history of workflow runs",
+		Long:  "Browse the history of workflow runs. Each run is recorded in the user cache dir with a snapshot of the workflow, the log, outputs, report results, timings and artifacts of each action.",
+	}
+	runsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the recorded runs, the most recent first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			records, err := workflows.ListRuns()
+			if err != nil {
+				return err
+			}
+			return workflows.WriteRuns(cmd.OutOrStdout(), records)
+		},
+	})
+	runsCmd.AddCommand(&cobra.Command{
+		Use:   "show <run-id>",
+		Short: "Show the status, timings, outputs, reports and artifacts of the actions of a run",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			record, err := workflows.LoadRun(args[0])
+			if err != nil {
+				return err
+			}
+			return record.Write(cmd.OutOrStdout())
+		},
+	})
+	var keep int
+	var olderThan time.Duration
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the oldest runs from the history",
+		Long:  "Remove the runs beyond the most recent ones from the history, along with their logs and artifacts, as well as the runs older than --older-than if set.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			pruned, err := workflows.PruneRuns(keep, olderThan)
+			for _, record := range pruned {
+				fmt.Fprintf(cmd.OutOrStdout(), "removed %s\n", record.ID)
+			}
+			return err
+		},
+	}
+	pruneCmd.Flags().IntVar(&keep, "keep", 20, "number of the most recent runs to keep")
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 0, "also remove the runs that started longer ago than this, e.g. 168h")
+	runsCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(runsCmd)
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "logs <run-id> [action]",
+		Short: "Print the logs of the actions of a run",
+		Long:  "Print the log of an action of a run, or the logs of every action of the run if no action is provided.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			record, err := workflows.LoadRun(args[0])
+			if err != nil {
+				return err
+			}
+			if len(args) == 2 {
+				if _, ok := record.Actions[args[1]]; !ok {
+					return fmt.Errorf("no action '%s' in run '%s'", args[1], record.ID)
+				}
+				err := printLog(cmd.OutOrStdout(), record.LogPath(args[1]))
+				if errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("no log recorded for action '%s' in run '%s'", args[1], record.ID)
+				}
+				return err
+			}
+			for _, actionID := range record.ActionIDs() {
+				fmt.Fprintf(cmd.OutOrStdout(), "==> %s <==\n", actionID)
+				if err := printLog(cmd.OutOrStdout(), record.LogPath(actionID)); errors.Is(err, fs.ErrNotExist) {
+					fmt.Fprintf(cmd.OutOrStdout(), "(no log: %s)\n", record.Actions[actionID].Error)
+				} else if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+func printLog(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/workflows"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func setupSecretsCommands(rootCmd *cobra.Command) {
+	secretsCmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage secrets files",
+	}
+	var outputPath string
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt <secrets-file>",
+		Short: "Encrypt a secrets file with a passphrase",
+		Long:  "Encrypt a dotenv or YAML secrets file with a passphrase, read from CCR_SECRETS_PASSPHRASE or prompted for. The encrypted file can be passed to --secrets-file, and is decrypted with the same passphrase.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("unable to read secrets file '%s': %w", args[0], err)
+			}
+			passphrase, ok := os.LookupEnv("CCR_SECRETS_PASSPHRASE")
+			if !ok {
+				if passphrase, err = workflows.PromptPassphrase("Passphrase"); err != nil {
+					return err
+				}
+				confirmation, err := workflows.PromptPassphrase("Confirm passphrase")
+				if err != nil {
+					return err
+				}
+				if confirmation != passphrase {
+					return fmt.Errorf("passphrases don't match")
+				}
+			}
+			encrypted, err := workflows.EncryptSecrets(content, passphrase)
+			if err != nil {
+				return err
+			}
+			if outputPath == "" {
+				outputPath = fmt.Sprintf("%s.enc", args[0])
+			}
+			if err := os.WriteFile(outputPath, encrypted, 0600); err != nil {
+				return err
+			}
+			log.Ctx(cmd.Context()).Info().Msgf("🔒 Secrets encrypted to %s", outputPath)
+			return nil
+		},
+	}
+	encryptCmd.Flags().StringVarP(&outputPath, "output", "o", "", "path of the encrypted file (default: <secrets-file>.enc)")
+	secretsCmd.AddCommand(encryptCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/workflows"
+
+	"github.com/spf13/cobra"
+)
+
+func setupValidateCommand(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Validate workflow files",
+		Long:  "Validate workflow files against the CodeCatalyst workflow definition. If no workflow file is provided, every workflow in the working directory is validated.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			params := new(workflows.ValidateParams)
+			params.WorkflowPath, _ = cmd.Flags().GetString("workflow-file")
+			params.WorkingDir, _ = cmd.Flags().GetString("working-dir")
+			findings, err := workflows.Validate(cmd.Context(), params)
+			if err != nil {
+				return err
+			}
+			for _, finding := range findings {
+				fmt.Fprintln(cmd.OutOrStdout(), finding)
+			}
+			errorCount := findings.Count(workflows.SeverityError)
+			fmt.Fprintf(cmd.OutOrStdout(), "%d error(s), %d warning(s)\n", errorCount, findings.Count(workflows.SeverityWarning))
+			if errorCount > 0 {
+				return fmt.Errorf("workflow validation failed with %d error(s)", errorCount)
+			}
+			return nil
+		},
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/cmd"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+
 	"github.com/rs/zerolog/log"
 )
 
@@ -43,7 +46,12 @@ func main() {
 	close()
 	if err != nil {
 		if err != context.Canceled {
-			fmt.Printf("error executing command: %s\n", err.Error())
+			// stdout is kept for the events of the json output format
+			fmt.Fprintf(os.Stderr, "error executing command: %s\n", err.Error())
+		}
+		if errors.As(err, new(*common.TimeoutError)) {
+			// same exit status as timeout(1)
+			os.Exit(124)
 		}
 		os.Exit(1)
 	}
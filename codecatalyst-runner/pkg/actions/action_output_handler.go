@@ -5,18 +5,26 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"regexp"
 	"slices"
 	"strings"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/exp/maps"
 )
 
-// ActionOutputHandler collects the output from an action and checks for failures in the ACTION_RUN_SUMMARY output
-func ActionOutputHandler(outputs map[string]string, suppressOutput bool) runner.Feature {
+// ActionOutputHandler collects the output from an action and checks for failures in the ACTION_RUN_SUMMARY output.
+// The outputs are read from the output file of the commands and their exported environment variables, once collected
+// from the commands' output dir, and from the ::set-output commands they print for backward compatibility.
+// The annotations printed by the commands are added to annotations, and the rendered messages of the ACTION_RUN_SUMMARY
+// output to summaries, if any.
+func ActionOutputHandler(outputs map[string]string, annotations *Annotations, summaries *RunSummaries, suppressOutput bool) runner.Feature {
 	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
 		log.Ctx(ctx).Debug().Msg("ENTER ActionOutputHandler")
 		var action *Action
@@ -25,53 +33,63 @@ func ActionOutputHandler(outputs map[string]string, suppressOutput bool) runner.
 		} else {
 			return fmt.Errorf("plan must implement ActionProvider for ActionOutputHandler")
 		}
-		lineHandlers := []lineHandler{
-			actionOutputLineHandler(outputs, maps.Keys(action.Outputs.Variables)),
-		}
 		if suppressOutput {
 			log.Ctx(ctx).Debug().Msgf("suppressing action output")
-		} else {
-			rawLogger := log.Ctx(ctx).With().Logger()
-			lineHandlers = append(lineHandlers, func(s string) bool {
-				s = strings.TrimRight(s, "\r\n")
-				rawLogger.Info().Msg(s)
-				return true
-			})
 		}
-		logWriter := newLineWriter(lineHandlers...)
-		log.Ctx(ctx).Debug().Msgf("Setting stdout/stderr to %+v", logWriter)
-		plan.EnvironmentConfiguration().Stdout = logWriter
-		plan.EnvironmentConfiguration().Stderr = logWriter
+		// the output of each stream is tagged, so that loggers can tell stdout from stderr
+		newLogWriter := func(stream string) io.Writer {
+			lineHandlers := []lineHandler{
+				actionOutputLineHandler(outputs, maps.Keys(action.Outputs.Variables)),
+				annotationLineHandler(annotations, plan.ID()),
+			}
+			if !suppressOutput {
+				rawLogger := log.Ctx(ctx).With().Str("stream", stream).Logger()
+				lineHandlers = append(lineHandlers, rawLogLineHandler(rawLogger))
+			}
+			return newLineWriter(lineHandlers...)
+		}
+		stdout, stderr := newLogWriter("stdout"), newLogWriter("stderr")
+		log.Ctx(ctx).Debug().Msgf("Setting stdout/stderr to %+v/%+v", stdout, stderr)
+		plan.EnvironmentConfiguration().Stdout = stdout
+		plan.EnvironmentConfiguration().Stderr = stderr
 
-		if err := e(ctx); err != nil {
-			maps.Clear(outputs)
-			return err
+		outputDir, err := os.MkdirTemp(runner.TmpDir(), "outputs")
+		if err != nil {
+			return fmt.Errorf("unable to create output dir: %w", err)
 		}
-		for k, v := range outputs {
-			if k == "ACTION_RUN_SUMMARY" {
-				delete(outputs, k)
-				// handle ACTION_RUN_SUMMARY output
-				actionRunSummaries := make([]ActionRunSummaryMessage, 0)
-				err := json.Unmarshal([]byte(v), &actionRunSummaries)
-				if err != nil {
-					return fmt.Errorf("unable to unmarshal ACTION_RUN_SUMMARY: %w\n%s", err, v)
-				}
-				var actionRunErrors error
-				for _, actionRunSummary := range actionRunSummaries {
-					if actionRunSummary.Level == ActionRunSummaryLevelError {
-						actionRunErrors = errors.Join(
-							actionRunErrors,
-							fmt.Errorf("[%s] %s", actionRunSummary.Text, actionRunSummary.Message),
-						)
-					}
-				}
-				if actionRunErrors != nil {
-					return actionRunErrors
-				}
+		defer os.RemoveAll(outputDir)
+		// the file map is removed once the action has run, so that retries don't collect the outputs more than once
+		fileMaps := plan.EnvironmentConfiguration().FileMaps
+		plan.EnvironmentConfiguration().FileMaps = append(slices.Clip(fileMaps), &runner.FileMap{
+			Type:       runner.FileMapTypeCollect,
+			SourcePath: fmt.Sprintf("%s/.", runner.OutputDir),
+			TargetPath: outputDir,
+		})
+		defer func() { plan.EnvironmentConfiguration().FileMaps = fileMaps }()
+
+		err = e(ctx)
+		// the output file is read even if the action failed, for the ACTION_RUN_SUMMARY
+		if readErr := readFileOutputs(outputDir, outputs, maps.Keys(action.Outputs.Variables)); readErr != nil {
+			if err != nil {
+				log.Ctx(ctx).Warn().Err(readErr).Msg("Unable to read the outputs of the failed action")
+			} else {
+				err = readErr
 			}
 		}
+		// the summary is reported even if the action failed, as it usually explains why
+		if v, ok := outputs["ACTION_RUN_SUMMARY"]; ok {
+			delete(outputs, "ACTION_RUN_SUMMARY")
+			if summaryErr := handleActionRunSummary(ctx, plan.ID(), v, summaries); err == nil && summaryErr != nil {
+				return summaryErr
+			}
+		}
+		if err != nil {
+			maps.Clear(outputs)
+			return err
+		}
 		log.Ctx(ctx).Debug().Msgf("action outputs: %+v", outputs)
 		if len(outputs) > 0 {
+			runner.EmitEvent(ctx, &runner.Event{Type: runner.EventOutputsSet, Outputs: maps.Clone(outputs)})
 			log.Ctx(ctx).Info().Msgf("")
 			log.Ctx(ctx).Info().Msgf("💬 OUTPUTS:")
 			for k, v := range outputs {
@@ -84,26 +102,106 @@ func ActionOutputHandler(outputs map[string]string, suppressOutput bool) runner.
 	}
 }
 
-var actionCommandPattern = regexp.MustCompile("^::([^ ]+)( (.+))?::([^\r\n]*)[\r\n]+$")
+// readFileOutputs adds the outputs of the action collected from its output dir to outputs. The outputs of the
+// output file override those printed with ::set-output.
+func readFileOutputs(outputDir string, outputs map[string]string, filter []string) error {
+	fileOutputs, err := runner.ReadOutputs(outputDir)
+	if err != nil {
+		return fmt.Errorf("unable to read outputs: %w", err)
+	}
+	for name, value := range fileOutputs {
+		if slices.Contains(filter, name) || name == "ACTION_RUN_SUMMARY" {
+			log.Debug().Msgf("Setting output %s = %s", name, value)
+			outputs[name] = value
+		}
+	}
+	return nil
+}
+
+// handleActionRunSummary renders the messages of an ACTION_RUN_SUMMARY output and adds them to summaries. It returns
+// the messages with the error level as errors.
+func handleActionRunSummary(ctx context.Context, planID string, value string, summaries *RunSummaries) error {
+	actionRunSummaries := make([]ActionRunSummaryMessage, 0)
+	if err := json.Unmarshal([]byte(value), &actionRunSummaries); err != nil {
+		return fmt.Errorf("unable to unmarshal ACTION_RUN_SUMMARY: %w\n%s", err, value)
+	}
+	messages := summaryMessages(actionRunSummaries)
+	if len(messages) == 0 {
+		return nil
+	}
+	summaries.Set(planID, messages)
+	runner.EmitEvent(ctx, &runner.Event{Type: runner.EventSummarySet, Summary: messages})
+	var actionRunErrors error
+	log.Ctx(ctx).Info().Msgf("")
+	log.Ctx(ctx).Info().Msgf("📋 SUMMARY:")
+	for _, message := range messages {
+		switch ActionRunSummaryLevel(message.Level) {
+		case ActionRunSummaryLevelError:
+			log.Ctx(ctx).Error().Msgf("    %s %s", SummaryIcon(message.Level), message)
+			actionRunErrors = errors.Join(actionRunErrors, fmt.Errorf("%s", message))
+		case ActionRunSummaryLevelWarning:
+			log.Ctx(ctx).Warn().Msgf("    %s %s", SummaryIcon(message.Level), message)
+		default:
+			log.Ctx(ctx).Info().Msgf("    %s %s", SummaryIcon(message.Level), message)
+		}
+	}
+	log.Ctx(ctx).Info().Msgf("")
+	return actionRunErrors
+}
+
+var actionCommandPattern = regexp.MustCompile("^::([^ :]+)( (.+?))?::([^\r\n]*)[\r\n]+$")
+
+// actionCommand is a command printed by an action, e.g. ::set-output name=FOO::bar
+type actionCommand struct {
+	name       string
+	properties map[string]string
+	value      string
+}
+
+// parseActionCommand parses the line as an action command, if it is one
+func parseActionCommand(line string) (*actionCommand, bool) {
+	m := actionCommandPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	command := &actionCommand{
+		name:       m[1],
+		properties: make(map[string]string),
+		value:      m[4],
+	}
+	kvPairList := strings.Split(m[3], ",")
+	for _, kvPair := range kvPairList {
+		kv := strings.Split(kvPair, "=")
+		if len(kv) == 2 {
+			command.properties[strings.TrimSpace(kv[0])] = unescapeProperty(kv[1])
+		}
+	}
+	return command, true
+}
+
+// unescapeValue decodes the characters that are percent-encoded in the value of an action command
+func unescapeValue(value string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%25", "%").Replace(value)
+}
+
+// unescapeProperty decodes the characters that are percent-encoded in the properties of an action command
+func unescapeProperty(value string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",", "%25", "%").Replace(value)
+}
 
 func actionOutputLineHandler(outputs map[string]string, filter []string) lineHandler {
 	return func(line string) bool {
-		if m := actionCommandPattern.FindStringSubmatch(line); m != nil {
-			command := m[1]
-			kvPairs := make(map[string]string)
-			kvPairList := strings.Split(m[3], ",")
-			for _, kvPair := range kvPairList {
-				kv := strings.Split(kvPair, "=")
-				if len(kv) == 2 {
-					kvPairs[kv[0]] = kv[1]
+		if command, ok := parseActionCommand(line); ok {
+			name := command.properties["name"]
+			if command.name == "set-output" {
+				if slices.Contains(filter, name) || name == "ACTION_RUN_SUMMARY" {
+					log.Debug().Msgf("Setting output %s = %s", name, command.value)
+					outputs[name] = command.value
 				}
+				return false
 			}
-			arg := m[4]
-			if command == "set-output" {
-				if slices.Contains(filter, kvPairs["name"]) || kvPairs["name"] == "ACTION_RUN_SUMMARY" {
-					log.Debug().Msgf("Setting output %s = %s", kvPairs["name"], arg)
-					outputs[kvPairs["name"]] = arg
-				}
+			if command.name == "add-mask" {
+				common.AddMask(command.value)
 				return false
 			}
 		}
@@ -111,11 +209,63 @@ func actionOutputLineHandler(outputs map[string]string, filter []string) lineHan
 	}
 }
 
+// annotationLineHandler adds the ::error, ::warning and ::notice commands of the plan to annotations
+func annotationLineHandler(annotations *Annotations, planID string) lineHandler {
+	return func(line string) bool {
+		if command, ok := parseActionCommand(line); ok {
+			switch AnnotationLevel(command.name) {
+			case AnnotationLevelError, AnnotationLevelWarning, AnnotationLevelNotice:
+				command.value = common.Mask(unescapeValue(command.value))
+				annotations.Add(newAnnotation(planID, command))
+			}
+		}
+		return true
+	}
+}
+
+// rawLogLineHandler logs the output of the commands. The annotations are logged at their level, ::debug:: lines at the
+// debug level, and the start and end of ::group:: sections with a group field for the loggers that render them.
+func rawLogLineHandler(rawLogger zerolog.Logger) lineHandler {
+	var group string
+	return func(line string) bool {
+		if command, ok := parseActionCommand(line); ok {
+			value := common.Mask(unescapeValue(command.value))
+			switch command.name {
+			case "debug":
+				rawLogger.Debug().Msg(value)
+				return true
+			case "group":
+				group = value
+				rawLogger.Info().Str("group", "start").Msgf("▶ %s", group)
+				return true
+			case "endgroup":
+				rawLogger.Info().Str("group", "end").Msgf("◀ %s", group)
+				group = ""
+				return true
+			case string(AnnotationLevelError), string(AnnotationLevelWarning), string(AnnotationLevelNotice):
+				command.value = value
+				annotation := newAnnotation("", command)
+				switch annotation.Level {
+				case AnnotationLevelError:
+					rawLogger.Error().Msg(annotation.String())
+				case AnnotationLevelWarning:
+					rawLogger.Warn().Msg(annotation.String())
+				default:
+					rawLogger.Info().Msg(annotation.String())
+				}
+				return true
+			}
+		}
+		rawLogger.Info().Msg(common.Mask(strings.TrimRight(line, "\r\n")))
+		return true
+	}
+}
+
 // ActionRunSummaryMessage describes a messages that was returned from the action
 type ActionRunSummaryMessage struct {
 	Text              string                      // text of the message
 	Level             ActionRunSummaryLevel       // level of the message
-	Message           string                      // template to be used with TemplateVariables
+	Message           string                      // template to be used with TemplateVariables, see [ActionRunSummaryMessage.Render]
 	TemplateVariables []ActionRunTemplateVariable // variables to apply in the message template
 }
 
@@ -123,8 +273,12 @@ type ActionRunSummaryMessage struct {
 type ActionRunSummaryLevel string
 
 const (
-	// ActionRunSummaryLevelError represents the error level
+	// ActionRunSummaryLevelError represents the error level, which fails the action
 	ActionRunSummaryLevelError ActionRunSummaryLevel = "Error"
+	// ActionRunSummaryLevelWarning represents the warning level
+	ActionRunSummaryLevelWarning ActionRunSummaryLevel = "Warning"
+	// ActionRunSummaryLevelInfo represents the info level
+	ActionRunSummaryLevelInfo ActionRunSummaryLevel = "Info"
 )
 
 // ActionRunTemplateVariable describes a variable for a message template
@@ -1,12 +1,17 @@
 package actions
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -88,7 +93,7 @@ func TestActionOutputHandler(t *testing.T) {
 			// setup the code under test
 			ctx := context.Background()
 			outputs := make(map[string]string)
-			feature := ActionOutputHandler(outputs, tt.SuppressOutput)
+			feature := ActionOutputHandler(outputs, nil, nil, tt.SuppressOutput)
 
 			// setup the mock
 			plan := new(actionPlan)
@@ -121,3 +126,93 @@ func TestActionOutputHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestActionOutputHandlerMask(t *testing.T) {
+	assert := assert.New(t)
+	out := new(bytes.Buffer)
+	ctx := zerolog.New(out).WithContext(context.Background())
+	plan := new(actionPlan)
+	plan.environmentConfiguration = &runner.EnvironmentConfiguration{}
+	plan.action = &Action{ID: "Mask"}
+	m := new(runner.MockPlanExecutor).WithPlan(plan).WithExecutor(func(ctx context.Context) error {
+		fmt.Fprintln(plan.environmentConfiguration.Stdout, "::add-mask::handler-test-value")
+		fmt.Fprintln(plan.environmentConfiguration.Stdout, "the value is handler-test-value")
+		return nil
+	})
+	m.OnExecute(ctx).Return(nil)
+
+	assert.NoError(m.Execute(ctx, ActionOutputHandler(make(map[string]string), nil, nil, false)))
+	assert.Contains(out.String(), "the value is ***")
+	assert.NotContains(out.String(), "handler-test-value")
+	assert.Equal("***", common.Mask("handler-test-value"))
+}
+
+func TestActionOutputHandlerFile(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	plan := new(actionPlan)
+	plan.environmentConfiguration = &runner.EnvironmentConfiguration{}
+	plan.action = &Action{
+		ID: "File",
+		Outputs: Outputs{
+			Variables: map[string]Output{"Foo": {}, "Notes": {}, "Region": {}},
+		},
+	}
+	m := new(runner.MockPlanExecutor).WithPlan(plan).WithExecutor(func(ctx context.Context) error {
+		// collect the output dir of a command group like the command executors do
+		fileMaps := plan.environmentConfiguration.FileMaps
+		assert.Len(fileMaps, 1)
+		assert.Equal(runner.FileMapTypeCollect, fileMaps[0].Type)
+		assert.Equal("outputs/.", fileMaps[0].SourcePath)
+		fmt.Fprintln(plan.environmentConfiguration.Stdout, "::set-output name=Foo::stdout")
+		fmt.Fprintln(plan.environmentConfiguration.Stdout, "::set-output name=Region::us-east-1")
+		output := "Foo=a,b=c\nNotes<<EOF\nline 1\nline 2\nEOF\nIgnored=value\n"
+		return writeCollectedOutput(fileMaps[0].TargetPath, output)
+	})
+	m.OnExecute(ctx).Return(nil)
+
+	outputs := make(map[string]string)
+	assert.NoError(m.Execute(ctx, ActionOutputHandler(outputs, nil, nil, false)))
+	assert.Equal(map[string]string{"Foo": "a,b=c", "Notes": "line 1\nline 2", "Region": "us-east-1"}, outputs)
+	assert.Empty(plan.environmentConfiguration.FileMaps)
+}
+
+func TestActionOutputHandlerFileFailure(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	plan := new(actionPlan)
+	plan.id = "FileFailure"
+	plan.environmentConfiguration = &runner.EnvironmentConfiguration{}
+	plan.action = &Action{
+		ID: "FileFailure",
+		Outputs: Outputs{
+			Variables: map[string]Output{"Foo": {}},
+		},
+	}
+	m := new(runner.MockPlanExecutor).WithPlan(plan).WithExecutor(func(ctx context.Context) error {
+		output := "Foo=bar\nACTION_RUN_SUMMARY=[{\"Level\":\"Error\",\"Text\":\"MyError\",\"Message\":\"test error\"}]\n"
+		if err := writeCollectedOutput(plan.environmentConfiguration.FileMaps[0].TargetPath, output); err != nil {
+			return err
+		}
+		return fmt.Errorf("exit status 1")
+	})
+	m.OnExecute(ctx).Return(nil)
+
+	// the summary of the failed action is reported, but not its outputs
+	outputs := make(map[string]string)
+	summaries := new(RunSummaries)
+	assert.EqualError(m.Execute(ctx, ActionOutputHandler(outputs, nil, summaries, false)), "exit status 1")
+	assert.Empty(outputs)
+	if messages := summaries.Get("FileFailure"); assert.Len(messages, 1) {
+		assert.Equal("MyError", messages[0].Code)
+	}
+}
+
+// writeCollectedOutput writes the output file of a command group collected in the output dir
+func writeCollectedOutput(outputDir string, output string) error {
+	dir := filepath.Join(outputDir, "0001")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "output"), []byte(output), 0644)
+}
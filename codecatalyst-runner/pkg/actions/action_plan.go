@@ -133,6 +133,9 @@ func (ap *actionPlan) loadDockerAction(action *Action, steps []string) error {
 				return err
 			}
 			cg.Commands = append(cg.Commands, []string{cmd})
+			if i == 2 { // run the post command group even if the pre or main command group failed
+				cg.RunCondition = runner.RunConditionAlways
+			}
 			if i == 1 { // add steps to the main command group
 				log.Debug().Msgf("steps: %+v", steps)
 				for _, step := range steps {
@@ -188,6 +191,9 @@ func (ap *actionPlan) loadNodeAction(action *Action, steps []string, executionTy
 				return err
 			}
 			cg.Commands = append(cg.Commands, []string{"node", fullCommand})
+			if i == 2 { // run the post command group even if the pre or main command group failed
+				cg.RunCondition = runner.RunConditionAlways
+			}
 			if i == 1 { // add steps to the main command group
 				for _, step := range steps {
 					cg.Commands = append(cg.Commands, []string{step})
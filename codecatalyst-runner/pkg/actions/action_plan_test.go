@@ -39,10 +39,11 @@ func TestNewActionPlan(t *testing.T) {
 				ID:      "test",
 				Basedir: "/my/actiondir",
 				Runs: Runs{
-					Using:         UsingTypeDocker,
-					Image:         "Dockerfile",
-					PreEntryPoint: "pre-command",
-					Entrypoint:    "main-command",
+					Using:          UsingTypeDocker,
+					Image:          "Dockerfile",
+					PreEntryPoint:  "pre-command",
+					Entrypoint:     "main-command",
+					PostEntryPoint: "post-command",
 				},
 			},
 			ExpectedEnvironmentConfiguration: runner.EnvironmentConfiguration{
@@ -66,6 +67,12 @@ func TestNewActionPlan(t *testing.T) {
 					Entrypoint: runner.Command{"/bin/cat"},
 					Commands:   []runner.Command{{"main-command"}},
 				},
+				{
+					Image:        "/my/actiondir/Dockerfile",
+					Entrypoint:   runner.Command{"/bin/cat"},
+					Commands:     []runner.Command{{"post-command"}},
+					RunCondition: runner.RunConditionAlways,
+				},
 			},
 		},
 		{
@@ -113,6 +120,7 @@ func TestNewActionPlan(t *testing.T) {
 					Using: UsingTypeNode12,
 					Pre:   "pre-command",
 					Main:  "main-command",
+					Post:  "post-command",
 				},
 			},
 			ExpectedEnvironmentConfiguration: runner.EnvironmentConfiguration{
@@ -141,6 +149,14 @@ func TestNewActionPlan(t *testing.T) {
 						{"node", "/my/actiondir/main-command"},
 					},
 				},
+				{
+					Image:      "",
+					Entrypoint: runner.Command{},
+					Commands: []runner.Command{
+						{"node", "/my/actiondir/post-command"},
+					},
+					RunCondition: runner.RunConditionAlways,
+				},
 			},
 		},
 		{
@@ -0,0 +1,154 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AnnotationLevel is the level of an [Annotation], named after the command that creates it
+type AnnotationLevel string
+
+const (
+	AnnotationLevelError   AnnotationLevel = "error"   // created by ::error
+	AnnotationLevelWarning AnnotationLevel = "warning" // created by ::warning
+	AnnotationLevelNotice  AnnotationLevel = "notice"  // created by ::notice
+)
+
+// Annotation is a message about a file of the source, printed by a command of an action with e.g.
+// ::error file=app.js,line=10,col=15::Something went wrong
+type Annotation struct {
+	PlanID  string          // ID of the plan of the action
+	Level   AnnotationLevel // level of the annotation
+	Message string          // message of the annotation
+	Title   string          // title of the annotation, if any
+	File    string          // path of the file, as printed by the command
+	Line    int             // line of the file, if any
+	Column  int             // column of the line, if any
+}
+
+// newAnnotation creates the annotation of an ::error, ::warning or ::notice command
+func newAnnotation(planID string, command *actionCommand) *Annotation {
+	annotation := &Annotation{
+		PlanID:  planID,
+		Level:   AnnotationLevel(command.name),
+		Message: command.value,
+		Title:   command.properties["title"],
+		File:    command.properties["file"],
+	}
+	annotation.Line, _ = strconv.Atoi(command.properties["line"])
+	annotation.Column, _ = strconv.Atoi(command.properties["col"])
+	return annotation
+}
+
+// Location returns the file:line:column of the annotation, with the file relative to workingDir if the path printed by
+// the command is in a copy of workingDir. It returns an empty string if the annotation isn't about a file.
+func (a *Annotation) Location(workingDir string) string {
+	if a.File == "" {
+		return ""
+	}
+	location := annotationPath(a.File, workingDir)
+	if a.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, a.Line)
+		if a.Column > 0 {
+			location = fmt.Sprintf("%s:%d", location, a.Column)
+		}
+	}
+	return location
+}
+
+// String returns the annotation as it is logged
+func (a *Annotation) String() string {
+	message := a.Message
+	if a.Title != "" {
+		message = fmt.Sprintf("%s: %s", a.Title, message)
+	}
+	if location := a.Location(""); location != "" {
+		message = fmt.Sprintf("%s: %s", location, message)
+	}
+	return message
+}
+
+// annotationPath returns the path of file relative to workingDir. The commands of an action run in a copy of the working
+// dir at git/v1/<name of working dir>, in a container or in a temporary directory, unless the working dir is bound.
+func annotationPath(file string, workingDir string) string {
+	if workingDir == "" || !filepath.IsAbs(file) {
+		return filepath.Clean(file)
+	}
+	sourceDir := fmt.Sprintf("/git/v1/%s/", filepath.Base(workingDir))
+	if i := strings.LastIndex(filepath.ToSlash(file), sourceDir); i >= 0 {
+		return filepath.FromSlash(filepath.ToSlash(file)[i+len(sourceDir):])
+	}
+	if absWorkingDir, err := filepath.Abs(workingDir); err == nil {
+		if relPath, err := filepath.Rel(absWorkingDir, file); err == nil && !strings.HasPrefix(relPath, "..") {
+			return relPath
+		}
+	}
+	return file
+}
+
+// Annotations collects the annotations of the actions of a workflow run
+type Annotations struct {
+	annotations []*Annotation
+	mutex       sync.Mutex
+}
+
+// Add adds an annotation
+func (as *Annotations) Add(annotation *Annotation) {
+	if as == nil {
+		return
+	}
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+	as.annotations = append(as.annotations, annotation)
+}
+
+// Annotations returns the annotations in the order they were added
+func (as *Annotations) Annotations() []*Annotation {
+	as.mutex.Lock()
+	defer as.mutex.Unlock()
+	return append([]*Annotation(nil), as.annotations...)
+}
+
+// Log logs the annotations grouped by plan, with their files relative to workingDir
+func (as *Annotations) Log(ctx context.Context, workingDir string) {
+	annotations := as.Annotations()
+	if len(annotations) == 0 {
+		return
+	}
+	counts := make(map[AnnotationLevel]int)
+	planIDs := make([]string, 0)
+	byPlan := make(map[string][]*Annotation)
+	for _, annotation := range annotations {
+		counts[annotation.Level]++
+		if _, ok := byPlan[annotation.PlanID]; !ok {
+			planIDs = append(planIDs, annotation.PlanID)
+		}
+		byPlan[annotation.PlanID] = append(byPlan[annotation.PlanID], annotation)
+	}
+	log.Ctx(ctx).Info().Msgf("📌 %d errors, %d warnings and %d notices annotated", counts[AnnotationLevelError], counts[AnnotationLevelWarning], counts[AnnotationLevelNotice])
+	for _, planID := range planIDs {
+		log.Ctx(ctx).Info().Msgf("   %s", planID)
+		for _, annotation := range byPlan[planID] {
+			message := annotation.Message
+			if annotation.Title != "" {
+				message = fmt.Sprintf("%s: %s", annotation.Title, message)
+			}
+			if location := annotation.Location(workingDir); location != "" {
+				message = fmt.Sprintf("%s %s", location, message)
+			}
+			log.Ctx(ctx).Info().Msgf("     %s %s", annotationIcons[annotation.Level], message)
+		}
+	}
+}
+
+var annotationIcons = map[AnnotationLevel]string{
+	AnnotationLevelError:   "❌",
+	AnnotationLevelWarning: "⚠️ ",
+	AnnotationLevelNotice:  "ℹ️ ",
+}
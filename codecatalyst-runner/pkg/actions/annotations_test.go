@@ -0,0 +1,62 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotations(t *testing.T) {
+	assert := assert.New(t)
+	out := new(bytes.Buffer)
+	ctx := zerolog.New(out).Level(zerolog.InfoLevel).WithContext(context.Background())
+	plan := new(actionPlan)
+	plan.id = "Build"
+	plan.environmentConfiguration = &runner.EnvironmentConfiguration{}
+	plan.action = &Action{ID: "Build"}
+	m := new(runner.MockPlanExecutor).WithPlan(plan).WithExecutor(func(ctx context.Context) error {
+		for _, line := range []string{
+			"::group::Compile",
+			"::error file=/codecatalyst/output/src/git/v1/my-app/src/app.go,line=10,col=5,title=Compile error::undefined: foo",
+			"::endgroup::",
+			"::warning file=README.md::broken link%0Aline two",
+			"::notice::done",
+			"::debug::hidden",
+		} {
+			fmt.Fprintln(plan.environmentConfiguration.Stdout, line)
+		}
+		return nil
+	})
+	m.OnExecute(ctx).Return(nil)
+
+	annotations := new(Annotations)
+	assert.NoError(m.Execute(ctx, ActionOutputHandler(make(map[string]string), annotations, nil, false)))
+	assert.Equal([]*Annotation{
+		{PlanID: "Build", Level: AnnotationLevelError, Title: "Compile error", Message: "undefined: foo", File: "/codecatalyst/output/src/git/v1/my-app/src/app.go", Line: 10, Column: 5},
+		{PlanID: "Build", Level: AnnotationLevelWarning, Message: "broken link\nline two", File: "README.md"},
+		{PlanID: "Build", Level: AnnotationLevelNotice, Message: "done"},
+	}, annotations.Annotations())
+	assert.Equal("src/app.go:10:5", annotations.Annotations()[0].Location("/home/me/my-app"))
+	assert.Equal("src/app.go:3", (&Annotation{File: "/home/me/my-app/src/app.go", Line: 3}).Location("/home/me/my-app"))
+	assert.Equal("/tmp/app.go:1", (&Annotation{File: "/tmp/app.go", Line: 1}).Location("/home/me/my-app"))
+	assert.Equal("", annotations.Annotations()[2].Location("/home/me/my-app"))
+
+	// groups are tagged for the TUI, annotations are logged at their level, and debug lines are hidden
+	logs := out.String()
+	assert.Contains(logs, `"group":"start","message":"▶ Compile"`)
+	assert.Contains(logs, `"group":"end","message":"◀ Compile"`)
+	assert.Contains(logs, `"level":"error","stream":"stdout","message":"/codecatalyst/output/src/git/v1/my-app/src/app.go:10:5: Compile error: undefined: foo"`)
+	assert.Contains(logs, `"level":"warn"`)
+	assert.NotContains(logs, "hidden")
+
+	logOut := new(bytes.Buffer)
+	annotations.Log(zerolog.New(logOut).WithContext(context.Background()), "/home/me/my-app")
+	assert.Contains(logOut.String(), "1 errors, 1 warnings and 1 notices annotated")
+	assert.Contains(logOut.String(), "❌ src/app.go:10:5 Compile error: undefined: foo")
+}
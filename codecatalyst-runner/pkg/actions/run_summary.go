@@ -0,0 +1,122 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sync"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+)
+
+// templateVariablePattern matches the placeholders of a message template, e.g. {name} or {{ name }}
+var templateVariablePattern = regexp.MustCompile(`\{\{?\s*([A-Za-z0-9_.-]+)\s*\}?\}`)
+
+// Render returns the message with its TemplateVariables applied. The ADK sets Message to the JSON encoding of the
+// template, which is decoded first. Placeholders without a variable are left as they are, and the message falls back
+// to Text if it is empty.
+func (m *ActionRunSummaryMessage) Render() string {
+	message := m.Message
+	var decoded string
+	if err := json.Unmarshal([]byte(message), &decoded); err == nil {
+		message = decoded
+	}
+	if message == "" {
+		return m.Text
+	}
+	variables := make(map[string]string)
+	for _, variable := range m.TemplateVariables {
+		variables[variable.Name] = variable.Value
+	}
+	return templateVariablePattern.ReplaceAllStringFunc(message, func(placeholder string) string {
+		name := templateVariablePattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// SummaryMessage returns the rendered message, with the secrets masked
+func (m *ActionRunSummaryMessage) SummaryMessage() *runner.SummaryMessage {
+	return &runner.SummaryMessage{
+		Level:   string(m.Level),
+		Code:    m.Text,
+		Message: common.Mask(m.Render()),
+	}
+}
+
+// RunSummaries collects the summary messages of the actions of a workflow run, by plan ID
+type RunSummaries struct {
+	planIDs   []string
+	summaries map[string][]*runner.SummaryMessage
+	mutex     sync.Mutex
+}
+
+// Set sets the summary messages of a plan, replacing those of a previous attempt
+func (rs *RunSummaries) Set(planID string, messages []*runner.SummaryMessage) {
+	if rs == nil {
+		return
+	}
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	if rs.summaries == nil {
+		rs.summaries = make(map[string][]*runner.SummaryMessage)
+	}
+	if _, ok := rs.summaries[planID]; !ok {
+		rs.planIDs = append(rs.planIDs, planID)
+	}
+	rs.summaries[planID] = messages
+}
+
+// Get returns the summary messages of a plan, if any
+func (rs *RunSummaries) Get(planID string) []*runner.SummaryMessage {
+	if rs == nil {
+		return nil
+	}
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	return rs.summaries[planID]
+}
+
+// Log logs the summary messages grouped by plan, in the order the plans set them
+func (rs *RunSummaries) Log(ctx context.Context) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	if len(rs.planIDs) == 0 {
+		return
+	}
+	log.Ctx(ctx).Info().Msgf("📋 Summary")
+	for _, planID := range rs.planIDs {
+		log.Ctx(ctx).Info().Msgf("   %s", planID)
+		for _, message := range rs.summaries[planID] {
+			log.Ctx(ctx).Info().Msgf("     %s %s", SummaryIcon(message.Level), message)
+		}
+	}
+}
+
+// SummaryIcon returns the icon of the level of a summary message
+func SummaryIcon(level string) string {
+	switch ActionRunSummaryLevel(level) {
+	case ActionRunSummaryLevelError:
+		return "❌"
+	case ActionRunSummaryLevelWarning:
+		return "⚠️ "
+	default:
+		return "ℹ️ "
+	}
+}
+
+// summaryMessages returns the rendered messages of an ACTION_RUN_SUMMARY output, without the empty ones
+func summaryMessages(actionRunSummaries []ActionRunSummaryMessage) []*runner.SummaryMessage {
+	messages := make([]*runner.SummaryMessage, 0, len(actionRunSummaries))
+	for i := range actionRunSummaries {
+		if message := actionRunSummaries[i].SummaryMessage(); message.Message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,92 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionRunSummaryMessageRender(t *testing.T) {
+	for _, tt := range []struct {
+		TestCase string
+		Message  ActionRunSummaryMessage
+		Expected string
+	}{
+		{
+			TestCase: "Plain message",
+			Message:  ActionRunSummaryMessage{Text: "CUSTOM", Message: "Build failed"},
+			Expected: "Build failed",
+		},
+		{
+			TestCase: "JSON encoded message",
+			Message:  ActionRunSummaryMessage{Text: "CUSTOM", Message: `"Build failed\nin main"`},
+			Expected: "Build failed\nin main",
+		},
+		{
+			TestCase: "Template variables",
+			Message: ActionRunSummaryMessage{
+				Message: "{{ count }} tests failed in {suite}, see {report}",
+				TemplateVariables: []ActionRunTemplateVariable{
+					{Name: "count", Value: "2"},
+					{Name: "suite", Value: "unit"},
+				},
+			},
+			Expected: "2 tests failed in unit, see {report}",
+		},
+		{
+			TestCase: "Empty message",
+			Message:  ActionRunSummaryMessage{Text: "UNKNOWN_ERROR"},
+			Expected: "UNKNOWN_ERROR",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert.Equal(t, tt.Expected, tt.Message.Render())
+		})
+	}
+}
+
+type summaryEventSink []*runner.Event
+
+func (s *summaryEventSink) Emit(event *runner.Event) {
+	*s = append(*s, event)
+}
+
+func TestActionOutputHandlerSummary(t *testing.T) {
+	assert := assert.New(t)
+	out := new(bytes.Buffer)
+	events := new(summaryEventSink)
+	ctx := runner.WithEventSink(zerolog.New(out).WithContext(context.Background()), events)
+	plan := new(actionPlan)
+	plan.environmentConfiguration = &runner.EnvironmentConfiguration{}
+	plan.id = "Summary"
+	plan.action = &Action{ID: "Summary"}
+	m := new(runner.MockPlanExecutor).WithPlan(plan).WithExecutor(func(ctx context.Context) error {
+		fmt.Fprintln(plan.environmentConfiguration.Stdout, `::set-output name=ACTION_RUN_SUMMARY::[{"level":"Warning","text":"CUSTOM","message":"\"{count} files skipped\"","templateVariables":[{"name":"count","value":"3"}]},{"level":"Info","text":"CUSTOM","message":"\"done\""}]`)
+		return fmt.Errorf("mock-error")
+	})
+	m.OnExecute(ctx).Return(nil)
+	summaries := new(RunSummaries)
+
+	// the summary of a failed action is kept, and only the error level fails the action
+	assert.EqualError(m.Execute(ctx, ActionOutputHandler(make(map[string]string), nil, summaries, false)), "mock-error")
+	expected := []*runner.SummaryMessage{
+		{Level: "Warning", Code: "CUSTOM", Message: "3 files skipped"},
+		{Level: "Info", Code: "CUSTOM", Message: "done"},
+	}
+	assert.Equal(expected, summaries.Get("Summary"))
+	assert.Len(*events, 1)
+	assert.Equal(runner.EventSummarySet, (*events)[0].Type)
+	assert.Equal(expected, (*events)[0].Summary)
+	assert.Contains(out.String(), `"level":"warn","message":"    ⚠️  [CUSTOM] 3 files skipped"`)
+
+	out.Reset()
+	summaries.Log(ctx)
+	assert.Contains(out.String(), "📋 Summary")
+	assert.Contains(out.String(), "     ℹ️  [CUSTOM] done")
+}
@@ -0,0 +1,367 @@
+package workflows
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxCoverageOffenders is the number of files with the lowest line coverage that are logged for a report
+const maxCoverageOffenders = 5
+
+// FileCoverage is the coverage of a source file found in a coverage report
+type FileCoverage struct {
+	Name            string // name of the source file
+	LinesCovered    int    // number of lines that were covered by tests
+	LinesTotal      int    // number of lines that can be covered
+	BranchesCovered int    // number of branches that were covered by tests
+	BranchesTotal   int    // number of branches that can be covered
+}
+
+// lineCoverage returns the percentage of lines that were covered, or 100 if the file has no lines
+func (fc *FileCoverage) lineCoverage() float32 {
+	return percentage(fc.LinesCovered, fc.LinesTotal)
+}
+
+func percentage(covered int, total int) float32 {
+	if total == 0 {
+		return 100
+	}
+	return float32(covered) * 100 / float32(total)
+}
+
+// coverageFiles collects the coverage of the files of a report, merging the coverage of a file found several times
+type coverageFiles struct {
+	files []*FileCoverage
+	index map[string]*FileCoverage
+}
+
+func (cf *coverageFiles) add(name string, linesCovered int, linesTotal int, branchesCovered int, branchesTotal int) {
+	if cf.index == nil {
+		cf.index = make(map[string]*FileCoverage)
+	}
+	file, ok := cf.index[name]
+	if !ok {
+		file = &FileCoverage{Name: name}
+		cf.index[name] = file
+		cf.files = append(cf.files, file)
+	}
+	file.LinesCovered += linesCovered
+	file.LinesTotal += linesTotal
+	file.BranchesCovered += branchesCovered
+	file.BranchesTotal += branchesTotal
+}
+
+func (cf *coverageFiles) addTo(report *Report) {
+	for _, file := range cf.files {
+		report.CoverageFiles = append(report.CoverageFiles, *file)
+	}
+}
+
+// updateCoverage sets the LineCoverage and BranchCoverage of the report from its files
+func updateCoverage(report *Report) {
+	if len(report.CoverageFiles) == 0 {
+		return
+	}
+	var linesCovered, linesTotal, branchesCovered, branchesTotal int
+	for _, file := range report.CoverageFiles {
+		linesCovered += file.LinesCovered
+		linesTotal += file.LinesTotal
+		branchesCovered += file.BranchesCovered
+		branchesTotal += file.BranchesTotal
+	}
+	lineCoverage := percentage(linesCovered, linesTotal)
+	report.LineCoverage = &lineCoverage
+	if branchesTotal > 0 {
+		branchCoverage := percentage(branchesCovered, branchesTotal)
+		report.BranchCoverage = &branchCoverage
+	}
+}
+
+// logCoverage logs the coverage of the report, along with the files with the lowest line coverage
+func logCoverage(ctx context.Context, name string, report *Report) {
+	if report.LineCoverage == nil {
+		return
+	}
+	branchCoverage := "n/a"
+	if report.BranchCoverage != nil {
+		branchCoverage = fmt.Sprintf("%.1f%%", *report.BranchCoverage)
+	}
+	log.Ctx(ctx).Info().Msgf("📈 %s: %.1f%% line coverage, %s branch coverage", name, *report.LineCoverage, branchCoverage)
+
+	offenders := make([]FileCoverage, 0, len(report.CoverageFiles))
+	for _, file := range report.CoverageFiles {
+		if file.LinesCovered < file.LinesTotal {
+			offenders = append(offenders, file)
+		}
+	}
+	sort.SliceStable(offenders, func(i, j int) bool {
+		return offenders[i].lineCoverage() < offenders[j].lineCoverage()
+	})
+	for i, file := range offenders {
+		if i == maxCoverageOffenders {
+			break
+		}
+		log.Ctx(ctx).Info().Msgf("   %5.1f%% %s (%d of %d lines)", file.lineCoverage(), file.Name, file.LinesCovered, file.LinesTotal)
+	}
+}
+
+var conditionCoveragePattern = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+// coberturaReportHandler reads the coverage of Cobertura XML reports
+func coberturaReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var coverage struct {
+			Classes []struct {
+				Filename string `xml:"filename,attr"`
+				Lines    []struct {
+					Hits              int    `xml:"hits,attr"`
+					Branch            bool   `xml:"branch,attr"`
+					ConditionCoverage string `xml:"condition-coverage,attr"`
+				} `xml:"lines>line"`
+			} `xml:"packages>package>classes>class"`
+		}
+		if !decodeXMLReport(reader, &coverage, "coverage") || len(coverage.Classes) == 0 {
+			log.Debug().Msgf("Skipping non-cobertura report")
+			return nil
+		}
+		files := new(coverageFiles)
+		for _, class := range coverage.Classes {
+			var linesCovered, branchesCovered, branchesTotal int
+			for _, line := range class.Lines {
+				if line.Hits > 0 {
+					linesCovered++
+				}
+				if match := conditionCoveragePattern.FindStringSubmatch(line.ConditionCoverage); line.Branch && match != nil {
+					covered, _ := strconv.Atoi(match[1])
+					total, _ := strconv.Atoi(match[2])
+					branchesCovered += covered
+					branchesTotal += total
+				}
+			}
+			files.add(class.Filename, linesCovered, len(class.Lines), branchesCovered, branchesTotal)
+		}
+		files.addTo(report)
+		return nil
+	}
+}
+
+type jacocoCounter struct {
+	Type    string `xml:"type,attr"`
+	Missed  int    `xml:"missed,attr"`
+	Covered int    `xml:"covered,attr"`
+}
+
+// jacocoReportHandler reads the coverage of JaCoCo XML reports
+func jacocoReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var jacoco struct {
+			Packages []struct {
+				Name        string `xml:"name,attr"`
+				SourceFiles []struct {
+					Name     string          `xml:"name,attr"`
+					Counters []jacocoCounter `xml:"counter"`
+				} `xml:"sourcefile"`
+			} `xml:"package"`
+		}
+		if !decodeXMLReport(reader, &jacoco, "report") {
+			log.Debug().Msgf("Skipping non-jacoco report")
+			return nil
+		}
+		files := new(coverageFiles)
+		for _, pkg := range jacoco.Packages {
+			for _, sourceFile := range pkg.SourceFiles {
+				var lines, branches jacocoCounter
+				for _, counter := range sourceFile.Counters {
+					switch counter.Type {
+					case "LINE":
+						lines = counter
+					case "BRANCH":
+						branches = counter
+					}
+				}
+				files.add(path.Join(pkg.Name, sourceFile.Name), lines.Covered, lines.Covered+lines.Missed, branches.Covered, branches.Covered+branches.Missed)
+			}
+		}
+		files.addTo(report)
+		return nil
+	}
+}
+
+type cloverFile struct {
+	Name    string `xml:"name,attr"`
+	Path    string `xml:"path,attr"`
+	Metrics struct {
+		Statements          int `xml:"statements,attr"`
+		CoveredStatements   int `xml:"coveredstatements,attr"`
+		Conditionals        int `xml:"conditionals,attr"`
+		CoveredConditionals int `xml:"coveredconditionals,attr"`
+	} `xml:"metrics"`
+}
+
+// cloverReportHandler reads the coverage of Clover XML reports. Statements are counted as lines and conditionals as branches.
+func cloverReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var coverage struct {
+			Project struct {
+				Files        []cloverFile `xml:"file"`
+				PackageFiles []cloverFile `xml:"package>file"`
+			} `xml:"project"`
+		}
+		if !decodeXMLReport(reader, &coverage, "coverage") {
+			log.Debug().Msgf("Skipping non-clover report")
+			return nil
+		}
+		files := new(coverageFiles)
+		for _, file := range append(coverage.Project.Files, coverage.Project.PackageFiles...) {
+			name := file.Path
+			if name == "" {
+				name = file.Name
+			}
+			files.add(name, file.Metrics.CoveredStatements, file.Metrics.Statements, file.Metrics.CoveredConditionals, file.Metrics.Conditionals)
+		}
+		files.addTo(report)
+		return nil
+	}
+}
+
+// lcovReportHandler reads the coverage of LCOV tracefiles. The LF, LH, BRF and BRH summaries of a record are used if present,
+// otherwise the DA and BRDA lines are counted.
+func lcovReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		files := new(coverageFiles)
+		type record struct {
+			name                                                     string
+			linesCovered, linesTotal, branchesCovered, branchesTotal int
+			summary                                                  map[string]int
+		}
+		var current *record
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+			if current == nil {
+				if key == "SF" {
+					current = &record{name: value, summary: make(map[string]int)}
+				} else if key != "TN" && key != "" && len(files.files) == 0 {
+					log.Debug().Msgf("Skipping non-lcov report")
+					return nil
+				}
+				continue
+			}
+			switch key {
+			case "DA":
+				current.linesTotal++
+				if fields := strings.Split(value, ","); len(fields) >= 2 && fields[1] != "0" {
+					current.linesCovered++
+				}
+			case "BRDA":
+				current.branchesTotal++
+				if fields := strings.Split(value, ","); len(fields) == 4 && fields[3] != "-" && fields[3] != "0" {
+					current.branchesCovered++
+				}
+			case "LF", "LH", "BRF", "BRH":
+				current.summary[key], _ = strconv.Atoi(value)
+			case "end_of_record":
+				for key, count := range map[string]*int{"LF": &current.linesTotal, "LH": &current.linesCovered, "BRF": &current.branchesTotal, "BRH": &current.branchesCovered} {
+					if summary, ok := current.summary[key]; ok {
+						*count = summary
+					}
+				}
+				files.add(current.name, current.linesCovered, current.linesTotal, current.branchesCovered, current.branchesTotal)
+				current = nil
+			}
+		}
+		files.addTo(report)
+		return nil
+	}
+}
+
+// simplecovReportHandler reads the coverage of SimpleCov .resultset.json files. The coverage of the commands in the
+// result set is merged.
+func simplecovReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var resultSet map[string]struct {
+			Coverage map[string]json.RawMessage `json:"coverage"`
+		}
+		if err := json.NewDecoder(reader).Decode(&resultSet); err != nil {
+			log.Debug().Err(err).Msgf("Skipping non-simplecov report")
+			return nil
+		}
+		type simplecovFile struct {
+			lines    []*int
+			branches map[string]int
+		}
+		merged := make(map[string]*simplecovFile)
+		for _, result := range resultSet {
+			for name, raw := range result.Coverage {
+				var fileCoverage struct {
+					Lines    []*int                    `json:"lines"`
+					Branches map[string]map[string]int `json:"branches"`
+				}
+				// the legacy format only has the coverage of the lines
+				if err := json.Unmarshal(raw, &fileCoverage.Lines); err != nil {
+					if err := json.Unmarshal(raw, &fileCoverage); err != nil {
+						log.Debug().Err(err).Msgf("Skipping non-simplecov report")
+						return nil
+					}
+				}
+				file, ok := merged[name]
+				if !ok {
+					file = &simplecovFile{branches: make(map[string]int)}
+					merged[name] = file
+				}
+				for i, hits := range fileCoverage.Lines {
+					if i == len(file.lines) {
+						file.lines = append(file.lines, nil)
+					}
+					if hits != nil {
+						sum := *hits
+						if file.lines[i] != nil {
+							sum += *file.lines[i]
+						}
+						file.lines[i] = &sum
+					}
+				}
+				for condition, branches := range fileCoverage.Branches {
+					for branch, hits := range branches {
+						file.branches[condition+branch] += hits
+					}
+				}
+			}
+		}
+		names := make([]string, 0, len(merged))
+		for name := range merged {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		files := new(coverageFiles)
+		for _, name := range names {
+			var linesCovered, linesTotal, branchesCovered int
+			for _, hits := range merged[name].lines {
+				if hits != nil {
+					linesTotal++
+					if *hits > 0 {
+						linesCovered++
+					}
+				}
+			}
+			for _, hits := range merged[name].branches {
+				if hits > 0 {
+					branchesCovered++
+				}
+			}
+			files.add(name, linesCovered, linesTotal, branchesCovered, len(merged[name].branches))
+		}
+		files.addTo(report)
+		return nil
+	}
+}
@@ -0,0 +1,170 @@
+package workflows
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageReportHandlers(t *testing.T) {
+	type TestParams struct {
+		TestCase              string
+		Format                ReportFormat
+		ReportPath            string
+		ExpectedCoverageFiles []FileCoverage
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:   "Cobertura",
+			Format:     ReportFormatCoberturaXML,
+			ReportPath: "testdata/reports/coverage/cobertura.xml",
+			ExpectedCoverageFiles: []FileCoverage{
+				{Name: "cart/cart.py", LinesCovered: 3, LinesTotal: 4, BranchesCovered: 1, BranchesTotal: 2},
+				{Name: "cart/discount.py", LinesCovered: 1, LinesTotal: 2},
+			},
+		},
+		{
+			TestCase:   "JaCoCo",
+			Format:     ReportFormatJaCoCoXML,
+			ReportPath: "testdata/reports/coverage/jacoco.xml",
+			ExpectedCoverageFiles: []FileCoverage{
+				{Name: "com/example/Cart.java", LinesCovered: 3, LinesTotal: 4, BranchesCovered: 3, BranchesTotal: 4},
+				{Name: "com/example/Discount.java", LinesCovered: 0, LinesTotal: 2},
+			},
+		},
+		{
+			TestCase:   "Clover",
+			Format:     ReportFormatCloverXML,
+			ReportPath: "testdata/reports/coverage/clover.xml",
+			ExpectedCoverageFiles: []FileCoverage{
+				{Name: "src/bootstrap.php", LinesCovered: 0, LinesTotal: 2},
+				{Name: "src/cart.php", LinesCovered: 7, LinesTotal: 8, BranchesCovered: 2, BranchesTotal: 4},
+			},
+		},
+		{
+			TestCase:   "LCOV",
+			Format:     ReportFormatLCOV,
+			ReportPath: "testdata/reports/coverage/lcov.info",
+			ExpectedCoverageFiles: []FileCoverage{
+				{Name: "src/cart.js", LinesCovered: 2, LinesTotal: 3, BranchesCovered: 1, BranchesTotal: 2},
+				{Name: "src/discount.js", LinesCovered: 2, LinesTotal: 4},
+			},
+		},
+		{
+			TestCase:   "SimpleCov",
+			Format:     ReportFormatSimpleCov,
+			ReportPath: "testdata/reports/coverage/.resultset.json",
+			ExpectedCoverageFiles: []FileCoverage{
+				{Name: "/app/lib/cart.rb", LinesCovered: 4, LinesTotal: 4, BranchesCovered: 1, BranchesTotal: 2},
+				{Name: "/app/lib/discount.rb", LinesCovered: 1, LinesTotal: 2},
+			},
+		},
+		{
+			TestCase:   "Cobertura handler with Clover report",
+			Format:     ReportFormatCoberturaXML,
+			ReportPath: "testdata/reports/coverage/clover.xml",
+		},
+		{
+			TestCase:   "LCOV handler with JUnit report",
+			Format:     ReportFormatLCOV,
+			ReportPath: "testdata/reports/tests/junit.xml",
+		},
+		{
+			TestCase:   "SimpleCov handler with SARIF report",
+			Format:     ReportFormatSimpleCov,
+			ReportPath: "testdata/reports/sarif-high-severity/sarif.json",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			handlers := reportHandlers(tt.Format)
+			assert.Len(handlers, 1)
+			reportFile, err := os.Open(tt.ReportPath)
+			assert.NoError(err)
+			defer reportFile.Close()
+
+			// run the handler
+			report := new(Report)
+			err = handlers[0](reportFile, report)
+
+			// assert the results
+			assert.NoError(err)
+			assert.Equal(tt.ExpectedCoverageFiles, report.CoverageFiles)
+		})
+	}
+}
+
+func TestCoverageReportCriteria(t *testing.T) {
+	type TestParams struct {
+		TestCase               string
+		Format                 ReportFormat
+		SuccessCriteria        SuccessCriteria
+		ExpectedLineCoverage   float32
+		ExpectedBranchCoverage float32
+		ExpectedResult         Result
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:               "Coverage met",
+			Format:                 ReportFormatLCOV,
+			SuccessCriteria:        SuccessCriteria{LineCoverage: 50, BranchCoverage: 50},
+			ExpectedLineCoverage:   float32(100) * 4 / 7,
+			ExpectedBranchCoverage: 50,
+			ExpectedResult:         ResultSucceeded,
+		},
+		{
+			TestCase:               "Line coverage not met",
+			Format:                 ReportFormatJaCoCoXML,
+			SuccessCriteria:        SuccessCriteria{LineCoverage: 60},
+			ExpectedLineCoverage:   50,
+			ExpectedBranchCoverage: 75,
+			ExpectedResult:         ResultFailed,
+		},
+		{
+			TestCase:               "Branch coverage not met",
+			Format:                 ReportFormatCoberturaXML,
+			SuccessCriteria:        SuccessCriteria{LineCoverage: 60, BranchCoverage: 60},
+			ExpectedLineCoverage:   float32(100) * 4 / 6,
+			ExpectedBranchCoverage: 50,
+			ExpectedResult:         ResultFailed,
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			ctx := context.Background()
+			report := new(Report)
+			config := &ReportConfig{
+				Format:          tt.Format,
+				SuccessCriteria: &tt.SuccessCriteria,
+			}
+			feature := ReportProcessor("coverage", config, report, "testdata/reports/coverage")
+
+			// setup the mock
+			m := new(runner.MockPlanExecutor)
+			m.OnExecute(ctx).Return(nil)
+
+			// run the feature
+			err := m.Execute(ctx, feature)
+
+			// assert the results
+			if tt.ExpectedResult == ResultSucceeded {
+				assert.NoError(err)
+			} else {
+				assert.EqualError(err, "report coverage status FAILED")
+			}
+			assert.Equal(tt.ExpectedResult, report.Result)
+			if assert.NotNil(report.LineCoverage) && assert.NotNil(report.BranchCoverage) {
+				assert.InDelta(tt.ExpectedLineCoverage, *report.LineCoverage, 0.01)
+				assert.InDelta(tt.ExpectedBranchCoverage, *report.BranchCoverage, 0.01)
+			}
+		})
+	}
+}
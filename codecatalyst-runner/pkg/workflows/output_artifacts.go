@@ -51,6 +51,15 @@ func OutputArtifacts(planID string, out []*OutputArtifact, artifactPlans map[str
 			}
 		}
 		err := e(ctx)
+		if err == nil {
+			for _, artifact := range out {
+				runner.EmitEvent(ctx, &runner.Event{
+					Type:     runner.EventArtifactProduced,
+					Artifact: artifact.Name,
+					Path:     filepath.Join(cacheDir, "artifacts", artifact.Name),
+				})
+			}
+		}
 
 		log.Ctx(ctx).Debug().Msg("EXIT OutputArtifacts")
 		return err
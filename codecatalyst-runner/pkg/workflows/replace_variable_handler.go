@@ -68,6 +68,7 @@ func (v *variableReplacer) Replace(ctx context.Context, plan runner.Plan, value
 				log.Ctx(ctx).Err(err).Msg("unable to get secret")
 				rtnError = err
 			} else {
+				common.AddMask(secretValue)
 				plan.EnvironmentConfiguration().Env[secretEnvName] = secretValue
 			}
 			log.Ctx(ctx).Debug().Msgf("Replacing SECRET %s with %s", varName, secretEnvName)
@@ -0,0 +1,202 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ReportFormat describes the format of a report
+type ReportFormat string
+
+const (
+	// ReportFormatJUnitXML is a JUnit XML test report
+	ReportFormatJUnitXML ReportFormat = "JUNITXML"
+	// ReportFormatTestNG is a TestNG XML test report
+	ReportFormatTestNG ReportFormat = "TESTNG"
+	// ReportFormatCucumberJSON is a Cucumber JSON test report
+	ReportFormatCucumberJSON ReportFormat = "CUCUMBERJSON"
+	// ReportFormatVisualStudioTRX is a Visual Studio TRX test report
+	ReportFormatVisualStudioTRX ReportFormat = "VISUALSTUDIOTRX"
+	// ReportFormatNUnitXML is an NUnit XML test report
+	ReportFormatNUnitXML ReportFormat = "NUNITXML"
+	// ReportFormatNUnit3XML is an NUnit 3 XML test report
+	ReportFormatNUnit3XML ReportFormat = "NUNIT3XML"
+	// ReportFormatCoberturaXML is a Cobertura XML coverage report
+	ReportFormatCoberturaXML ReportFormat = "COBERTURAXML"
+	// ReportFormatJaCoCoXML is a JaCoCo XML coverage report
+	ReportFormatJaCoCoXML ReportFormat = "JACOCOXML"
+	// ReportFormatCloverXML is a Clover XML coverage report
+	ReportFormatCloverXML ReportFormat = "CLOVERXML"
+	// ReportFormatLCOV is an LCOV coverage report
+	ReportFormatLCOV ReportFormat = "LCOV"
+	// ReportFormatSimpleCov is a SimpleCov JSON coverage report
+	ReportFormatSimpleCov ReportFormat = "SIMPLECOV"
+	// ReportFormatSARIFSCA is a SARIF software composition analysis report
+	ReportFormatSARIFSCA ReportFormat = "SARIFSCA"
+	// ReportFormatSARIFSA is a SARIF static analysis report
+	ReportFormatSARIFSA ReportFormat = "SARIFSA"
+)
+
+// ReportConfig describes the files of a report produced by an action. Paths are relative to the WorkflowSource.
+type ReportConfig struct {
+	Format          ReportFormat     `yaml:"Format"`          // format of the report, reports of any format are processed if empty
+	IncludePaths    []string         `yaml:"IncludePaths"`    // patterns of the files to include, e.g. reports/**/*.xml. Defaults to **/*
+	ExcludePaths    []string         `yaml:"ExcludePaths"`    // patterns of the files to exclude
+	SuccessCriteria *SuccessCriteria `yaml:"SuccessCriteria"` // results required for the action to pass
+}
+
+// AutoDiscoverReports describes the reports discovered in the files of an action, whatever their format
+type AutoDiscoverReports struct {
+	Enabled          bool             `yaml:"Enabled"`          // discover the reports of the action
+	ReportNamePrefix string           `yaml:"ReportNamePrefix"` // prefix of the name of the discovered reports
+	IncludePaths     []string         `yaml:"IncludePaths"`     // patterns of the files to include. Defaults to **/*
+	ExcludePaths     []string         `yaml:"ExcludePaths"`     // patterns of the files to exclude
+	SuccessCriteria  *SuccessCriteria `yaml:"SuccessCriteria"`  // results required for the action to pass
+}
+
+// actionReports returns the reports of an action keyed by name, including the discovered reports if enabled
+func actionReports(action *Action) map[string]*ReportConfig {
+	reports := make(map[string]*ReportConfig)
+	for name, report := range action.Outputs.Reports {
+		if report != nil {
+			reports[name] = report
+		}
+	}
+	if autoDiscover := action.Outputs.AutoDiscoverReports; autoDiscover.Enabled {
+		name := autoDiscover.ReportNamePrefix
+		if name == "" {
+			name = "AutoDiscovered"
+		}
+		reports[name] = &ReportConfig{
+			IncludePaths:    autoDiscover.IncludePaths,
+			ExcludePaths:    autoDiscover.ExcludePaths,
+			SuccessCriteria: autoDiscover.SuccessCriteria,
+		}
+	}
+	return reports
+}
+
+// sortedReportNames returns the names of the reports in sorted order
+func sortedReportNames(reports map[string]*ReportConfig) []string {
+	names := make([]string, 0, len(reports))
+	for name := range reports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReportCollector is a Feature that copies the files that may match the include paths of a report to reportDir
+// once the action has run. The contents of reportDir from previous runs are removed.
+func ReportCollector(config *ReportConfig, reportDir string) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER ReportCollector")
+		if err := os.RemoveAll(reportDir); err != nil {
+			return fmt.Errorf("unable to clean report directory: %w", err)
+		}
+		envCfg := plan.EnvironmentConfiguration()
+		for _, dir := range config.baseDirs() {
+			envCfg.FileMaps = append(envCfg.FileMaps, &runner.FileMap{
+				Type:       runner.FileMapTypeCopyOut,
+				SourcePath: fmt.Sprintf("%s/.", filepath.Join("git", "v1", filepath.Base(envCfg.WorkingDir), dir)),
+				TargetPath: filepath.Join(reportDir, dir),
+			})
+		}
+		err := e(ctx)
+		log.Ctx(ctx).Debug().Msg("EXIT ReportCollector")
+		return err
+	}
+}
+
+// includePaths returns the patterns of the files to include, defaulting to every file
+func (rc *ReportConfig) includePaths() []string {
+	if len(rc.IncludePaths) == 0 {
+		return []string{"**/*"}
+	}
+	return rc.IncludePaths
+}
+
+// baseDirs returns the directories that contain every file matched by the include paths, without glob patterns
+func (rc *ReportConfig) baseDirs() []string {
+	dirs := make([]string, 0)
+	for _, pattern := range rc.includePaths() {
+		segments := strings.Split(path.Clean(strings.TrimPrefix(pattern, "./")), "/")
+		i := 0
+		for i < len(segments)-1 && !strings.ContainsAny(segments[i], "*?[") {
+			i++
+		}
+		dir := path.Join(segments[:i]...)
+		if dir == "" {
+			dir = "."
+		}
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	result := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if len(result) > 0 {
+			last := result[len(result)-1]
+			if dir == last || last == "." || strings.HasPrefix(dir, last+"/") {
+				continue
+			}
+		}
+		result = append(result, dir)
+	}
+	return result
+}
+
+// matches returns true if the slash separated path of a file is included and not excluded by the report
+func (rc *ReportConfig) matches(name string) bool {
+	included := false
+	for _, pattern := range rc.includePaths() {
+		if matchPath(pattern, name) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range rc.ExcludePaths {
+		if matchPath(pattern, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPath returns true if the slash separated name matches the pattern. A ** segment in the pattern matches
+// any number of directories, other segments are matched with [path.Match].
+func matchPath(pattern string, name string) bool {
+	return matchSegments(strings.Split(path.Clean(strings.TrimPrefix(pattern, "./")), "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(patterns []string, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(patterns[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(patterns[0], names[0]); !matched {
+		return false
+	}
+	return matchSegments(patterns[1:], names[1:])
+}
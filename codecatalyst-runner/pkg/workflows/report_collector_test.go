@@ -0,0 +1,96 @@
+package workflows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportCollector(t *testing.T) {
+	type TestParams struct {
+		TestCase         string
+		Config           ReportConfig
+		ExpectedFileMaps []*runner.FileMap
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase: "Default include paths",
+			ExpectedFileMaps: []*runner.FileMap{
+				{Type: runner.FileMapTypeCopyOut, SourcePath: "git/v1/myapp/.", TargetPath: "."},
+			},
+		},
+		{
+			TestCase: "Include paths in the root directory",
+			Config: ReportConfig{
+				IncludePaths: []string{"build/reports/**/*.xml", "build/reports/unit/*.xml", "results.sarif", "coverage/lcov.info"},
+			},
+			ExpectedFileMaps: []*runner.FileMap{
+				{Type: runner.FileMapTypeCopyOut, SourcePath: "git/v1/myapp/.", TargetPath: "."},
+			},
+		},
+		{
+			TestCase: "Nested include paths",
+			Config: ReportConfig{
+				IncludePaths: []string{"build/reports/**/*.xml", "build/reports/unit/*.xml", "./coverage/lcov.info"},
+			},
+			ExpectedFileMaps: []*runner.FileMap{
+				{Type: runner.FileMapTypeCopyOut, SourcePath: "git/v1/myapp/build/reports/.", TargetPath: "build/reports"},
+				{Type: runner.FileMapTypeCopyOut, SourcePath: "git/v1/myapp/coverage/.", TargetPath: "coverage"},
+			},
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			ctx := context.Background()
+			reportDir := t.TempDir()
+			assert.NoError(os.WriteFile(filepath.Join(reportDir, "stale.xml"), []byte("<stale/>"), 0600))
+			feature := ReportCollector(&tt.Config, reportDir)
+
+			// setup the mock
+			plan := new(runner.MockPlan)
+			plan.EnvironmentConfiguration().WorkingDir = "/home/user/myapp"
+			m := new(runner.MockPlanExecutor).WithPlan(plan)
+			m.OnExecute(ctx).Return(nil)
+
+			// run the feature
+			err := m.Execute(ctx, feature)
+
+			// assert the results
+			assert.NoError(err)
+			m.AssertExpectations(t)
+			for _, fileMap := range tt.ExpectedFileMaps {
+				fileMap.TargetPath = filepath.Join(reportDir, fileMap.TargetPath)
+			}
+			assert.Equal(tt.ExpectedFileMaps, plan.EnvironmentConfiguration().FileMaps)
+			assert.NoFileExists(filepath.Join(reportDir, "stale.xml"))
+		})
+	}
+}
+
+func TestReportConfigMatches(t *testing.T) {
+	for _, tt := range []struct {
+		IncludePaths []string
+		ExcludePaths []string
+		Path         string
+		Expected     bool
+	}{
+		{Path: "reports/junit.xml", Expected: true},
+		{IncludePaths: []string{"reports/*.xml"}, Path: "reports/junit.xml", Expected: true},
+		{IncludePaths: []string{"reports/*.xml"}, Path: "reports/unit/junit.xml", Expected: false},
+		{IncludePaths: []string{"reports/**/*.xml"}, Path: "reports/junit.xml", Expected: true},
+		{IncludePaths: []string{"reports/**/*.xml"}, Path: "reports/unit/fast/junit.xml", Expected: true},
+		{IncludePaths: []string{"**/*.sarif"}, Path: "scan.sarif", Expected: true},
+		{IncludePaths: []string{"./scan.sarif"}, Path: "scan.sarif", Expected: true},
+		{IncludePaths: []string{"**/*.xml"}, ExcludePaths: []string{"**/node_modules/**"}, Path: "lib/node_modules/pkg/junit.xml", Expected: false},
+	} {
+		config := &ReportConfig{IncludePaths: tt.IncludePaths, ExcludePaths: tt.ExcludePaths}
+		assert.Equal(t, tt.Expected, config.matches(tt.Path), "%v excluding %v matches %s", tt.IncludePaths, tt.ExcludePaths, tt.Path)
+	}
+}
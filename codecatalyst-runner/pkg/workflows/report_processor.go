@@ -20,11 +20,12 @@ import (
 
 type reportHandler func(reader io.Reader, report *Report) error
 
-// ReportProcessor looks for reports created by the action and fails if they dont meet the successCriteria.
-// Results are saved in the provided report parameter.
+// ReportProcessor looks for the reports of the action in reportDir and fails if they dont meet the SuccessCriteria of the config.
+// Results are saved in the provided report parameter. A failed report doesn't mask the error of a failed action.
 func ReportProcessor(
+	name string,
+	config *ReportConfig,
 	report *Report,
-	successCriteria *SuccessCriteria,
 	reportDir string,
 ) runner.Feature {
 	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
@@ -32,26 +33,43 @@ func ReportProcessor(
 		err := e(ctx)
 		if processError := newReportProcessor(
 			reportDir,
+			config,
 			report,
-			successCriteria,
 		)(ctx); processError != nil {
 			log.Warn().Err(processError).Msg("Failed to process report")
 		}
+		logTestSuites(ctx, name, report)
+		logCoverage(ctx, name, report)
 		if report.Result != ResultSucceeded {
-			err = fmt.Errorf("report status %s", report.Result)
+			if err == nil {
+				err = fmt.Errorf("report %s status %s", name, report.Result)
+			} else {
+				log.Ctx(ctx).Warn().Msgf("report %s status %s", name, report.Result)
+			}
 		}
 		log.Ctx(ctx).Debug().Msg("EXIT ReportProcessor")
 		return err
 	}
 }
 
-func newReportProcessor(reportsDir string, report *Report, successCriteria *SuccessCriteria) common.Executor {
-	handlers := []reportHandler{
-		sarifReportHandler(successCriteria.VulnerabilityThreshold),
+func newReportProcessor(reportsDir string, config *ReportConfig, report *Report) common.Executor {
+	handlers := reportHandlers(config.Format)
+	successCriteria := config.SuccessCriteria
+	if successCriteria == nil {
+		successCriteria = new(SuccessCriteria)
 	}
 	return func(ctx context.Context) error {
+		// a report that can't be parsed must not pass its success criteria
+		if handlers == nil {
+			report.Result = ResultFailed
+			return fmt.Errorf("unsupported report format '%s'", config.Format)
+		}
 		err := filepath.WalkDir(reportsDir, func(path string, d fs.DirEntry, err error) error {
 			if d != nil && d.Type().IsRegular() {
+				relPath, err := filepath.Rel(reportsDir, path)
+				if err != nil || !config.matches(filepath.ToSlash(relPath)) {
+					return nil
+				}
 				for _, handler := range handlers {
 					reportFile, err := os.Open(path)
 					if err != nil {
@@ -68,6 +86,9 @@ func newReportProcessor(reportsDir string, report *Report, successCriteria *Succ
 		if err != nil {
 			return err
 		}
+		updatePassRate(report)
+		updateCoverage(report)
+		successCriteria.evaluate(report)
 		if report.Result == "" {
 			report.Result = ResultSucceeded
 		}
@@ -75,7 +96,54 @@ func newReportProcessor(reportsDir string, report *Report, successCriteria *Succ
 	}
 }
 
-func sarifReportHandler(severityThreshold VulnerabilitySeverity) reportHandler {
+// reportHandlers returns the handlers for reports of the format, every handler if the format is empty, or nil if the format is unsupported
+func reportHandlers(format ReportFormat) []reportHandler {
+	switch format {
+	case ReportFormatJUnitXML:
+		return []reportHandler{junitReportHandler()}
+	case ReportFormatTestNG:
+		return []reportHandler{testngReportHandler()}
+	case ReportFormatCucumberJSON:
+		return []reportHandler{cucumberReportHandler()}
+	case ReportFormatVisualStudioTRX:
+		return []reportHandler{trxReportHandler()}
+	case ReportFormatNUnitXML, ReportFormatNUnit3XML:
+		return []reportHandler{nunitReportHandler()}
+	case ReportFormatCoberturaXML:
+		return []reportHandler{coberturaReportHandler()}
+	case ReportFormatJaCoCoXML:
+		return []reportHandler{jacocoReportHandler()}
+	case ReportFormatCloverXML:
+		return []reportHandler{cloverReportHandler()}
+	case ReportFormatLCOV:
+		return []reportHandler{lcovReportHandler()}
+	case ReportFormatSimpleCov:
+		return []reportHandler{simplecovReportHandler()}
+	case ReportFormatSARIFSCA:
+		return []reportHandler{sarifReportHandler(), cyclonedxVulnerabilityHandler()}
+	case ReportFormatSARIFSA:
+		return []reportHandler{sarifReportHandler()}
+	case "":
+		return []reportHandler{
+			junitReportHandler(),
+			testngReportHandler(),
+			cucumberReportHandler(),
+			trxReportHandler(),
+			nunitReportHandler(),
+			coberturaReportHandler(),
+			jacocoReportHandler(),
+			cloverReportHandler(),
+			lcovReportHandler(),
+			simplecovReportHandler(),
+			sarifReportHandler(),
+			cyclonedxVulnerabilityHandler(),
+		}
+	default:
+		return nil
+	}
+}
+
+func sarifReportHandler() reportHandler {
 	return func(reader io.Reader, report *Report) error {
 		decoder := json.NewDecoder(reader)
 		sarifReport := new(sarif.Report)
@@ -89,10 +157,7 @@ func sarifReportHandler(severityThreshold VulnerabilitySeverity) reportHandler {
 					// only consider results with empty 'kind' or 'kind' of 'fail'
 					if r.Kind == nil || *r.Kind == "" || *r.Kind == "fail" {
 						severity := levelToSeverity(r.Level)
-						log.Debug().Msgf("Got result with severity %s (threshold=%s)", severity, severityThreshold)
-						if severityExceedsThreshold(severityThreshold, severity) && len(r.Suppressions) == 0 {
-							report.Result = ResultFailed
-						}
+						log.Debug().Msgf("Got result with severity %s", severity)
 						report.Vulnerabilities = append(report.Vulnerabilities, Vulnerability{
 							Severity:     severity,
 							RuleID:       safeString(r.RuleID),
@@ -208,6 +273,8 @@ type Report struct {
 	LineCoverage    *float32        `json:"codecatalyst_action_lineCoverage,omitempty"`   // number between 0 and 100 representing the percentage of lines that were covered by tests
 	BranchCoverage  *float32        `json:"codecatalyst_action_branchCoverage,omitempty"` // number between 0 and 100 representing the percentage of branches that were covered by tests
 	Vulnerabilities []Vulnerability `json:"codecatalyst_action_vulnerabilities"`          // list of vulnerabilities found
+	TestSuites      []TestSuite     `json:"-"`                                            // results of the test suites found
+	CoverageFiles   []FileCoverage  `json:"-"`                                            // coverage of the source files found
 }
 
 // Result for a report, either SUCCEEDED or FAILED
@@ -245,10 +312,59 @@ type Suppression struct {
 
 // SuccessCriteria defines the required results of test reports for an action to pass
 type SuccessCriteria struct {
-	PassRate               float32               `yaml:"passRate"`               // number between 0 and 100 representing the percentage of tests that must pass
-	LineCoverage           float32               `yaml:"lineCoverage"`           // number between 0 and 100 representing the percentage of lines that must be covered by tests
-	BranchCoverage         float32               `yaml:"branchCoverage"`         // number between 0 and 100 representing the percentage of branches that must be covered by tests
-	VulnerabilityThreshold VulnerabilitySeverity `yaml:"vulnerabilityThreshold"` // the max severity of the vulnerabilities allowed
+	PassRate               float32               // number between 0 and 100 representing the percentage of tests that must pass
+	LineCoverage           float32               // number between 0 and 100 representing the percentage of lines that must be covered by tests
+	BranchCoverage         float32               // number between 0 and 100 representing the percentage of branches that must be covered by tests
+	VulnerabilityThreshold VulnerabilitySeverity // the min severity of the vulnerabilities that are counted, none are counted if empty
+	VulnerabilityNumber    int                   // the max number of vulnerabilities at or above the threshold allowed
+}
+
+// UnmarshalYAML reads the SuccessCriteria of a report in a workflow
+func (sc *SuccessCriteria) UnmarshalYAML(unmarshal func(any) error) error {
+	var criteria struct {
+		PassRate        float32 `yaml:"PassRate"`
+		LineCoverage    float32 `yaml:"LineCoverage"`
+		BranchCoverage  float32 `yaml:"BranchCoverage"`
+		Vulnerabilities struct {
+			Severity VulnerabilitySeverity `yaml:"Severity"`
+			Number   int                   `yaml:"Number"`
+		} `yaml:"Vulnerabilities"`
+	}
+	if err := unmarshal(&criteria); err != nil {
+		return err
+	}
+	*sc = SuccessCriteria{
+		PassRate:               criteria.PassRate,
+		LineCoverage:           criteria.LineCoverage,
+		BranchCoverage:         criteria.BranchCoverage,
+		VulnerabilityThreshold: criteria.Vulnerabilities.Severity,
+		VulnerabilityNumber:    criteria.Vulnerabilities.Number,
+	}
+	return nil
+}
+
+// evaluate marks the report as failed if its results don't meet the success criteria
+func (sc *SuccessCriteria) evaluate(report *Report) {
+	if report.PassRate != nil && *report.PassRate < sc.PassRate {
+		report.Result = ResultFailed
+	}
+	if report.LineCoverage != nil && *report.LineCoverage < sc.LineCoverage {
+		report.Result = ResultFailed
+	}
+	if report.BranchCoverage != nil && *report.BranchCoverage < sc.BranchCoverage {
+		report.Result = ResultFailed
+	}
+	if sc.VulnerabilityThreshold != "" {
+		count := 0
+		for _, vulnerability := range report.Vulnerabilities {
+			if severityExceedsThreshold(sc.VulnerabilityThreshold, vulnerability.Severity) && len(vulnerability.Suppressions) == 0 {
+				count++
+			}
+		}
+		if count > sc.VulnerabilityNumber {
+			report.Result = ResultFailed
+		}
+	}
 }
 
 // VulnerabilitySeverity describes the severity of a vulnerability
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestReportFeature(t *testing.T) {
@@ -87,7 +88,7 @@ func TestReportFeature(t *testing.T) {
 			// setup the code under test
 			ctx := context.Background()
 			report := new(Report)
-			feature := ReportProcessor(report, &tt.SuccessCriteria, tt.ReportDir)
+			feature := ReportProcessor("test", &ReportConfig{SuccessCriteria: &tt.SuccessCriteria}, report, tt.ReportDir)
 
 			// setup the mock
 			m := new(runner.MockPlanExecutor)
@@ -110,6 +111,19 @@ func TestReportFeature(t *testing.T) {
 	}
 }
 
+func TestReportFeatureUnsupportedFormat(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	report := new(Report)
+	config := &ReportConfig{Format: "XUNITXML", SuccessCriteria: &SuccessCriteria{PassRate: 100}}
+	m := new(runner.MockPlanExecutor)
+	m.OnExecute(ctx).Return(nil)
+
+	// a report that can't be parsed fails instead of passing its success criteria
+	assert.EqualError(m.Execute(ctx, ReportProcessor("test", config, report, "testdata/reports/tests")), "report test status FAILED")
+	assert.Equal(ResultFailed, report.Result)
+}
+
 func TestSarifReportHandler(t *testing.T) {
 	assert := assert.New(t)
 
@@ -181,13 +195,71 @@ func TestSarifReportHandler(t *testing.T) {
 		},
 	} {
 		report := new(Report)
-		err := sarifReportHandler(tt.SuccessCriteria.VulnerabilityThreshold)(strings.NewReader(tt.Report), report)
+		err := sarifReportHandler()(strings.NewReader(tt.Report), report)
+		tt.SuccessCriteria.evaluate(report)
 		assert.Equal(tt.ExpectedResult, report.Result, "%s - result", tt.TestCase)
 		assert.Equal(tt.ExpectedVulnerabilities, report.Vulnerabilities, "%s - vulnerabilities", tt.TestCase)
 		assert.NoError(err)
 	}
 }
 
+func TestSuccessCriteria(t *testing.T) {
+	type TestParams struct {
+		TestCase        string
+		SuccessCriteria string
+		Report          Report
+		ExpectedResult  Result
+	}
+
+	high := Vulnerability{Severity: VulnerabilitySeverityHigh}
+	for _, tt := range []*TestParams{
+		{
+			TestCase:        "No criteria",
+			SuccessCriteria: "{}",
+			Report:          Report{Vulnerabilities: []Vulnerability{high}},
+		},
+		{
+			TestCase:        "Vulnerabilities below number",
+			SuccessCriteria: "Vulnerabilities: {Severity: HIGH, Number: 1}",
+			Report:          Report{Vulnerabilities: []Vulnerability{high}},
+		},
+		{
+			TestCase:        "Vulnerabilities above number",
+			SuccessCriteria: "Vulnerabilities: {Severity: MEDIUM, Number: 1}",
+			Report:          Report{Vulnerabilities: []Vulnerability{high, high}},
+			ExpectedResult:  ResultFailed,
+		},
+		{
+			TestCase:        "Suppressed vulnerabilities",
+			SuccessCriteria: "Vulnerabilities: {Severity: HIGH}",
+			Report:          Report{Vulnerabilities: []Vulnerability{{Severity: VulnerabilitySeverityCritical, Suppressions: []Suppression{{Kind: "inSource"}}}}},
+		},
+		{
+			TestCase:        "Pass rate below criteria",
+			SuccessCriteria: "PassRate: 90",
+			Report:          Report{PassRate: float32Ref(80)},
+			ExpectedResult:  ResultFailed,
+		},
+		{
+			TestCase:        "Coverage meets criteria",
+			SuccessCriteria: "{LineCoverage: 70, BranchCoverage: 50}",
+			Report:          Report{LineCoverage: float32Ref(70), BranchCoverage: float32Ref(60)},
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			successCriteria := new(SuccessCriteria)
+			assert.NoError(yaml.Unmarshal([]byte(tt.SuccessCriteria), successCriteria))
+			successCriteria.evaluate(&tt.Report)
+			assert.Equal(tt.ExpectedResult, tt.Report.Result)
+		})
+	}
+}
+
+func float32Ref(f float32) *float32 {
+	return &f
+}
+
 func intRef(i int) *int {
 	return &i
 }
@@ -0,0 +1,524 @@
+package workflows
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/actions"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RunStatusRunning is the status of a run that hasn't finished, or whose process was killed before it finished.
+// Finished runs and actions use the statuses of the [runner.EventPlanFinished] event, e.g. [features.EventStatusSucceeded].
+const RunStatusRunning = "running"
+
+// RunRecord is the history of a workflow run. It is saved as run.json in the directory of the run, next to a snapshot
+// of the workflow, the log of each action and the zips of the artifacts produced.
+type RunRecord struct {
+	ID           string                   `json:"id"`                    // ID of the run, made of its start time and a random suffix
+	Workflow     string                   `json:"workflow"`              // name of the workflow
+	WorkflowPath string                   `json:"workflowPath"`          // path of the workflow file that was run
+	WorkingDir   string                   `json:"workingDir"`            // working directory of the run
+	Action       string                   `json:"action,omitempty"`      // expression selecting the actions that were run, see [SelectActions]
+	WorkflowHash string                   `json:"workflowHash"`          // SHA-256 of the workflow file
+	WorkingTree  string                   `json:"workingTree,omitempty"` // fingerprint of the files of the working directory, see [workingTreeFingerprint]
+	ResumedFrom  string                   `json:"resumedFrom,omitempty"` // ID of the run this run resumed, if any
+	Status       string                   `json:"status"`                // status of the run
+	Error        string                   `json:"error,omitempty"`       // error the run failed with
+	StartTime    time.Time                `json:"startTime"`             // time the run started
+	EndTime      *time.Time               `json:"endTime,omitempty"`     // time the run finished
+	Actions      map[string]*ActionRecord `json:"actions"`               // record of each action planned, by action ID
+	dir          string
+	mutex        sync.Mutex
+}
+
+// ActionRecord is the history of an action of a workflow run
+type ActionRecord struct {
+	Status    string                   `json:"status"`              // status of the action
+	Error     string                   `json:"error,omitempty"`     // error the action failed with
+	StartTime *time.Time               `json:"startTime,omitempty"` // time the action started, unless it didn't run
+	EndTime   *time.Time               `json:"endTime,omitempty"`   // time the action finished, unless it didn't run
+	Outputs   map[string]string        `json:"outputs,omitempty"`   // output variables set by the action
+	Reports   map[string]*Report       `json:"reports,omitempty"`   // results of the reports of the action, by report name
+	Artifacts []string                 `json:"artifacts,omitempty"` // names of the artifacts produced by the action
+	Summary   []*runner.SummaryMessage `json:"summary,omitempty"`   // rendered messages of the ACTION_RUN_SUMMARY of the action
+}
+
+// Duration returns how long the action ran for, or zero if it didn't run
+func (ar *ActionRecord) Duration() time.Duration {
+	if ar.StartTime == nil || ar.EndTime == nil {
+		return 0
+	}
+	return ar.EndTime.Sub(*ar.StartTime)
+}
+
+// Duration returns how long the run took, or zero if it hasn't finished
+func (rr *RunRecord) Duration() time.Duration {
+	if rr.EndTime == nil {
+		return 0
+	}
+	return rr.EndTime.Sub(rr.StartTime)
+}
+
+// runsDir returns the directory of the user cache dir that holds the history of the runs
+func runsDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "codecatalyst-runner", "runs"), nil
+}
+
+// newRunID returns an ID made of the start time of the run and a random suffix
+func newRunID(startTime time.Time) (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s", startTime.UTC().Format("20060102-150405"), hex.EncodeToString(suffix)), nil
+}
+
+// newRunRecord creates the directory of a new run of the workflow, with a snapshot of the workflow file
+func newRunRecord(ctx context.Context, workflow *Workflow, workingDir string, action string) (*RunRecord, error) {
+	startTime := time.Now()
+	id, err := newRunID(startTime)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	record := &RunRecord{
+		ID:           id,
+		Workflow:     workflow.Name,
+		WorkflowPath: workflow.Path,
+		WorkingDir:   workingDir,
+		Action:       action,
+		Status:       RunStatusRunning,
+		StartTime:    startTime,
+		Actions:      make(map[string]*ActionRecord),
+		dir:          filepath.Join(runs, id),
+	}
+	if err := os.MkdirAll(record.dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create run dir %s: %w", record.dir, err)
+	}
+	content, err := os.ReadFile(workflow.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(record.WorkflowSnapshotPath(), content, 0644); err != nil {
+		return nil, err
+	}
+	record.WorkflowHash = contentHash(content)
+	if record.WorkingTree, err = workingTreeFingerprint(workingDir); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("unable to fingerprint the working dir")
+	}
+	return record, record.save()
+}
+
+// LoadRun loads the record of the run with the given ID
+func LoadRun(id string) (*RunRecord, error) {
+	runs, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid run ID '%s'", id)
+	}
+	return loadRun(filepath.Join(runs, id))
+}
+
+func loadRun(dir string) (*RunRecord, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "run.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no run found with ID '%s'", filepath.Base(dir))
+	} else if err != nil {
+		return nil, err
+	}
+	record := new(RunRecord)
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, fmt.Errorf("unable to read run '%s': %w", filepath.Base(dir), err)
+	}
+	record.dir = dir
+	return record, nil
+}
+
+// ListRuns returns the records of the runs in the history, the most recent first
+func ListRuns() ([]*RunRecord, error) {
+	runs, err := runsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(runs)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	records := make([]*RunRecord, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		record, err := loadRun(filepath.Join(runs, entry.Name()))
+		if err != nil {
+			log.Debug().Err(err).Msgf("skipping run dir %s", entry.Name())
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].StartTime.After(records[j].StartTime)
+	})
+	return records, nil
+}
+
+// PruneRuns removes the runs in the history beyond the keep most recent ones, as well as the runs that started more
+// than olderThan ago if olderThan isn't zero. It returns the records of the removed runs, the most recent first.
+func PruneRuns(keep int, olderThan time.Duration) ([]*RunRecord, error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("invalid number of runs to keep: %d", keep)
+	}
+	records, err := ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	pruned := make([]*RunRecord, 0)
+	for i, record := range records {
+		if i < keep && (olderThan == 0 || time.Since(record.StartTime) <= olderThan) {
+			continue
+		}
+		if err := os.RemoveAll(record.dir); err != nil {
+			return pruned, fmt.Errorf("unable to remove run '%s': %w", record.ID, err)
+		}
+		pruned = append(pruned, record)
+	}
+	return pruned, nil
+}
+
+// Dir returns the directory of the run
+func (rr *RunRecord) Dir() string {
+	return rr.dir
+}
+
+// WorkflowSnapshotPath returns the path of the copy of the workflow file taken when the run started
+func (rr *RunRecord) WorkflowSnapshotPath() string {
+	return filepath.Join(rr.dir, "workflow.yaml")
+}
+
+// LogPath returns the path of the log of an action of the run
+func (rr *RunRecord) LogPath(actionID string) string {
+	return filepath.Join(rr.dir, "logs", fmt.Sprintf("%s.log", actionID))
+}
+
+// ArtifactPath returns the path of the zip of an artifact produced by the run
+func (rr *RunRecord) ArtifactPath(name string) string {
+	return filepath.Join(rr.dir, "artifacts", fmt.Sprintf("%s.zip", name))
+}
+
+// ReportPath returns the path of a report of the run, such as the merged SARIF file
+func (rr *RunRecord) ReportPath(name string) string {
+	return filepath.Join(rr.dir, "reports", name)
+}
+
+// ActionIDs returns the IDs of the actions of the run in sorted order
+func (rr *RunRecord) ActionIDs() []string {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	actionIDs := make([]string, 0, len(rr.Actions))
+	for actionID := range rr.Actions {
+		actionIDs = append(actionIDs, actionID)
+	}
+	sort.Strings(actionIDs)
+	return actionIDs
+}
+
+// add registers an action of the run. An action that never runs is recorded as skipped.
+func (rr *RunRecord) add(actionID string) *ActionRecord {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	if action, ok := rr.Actions[actionID]; ok {
+		return action
+	}
+	action := &ActionRecord{
+		Status: features.EventStatusSkipped,
+		Error:  "not run",
+	}
+	rr.Actions[actionID] = action
+	return action
+}
+
+// finish records the end of the run and saves the record
+func (rr *RunRecord) finish(err error) error {
+	rr.mutex.Lock()
+	endTime := time.Now()
+	rr.EndTime = &endTime
+	rr.Status = features.EventStatusSucceeded
+	if err != nil {
+		rr.Status = features.EventStatusFailed
+		rr.Error = common.Mask(err.Error())
+	}
+	rr.mutex.Unlock()
+	return rr.save()
+}
+
+// save writes the record to run.json in the directory of the run
+func (rr *RunRecord) save() error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	content, err := json.MarshalIndent(rr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rr.dir, "run.json"), content, 0644)
+}
+
+// ActionRecorder is a Feature to record the status, timings, log, outputs, reports, artifacts and summary of an action
+// in the history of the run. The outputs, reports and summary are read once the action has run, and the artifacts are
+// zipped from the artifacts directory of cacheDir. Secrets are masked in the outputs and errors, as they are in the log. It must be wrapped by the feature that skips actions whose
+// dependencies failed.
+func ActionRecorder(record *RunRecord, actionID string, outputs map[string]string, reports map[string]*Report, artifacts []string, summaries *actions.RunSummaries, cacheDir string) runner.Feature {
+	action := record.add(actionID)
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER ActionRecorder")
+		startTime := time.Now()
+		logHook, err := newLogFileHook(record.LogPath(actionID))
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("Unable to record the log of the action")
+		} else {
+			defer logHook.Close()
+			ctx = log.Ctx(ctx).Hook(logHook).WithContext(ctx)
+		}
+		err = e(ctx)
+		if errors.Is(err, common.ErrDefer) {
+			return err
+		}
+		produced := make([]string, 0)
+		if err == nil {
+			for _, artifact := range artifacts {
+				if zipped, zipErr := zipDir(filepath.Join(cacheDir, "artifacts", artifact), record.ArtifactPath(artifact)); zipErr != nil {
+					log.Ctx(ctx).Warn().Err(zipErr).Msgf("Unable to record artifact %s", artifact)
+				} else if zipped {
+					produced = append(produced, artifact)
+				}
+			}
+		}
+		endTime := time.Now()
+
+		record.mutex.Lock()
+		action.StartTime = &startTime
+		action.EndTime = &endTime
+		action.Status = actionStatus(ctx, err)
+		action.Error = ""
+		if err != nil {
+			action.Error = common.Mask(err.Error())
+		}
+		action.Outputs = make(map[string]string)
+		for name, value := range outputs {
+			action.Outputs[name] = common.Mask(value)
+		}
+		action.Reports = reports
+		action.Artifacts = produced
+		action.Summary = summaries.Get(actionID)
+		record.mutex.Unlock()
+		if saveErr := record.save(); saveErr != nil {
+			log.Ctx(ctx).Warn().Err(saveErr).Msg("Unable to save the history of the run")
+		}
+		log.Ctx(ctx).Debug().Msg("EXIT ActionRecorder")
+		return err
+	}
+}
+
+// actionStatus returns the status of an action that returned err
+func actionStatus(ctx context.Context, err error) string {
+	switch _, isWarning := err.(common.Warning); {
+	case err == nil:
+		return features.EventStatusSucceeded
+	case isWarning:
+		return features.EventStatusWarning
+	case errors.As(err, new(*common.TimeoutError)):
+		return features.EventStatusTimedOut
+	case common.Cancelled(ctx) != nil:
+		return features.EventStatusCancelled
+	default:
+		return features.EventStatusFailed
+	}
+}
+
+// logFileHook is a zerolog hook that appends each log entry to a file
+type logFileHook struct {
+	file  *os.File
+	mutex sync.Mutex
+}
+
+func newLogFileHook(path string) (*logFileHook, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &logFileHook{file: file}, nil
+}
+
+func (lfh *logFileHook) Run(_ *zerolog.Event, level zerolog.Level, message string) {
+	lfh.mutex.Lock()
+	defer lfh.mutex.Unlock()
+	fmt.Fprintf(lfh.file, "%s %s %s\n", time.Now().Format(time.RFC3339), level.String(), common.Mask(message))
+}
+
+func (lfh *logFileHook) Close() error {
+	lfh.mutex.Lock()
+	defer lfh.mutex.Unlock()
+	return lfh.file.Close()
+}
+
+// zipDir zips the files of sourceDir into zipPath. It returns false if sourceDir has no files, in which case no zip is written.
+func zipDir(sourceDir string, zipPath string) (bool, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(sourceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(files) == 0) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(zipPath), 0755); err != nil {
+		return false, err
+	}
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return false, err
+	}
+	defer zipFile.Close()
+	zipWriter := zip.NewWriter(zipFile)
+	for _, path := range files {
+		relPath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return false, err
+		}
+		dst, err := zipWriter.Create(filepath.ToSlash(relPath))
+		if err != nil {
+			return false, err
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return false, err
+		}
+		_, err = io.Copy(dst, src)
+		src.Close()
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, zipWriter.Close()
+}
+
+// WriteRuns writes a table of the runs, with their ID, workflow, status, start time and duration
+func WriteRuns(w io.Writer, records []*RunRecord) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tWORKFLOW\tSTATUS\tSTARTED\tDURATION")
+	for _, record := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			record.ID,
+			record.Workflow,
+			record.Status,
+			record.StartTime.Local().Format(time.DateTime),
+			formatDuration(record.Duration()),
+		)
+	}
+	return tw.Flush()
+}
+
+// Write writes the details of the run: its status and timings, then the status, timings, outputs, reports and artifacts of each action
+func (rr *RunRecord) Write(w io.Writer) error {
+	fmt.Fprintf(w, "Run:       %s\n", rr.ID)
+	fmt.Fprintf(w, "Workflow:  %s (%s)\n", rr.Workflow, rr.WorkflowPath)
+	fmt.Fprintf(w, "Status:    %s\n", rr.Status)
+	fmt.Fprintf(w, "Started:   %s\n", rr.StartTime.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Duration:  %s\n", formatDuration(rr.Duration()))
+	if rr.Error != "" {
+		fmt.Fprintf(w, "Error:     %s\n", rr.Error)
+	}
+	fmt.Fprintf(w, "Directory: %s\n", rr.dir)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nACTION\tSTATUS\tDURATION\tERROR")
+	actionIDs := rr.ActionIDs()
+	for _, actionID := range actionIDs {
+		action := rr.Actions[actionID]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", actionID, action.Status, formatDuration(action.Duration()), action.Error)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, actionID := range actionIDs {
+		action := rr.Actions[actionID]
+		if len(action.Outputs) == 0 && len(action.Reports) == 0 && len(action.Artifacts) == 0 && len(action.Summary) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n", actionID)
+		for _, name := range sortedKeys(action.Outputs) {
+			fmt.Fprintf(w, "  output   %s=%s\n", name, action.Outputs[name])
+		}
+		for _, name := range sortedKeys(action.Reports) {
+			fmt.Fprintf(w, "  report   %s %s\n", name, action.Reports[name].Result)
+		}
+		for _, name := range action.Artifacts {
+			fmt.Fprintf(w, "  artifact %s %s\n", name, rr.ArtifactPath(name))
+		}
+		for _, message := range action.Summary {
+			fmt.Fprintf(w, "  summary  %s %s\n", message.Level, message)
+		}
+	}
+	return nil
+}
+
+// formatDuration formats a duration rounded to the second, or - if it is zero
+func formatDuration(duration time.Duration) string {
+	if duration == 0 {
+		return "-"
+	}
+	if duration < time.Second {
+		return duration.Round(time.Millisecond).String()
+	}
+	return duration.Round(time.Second).String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
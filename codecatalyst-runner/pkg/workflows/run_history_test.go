@@ -0,0 +1,154 @@
+package workflows
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/actions"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRunHistory(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	ctx := log.Logger.WithContext(context.Background())
+
+	workflow, err := readWorkflow("testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml")
+	assert.NoError(err)
+	record, err := newRunRecord(ctx, workflow, t.TempDir(), "Build+")
+	assert.NoError(err)
+	assert.FileExists(record.WorkflowSnapshotPath())
+
+	// an action that succeeds with outputs, a report and an artifact
+	cacheDir := t.TempDir()
+	assert.NoError(os.MkdirAll(filepath.Join(cacheDir, "artifacts", "BuildOutput"), 0755))
+	assert.NoError(os.WriteFile(filepath.Join(cacheDir, "artifacts", "BuildOutput", "app.txt"), []byte("app"), 0644))
+	outputs := map[string]string{"IMAGE_TAG": "abc123"}
+	reports := map[string]*Report{"unit": {Result: ResultSucceeded}}
+	summaries := new(actions.RunSummaries)
+	feature := ActionRecorder(record, "Build", outputs, reports, []string{"BuildOutput", "Missing"}, summaries, cacheDir)
+	m := new(runner.MockPlanExecutor).WithExecutor(func(ctx context.Context) error {
+		log.Ctx(ctx).Info().Msg("building")
+		return nil
+	})
+	m.OnExecute(mock.Anything).Return(nil)
+	assert.NoError(m.Execute(ctx, feature))
+
+	// an action that is deferred then fails with a summary
+	feature = ActionRecorder(record, "Test", nil, nil, nil, summaries, cacheDir)
+	summaries.Set("Test", []*runner.SummaryMessage{{Level: "Error", Code: "CUSTOM", Message: "2 tests failed"}})
+	m = new(runner.MockPlanExecutor)
+	m.OnExecute(mock.Anything).Return(common.ErrDefer).Once()
+	m.OnExecute(mock.Anything).Return(fmt.Errorf("mock-error")).Once()
+	assert.ErrorIs(m.Execute(ctx, feature), common.ErrDefer)
+	assert.EqualError(m.Execute(ctx, feature), "mock-error")
+
+	// an action that fails with a secret in its outputs and error
+	common.AddMask("run-history-secret")
+	feature = ActionRecorder(record, "Publish", map[string]string{"TOKEN": "run-history-secret"}, nil, nil, summaries, cacheDir)
+	m = new(runner.MockPlanExecutor)
+	m.OnExecute(mock.Anything).Return(fmt.Errorf("invalid token run-history-secret"))
+	assert.Error(m.Execute(ctx, feature))
+
+	// an action that never runs
+	ActionRecorder(record, "Deploy", nil, nil, nil, summaries, cacheDir)
+	assert.NoError(record.finish(fmt.Errorf("mock-error")))
+
+	// reload the run from the history
+	runs, err := ListRuns()
+	assert.NoError(err)
+	assert.Len(runs, 1)
+	loaded, err := LoadRun(record.ID)
+	assert.NoError(err)
+	assert.Equal(record.ID, runs[0].ID)
+	assert.Equal("sample", loaded.Workflow)
+	assert.Equal(features.EventStatusFailed, loaded.Status)
+	assert.Equal("mock-error", loaded.Error)
+	assert.NotNil(loaded.EndTime)
+	assert.Equal([]string{"Build", "Deploy", "Publish", "Test"}, loaded.ActionIDs())
+
+	build := loaded.Actions["Build"]
+	assert.Equal(features.EventStatusSucceeded, build.Status)
+	assert.Equal(outputs, build.Outputs)
+	assert.Equal(ResultSucceeded, build.Reports["unit"].Result)
+	assert.Equal([]string{"BuildOutput"}, build.Artifacts)
+	assert.NotNil(build.StartTime)
+	archive, err := zip.OpenReader(loaded.ArtifactPath("BuildOutput"))
+	assert.NoError(err)
+	assert.Equal("app.txt", archive.File[0].Name)
+	archive.Close()
+	buildLog, err := os.ReadFile(loaded.LogPath("Build"))
+	assert.NoError(err)
+	assert.Contains(string(buildLog), "info building")
+
+	assert.Equal(features.EventStatusFailed, loaded.Actions["Test"].Status)
+	assert.Equal("mock-error", loaded.Actions["Test"].Error)
+	assert.Equal([]*runner.SummaryMessage{{Level: "Error", Code: "CUSTOM", Message: "2 tests failed"}}, loaded.Actions["Test"].Summary)
+	assert.Empty(build.Summary)
+	assert.Equal(map[string]string{"TOKEN": common.MaskedValue}, loaded.Actions["Publish"].Outputs)
+	assert.Equal("invalid token "+common.MaskedValue, loaded.Actions["Publish"].Error)
+	assert.Equal(features.EventStatusSkipped, loaded.Actions["Deploy"].Status)
+	assert.Nil(loaded.Actions["Deploy"].StartTime)
+
+	out := new(bytes.Buffer)
+	assert.NoError(loaded.Write(out))
+	assert.Contains(out.String(), "output   IMAGE_TAG=abc123")
+	assert.Contains(out.String(), "report   unit SUCCEEDED")
+	assert.Contains(out.String(), "summary  Error [CUSTOM] 2 tests failed")
+
+	_, err = LoadRun("missing")
+	assert.EqualError(err, "no run found with ID 'missing'")
+	_, err = LoadRun("../runs")
+	assert.Error(err)
+}
+
+func TestPruneRuns(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	ctx := log.Logger.WithContext(context.Background())
+
+	workflow, err := readWorkflow("testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml")
+	assert.NoError(err)
+	ids := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		record, err := newRunRecord(ctx, workflow, t.TempDir(), "")
+		assert.NoError(err)
+		// runs that started a day apart, the first one three days ago
+		record.StartTime = time.Now().Add(time.Duration(i-3) * 24 * time.Hour)
+		assert.NoError(record.finish(nil))
+		ids = append(ids, record.ID)
+	}
+
+	pruned, err := PruneRuns(3, 0)
+	assert.NoError(err)
+	if assert.Len(pruned, 1) {
+		assert.Equal(ids[0], pruned[0].ID)
+		assert.NoDirExists(pruned[0].Dir())
+	}
+
+	pruned, err = PruneRuns(3, 36*time.Hour)
+	assert.NoError(err)
+	if assert.Len(pruned, 1) {
+		assert.Equal(ids[1], pruned[0].ID)
+	}
+
+	runs, err := ListRuns()
+	assert.NoError(err)
+	assert.Len(runs, 2)
+
+	_, err = PruneRuns(-1, 0)
+	assert.EqualError(err, "invalid number of runs to keep: -1")
+}
@@ -0,0 +1,208 @@
+package workflows
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/manifoldco/promptui"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+)
+
+// latestRun returns the most recent run of the workflow file in the history
+func latestRun(workflowPath string) (*RunRecord, error) {
+	records, err := ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.WorkflowPath == workflowPath {
+			return record, nil
+		}
+	}
+	return nil, fmt.Errorf("no previous run of workflow file '%s' to rerun", workflowPath)
+}
+
+// resumeRun prepares params to resume the previous run. The output variables and artifacts of the actions that
+// succeeded in the previous run are provided as if they had been passed with --var and --artifact, and the IDs of these
+// actions are returned so that they aren't planned again. The actions that failed, were cancelled or were skipped run again.
+func resumeRun(ctx context.Context, params *RunParams, previous *RunRecord, workflowActions map[string]*Action) ([]string, error) {
+	if previous.WorkflowPath != params.WorkflowPath {
+		return nil, fmt.Errorf("unable to resume run '%s': it ran workflow file '%s', not '%s'", previous.ID, previous.WorkflowPath, params.WorkflowPath)
+	}
+	if changes := previous.changes(ctx, params.WorkingDir); len(changes) > 0 {
+		for _, change := range changes {
+			log.Ctx(ctx).Warn().Msgf("⚠️  %s since run %s", change, previous.ID)
+		}
+		if !confirmResume(previous.ID) {
+			return nil, fmt.Errorf("resume of run '%s' aborted", previous.ID)
+		}
+	}
+	if params.Action == "" {
+		params.Action = previous.Action
+	}
+	if params.Variables == nil {
+		params.Variables = make(map[string]string)
+	}
+	if params.Artifacts == nil {
+		params.Artifacts = make(map[string]string)
+	}
+
+	succeeded := make([]string, 0)
+	for _, actionID := range previous.ActionIDs() {
+		action := previous.Actions[actionID]
+		if action.Status != features.EventStatusSucceeded {
+			continue
+		}
+		if _, ok := workflowActions[actionID]; !ok {
+			log.Ctx(ctx).Debug().Msgf("action %s of run %s is no longer in the workflow", actionID, previous.ID)
+			continue
+		}
+		for name, value := range action.Outputs {
+			key := fmt.Sprintf("%s.%s", strings.Replace(actionID, "@", ".", 1), name)
+			if _, ok := params.Variables[key]; !ok {
+				params.Variables[key] = value
+			}
+		}
+		for _, artifact := range action.Artifacts {
+			if _, ok := params.Artifacts[artifact]; !ok {
+				params.Artifacts[artifact] = previous.ArtifactPath(artifact)
+			}
+		}
+		succeeded = append(succeeded, actionID)
+	}
+	log.Ctx(ctx).Info().Msgf("⏩ Resuming run %s, %d actions succeeded and are not run again", previous.ID, len(succeeded))
+	return succeeded, nil
+}
+
+// changes describes how the workflow file and the working directory changed since the run
+func (rr *RunRecord) changes(ctx context.Context, workingDir string) []string {
+	changes := make([]string, 0)
+	if content, err := os.ReadFile(rr.WorkflowPath); err != nil || contentHash(content) != rr.WorkflowHash {
+		changes = append(changes, fmt.Sprintf("workflow file %s changed", rr.WorkflowPath))
+	}
+	if rr.WorkingTree != "" {
+		if fingerprint, err := workingTreeFingerprint(workingDir); err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msg("unable to fingerprint the working dir")
+		} else if fingerprint != rr.WorkingTree {
+			changes = append(changes, fmt.Sprintf("files of working dir %s changed", workingDir))
+		}
+	}
+	return changes
+}
+
+// confirmResume prompts whether to resume the run despite the changes. Without a terminal to prompt, the run is resumed.
+func confirmResume(runID string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return true
+	}
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Resume run %s anyway", runID),
+		IsConfirm: true,
+		Stdout:    &bellSkipper{},
+	}
+	_, err := prompt.Run()
+	return err == nil
+}
+
+// resume copies the records, logs and artifacts of the actions of the previous run that aren't run again
+func (rr *RunRecord) resume(previous *RunRecord, actionIDs []string) error {
+	rr.mutex.Lock()
+	rr.ResumedFrom = previous.ID
+	for _, actionID := range actionIDs {
+		rr.Actions[actionID] = previous.Actions[actionID]
+	}
+	rr.mutex.Unlock()
+	for _, actionID := range actionIDs {
+		if err := copyFile(previous.LogPath(actionID), rr.LogPath(actionID)); err != nil {
+			return err
+		}
+		for _, artifact := range previous.Actions[actionID].Artifacts {
+			if err := copyFile(previous.ArtifactPath(artifact), rr.ArtifactPath(artifact)); err != nil {
+				return err
+			}
+		}
+	}
+	return rr.save()
+}
+
+// copyFile copies the file at sourcePath to destPath, if it exists
+func copyFile(sourcePath string, destPath string) error {
+	src, err := os.Open(sourcePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+	dst, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// workingTreeFingerprint hashes the path, size and modification time of the files of the working directory that
+// are copied into the actions, i.e. the files that aren't ignored by a .gitignore
+func workingTreeFingerprint(workingDir string) (string, error) {
+	patterns, err := gitignore.ReadPatterns(polyfill.New(osfs.New(workingDir)), nil)
+	if err != nil {
+		return "", err
+	}
+	ignorer := gitignore.NewMatcher(patterns)
+	entries := make([]string, 0)
+	err = filepath.WalkDir(workingDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(workingDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(relPath), "/")
+		if d.IsDir() && d.Name() == ".git" || ignorer.Match(parts, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, fmt.Sprintf("%s %d %d", filepath.ToSlash(relPath), info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(entries)
+	return contentHash([]byte(strings.Join(entries, "\n"))), nil
+}
+
+// contentHash returns the hex encoded SHA-256 of content
+func contentHash(content []byte) string {
+	sha := sha256.Sum256(content)
+	return hex.EncodeToString(sha[:])
+}
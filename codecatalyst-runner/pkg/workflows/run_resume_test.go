@@ -0,0 +1,78 @@
+package workflows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeRun(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	ctx := log.Logger.WithContext(context.Background())
+
+	// a previous run where FirstAction succeeded and Group1@SubAction1 failed
+	workingDir := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(workingDir, "main.go"), []byte("package main"), 0644))
+	content, err := os.ReadFile("testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml")
+	assert.NoError(err)
+	workflowPath := filepath.Join(workingDir, "sample.yaml")
+	assert.NoError(os.WriteFile(workflowPath, content, 0644))
+	workflow, err := readWorkflow(workflowPath)
+	assert.NoError(err)
+	workflowActions, _, err := workflow.ActionsByID()
+	assert.NoError(err)
+	previous, err := newRunRecord(ctx, workflow, workingDir, "FirstAction+")
+	assert.NoError(err)
+	previous.Actions["FirstAction"] = &ActionRecord{
+		Status:    features.EventStatusSucceeded,
+		Outputs:   map[string]string{"IMAGE_TAG": "abc123"},
+		Artifacts: []string{"BuildOutput"},
+	}
+	previous.Actions["Group1@SubAction1"] = &ActionRecord{
+		Status:  features.EventStatusSucceeded,
+		Outputs: map[string]string{"RESULT": "ok"},
+	}
+	previous.Actions["Group1@SubAction2"] = &ActionRecord{Status: features.EventStatusFailed}
+	previous.Actions["FinalAction"] = &ActionRecord{Status: features.EventStatusSkipped}
+	_, err = zipDir(workingDir, previous.ArtifactPath("BuildOutput"))
+	assert.NoError(err)
+	assert.NoError(previous.finish(nil))
+	assert.Empty(previous.changes(ctx, workingDir))
+
+	// resume it
+	params := &RunParams{WorkflowPath: workflowPath}
+	params.Variables = map[string]string{"FirstAction.IMAGE_TAG": "override"}
+	resumed, err := resumeRun(ctx, params, previous, workflowActions)
+	assert.NoError(err)
+	assert.Equal([]string{"FirstAction", "Group1@SubAction1"}, resumed)
+	assert.Equal("FirstAction+", params.Action)
+	assert.Equal(map[string]string{
+		"FirstAction.IMAGE_TAG":    "override",
+		"Group1.SubAction1.RESULT": "ok",
+	}, params.Variables)
+	assert.Equal(map[string]string{"BuildOutput": previous.ArtifactPath("BuildOutput")}, params.Artifacts)
+
+	// the records of the actions that aren't run again are copied to the new run
+	record, err := newRunRecord(ctx, workflow, workingDir, params.Action)
+	assert.NoError(err)
+	assert.NoError(record.resume(previous, resumed))
+	assert.Equal(previous.ID, record.ResumedFrom)
+	assert.Equal([]string{"FirstAction", "Group1@SubAction1"}, record.ActionIDs())
+	assert.FileExists(record.ArtifactPath("BuildOutput"))
+
+	// a run of another workflow can't be resumed
+	_, err = resumeRun(ctx, &RunParams{WorkflowPath: "other.yaml"}, previous, workflowActions)
+	assert.Error(err)
+
+	// changes to the workflow file and the working directory are detected
+	assert.NoError(os.WriteFile(filepath.Join(workingDir, "main.go"), []byte("package main\n"), 0644))
+	assert.NoError(os.WriteFile(workflowPath, append(content, '\n'), 0644))
+	assert.Len(previous.changes(ctx, workingDir), 2)
+}
@@ -0,0 +1,167 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SBOMSummary collects the components of the SBOMs detected in the reports of the actions of a workflow run
+type SBOMSummary struct {
+	mutex      sync.Mutex
+	components map[string][]features.SBOMComponent // distinct components of each action
+	index      map[string]map[string]bool          // keys of the components of each action
+}
+
+// SBOMCollector is a Feature that adds the components of the SBOMs detected for an action to the summary
+func SBOMCollector(summary *SBOMSummary, actionID string, sboms *[]features.SBOM) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER SBOMCollector")
+		err := e(ctx)
+		if summary != nil {
+			summary.Add(actionID, *sboms)
+		}
+		log.Ctx(ctx).Debug().Msg("EXIT SBOMCollector")
+		return err
+	}
+}
+
+// Add adds the components of the SBOMs of an action. A component listed in several SBOMs of the action is only added once.
+func (ss *SBOMSummary) Add(actionID string, sboms []features.SBOM) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if ss.components == nil {
+		ss.components = make(map[string][]features.SBOMComponent)
+		ss.index = make(map[string]map[string]bool)
+	}
+	for _, sbom := range sboms {
+		if ss.index[actionID] == nil {
+			ss.index[actionID] = make(map[string]bool)
+			ss.components[actionID] = make([]features.SBOMComponent, 0)
+		}
+		for _, component := range sbom.Components {
+			key := componentKey(component)
+			if !ss.index[actionID][key] {
+				ss.index[actionID][key] = true
+				ss.components[actionID] = append(ss.components[actionID], component)
+			}
+		}
+	}
+}
+
+// ComponentCounts returns the number of distinct components found in the SBOMs of each action
+func (ss *SBOMSummary) ComponentCounts() map[string]int {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	counts := make(map[string]int)
+	for actionID, components := range ss.components {
+		counts[actionID] = len(components)
+	}
+	return counts
+}
+
+// Log logs the number of components found in the SBOMs of each action
+func (ss *SBOMSummary) Log(ctx context.Context) {
+	counts := ss.ComponentCounts()
+	if len(counts) == 0 {
+		return
+	}
+	actionIDs := make([]string, 0, len(counts))
+	for actionID := range counts {
+		actionIDs = append(actionIDs, actionID)
+	}
+	sort.Strings(actionIDs)
+	log.Ctx(ctx).Info().Msgf("📦 SBOMs found for %d actions", len(actionIDs))
+	for _, actionID := range actionIDs {
+		log.Ctx(ctx).Info().Msgf("   %5d components %s", counts[actionID], actionID)
+	}
+}
+
+// WriteCycloneDX writes a CycloneDX JSON SBOM to path that merges the components of every action. Each component is
+// listed once, with the actions that reported it in its properties.
+func (ss *SBOMSummary) WriteCycloneDX(path string, workflowName string) error {
+	type property struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	type component struct {
+		BOMRef     string     `json:"bom-ref"`
+		Type       string     `json:"type"`
+		Name       string     `json:"name"`
+		Version    string     `json:"version,omitempty"`
+		PURL       string     `json:"purl,omitempty"`
+		Properties []property `json:"properties"`
+	}
+
+	ss.mutex.Lock()
+	actionIDs := make([]string, 0, len(ss.components))
+	for actionID := range ss.components {
+		actionIDs = append(actionIDs, actionID)
+	}
+	sort.Strings(actionIDs)
+	components := make([]*component, 0)
+	index := make(map[string]*component)
+	for _, actionID := range actionIDs {
+		for _, c := range ss.components[actionID] {
+			key := componentKey(c)
+			merged, ok := index[key]
+			if !ok {
+				componentType := c.Type
+				if componentType == "" {
+					componentType = "library"
+				}
+				merged = &component{
+					BOMRef:  key,
+					Type:    componentType,
+					Name:    c.Name,
+					Version: c.Version,
+					PURL:    c.PURL,
+				}
+				index[key] = merged
+				components = append(components, merged)
+			}
+			merged.Properties = append(merged.Properties, property{Name: "codecatalyst:action", Value: actionID})
+		}
+	}
+	ss.mutex.Unlock()
+
+	bom := map[string]any{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"version":     1,
+		"metadata": map[string]any{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"component": map[string]string{
+				"type": "application",
+				"name": workflowName,
+			},
+		},
+		"components": components,
+	}
+	content, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// componentKey identifies a component by its package URL, or by its name and version if it has none
+func componentKey(component features.SBOMComponent) string {
+	if component.PURL != "" {
+		return component.PURL
+	}
+	return fmt.Sprintf("%s@%s", component.Name, component.Version)
+}
@@ -0,0 +1,76 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSBOMSummary(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	summary := new(SBOMSummary)
+
+	express := features.SBOMComponent{Type: "library", Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"}
+	lodash := features.SBOMComponent{Type: "library", Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"}
+	hello := features.SBOMComponent{Name: "hello"}
+
+	for _, tt := range []struct {
+		ActionID string
+		SBOMs    []features.SBOM
+	}{
+		{
+			ActionID: "Build",
+			SBOMs: []features.SBOM{
+				{Type: features.SBOMTypeCycloneDX, Components: []features.SBOMComponent{express, lodash}},
+				{Type: features.SBOMTypeCycloneDX, Components: []features.SBOMComponent{lodash}},
+			},
+		},
+		{
+			ActionID: "Package",
+			SBOMs: []features.SBOM{
+				{Type: features.SBOMTypeSPDX, Components: []features.SBOMComponent{hello, express}},
+			},
+		},
+		{
+			ActionID: "Test",
+		},
+	} {
+		sboms := tt.SBOMs
+		m := new(runner.MockPlanExecutor)
+		m.OnExecute(ctx).Return(nil)
+		assert.NoError(m.Execute(ctx, SBOMCollector(summary, tt.ActionID, &sboms)))
+	}
+
+	assert.Equal(map[string]int{"Build": 2, "Package": 2}, summary.ComponentCounts())
+
+	sbomPath := filepath.Join(t.TempDir(), "reports", "sbom.cdx.json")
+	assert.NoError(summary.WriteCycloneDX(sbomPath, "onPush"))
+	content, err := os.ReadFile(sbomPath)
+	assert.NoError(err)
+	var bom struct {
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			BOMRef     string `json:"bom-ref"`
+			Type       string `json:"type"`
+			Properties []struct {
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"components"`
+	}
+	assert.NoError(json.Unmarshal(content, &bom))
+	assert.Equal("CycloneDX", bom.BOMFormat)
+	if assert.Len(bom.Components, 3) {
+		assert.Equal("pkg:npm/express@4.18.2", bom.Components[0].BOMRef)
+		assert.Len(bom.Components[0].Properties, 2)
+		assert.Equal("hello@", bom.Components[2].BOMRef)
+		assert.Equal("library", bom.Components[2].Type)
+	}
+}
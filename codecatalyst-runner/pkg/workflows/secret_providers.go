@@ -0,0 +1,310 @@
+package workflows
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/manifoldco/promptui"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// SecretUndefinedError is returned by a [SecretProvider] that doesn't define a secret, so that the next provider of a
+// chain is tried
+type SecretUndefinedError struct {
+	Name string
+}
+
+func (e *SecretUndefinedError) Error() string {
+	return fmt.Sprintf("secret '%s' undefined", e.Name)
+}
+
+// SecretProviderParams configures the secret providers of a run
+type SecretProviderParams struct {
+	SecretsFiles []string // Paths of dotenv or YAML files with secrets, optionally encrypted, in priority order
+	SecretsExec  string   // Helper command to run with the name of each secret to print its value, if any
+	AgeIdentity  string   // Path of the age identity to decrypt .age secrets files with, if any
+}
+
+// NewSecretProvider returns the chain of secret providers configured by params. Secrets are looked up in the secrets
+// files in order, then with the helper command, then in the environment variables.
+func NewSecretProvider(ctx context.Context, params *SecretProviderParams) (SecretProvider, error) {
+	providers := make([]SecretProvider, 0)
+	for _, secretsFile := range params.SecretsFiles {
+		provider, err := ReadSecretsFile(ctx, secretsFile, params.AgeIdentity)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	if params.SecretsExec != "" {
+		provider, err := NewExecSecretProvider(params.SecretsExec)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	providers = append(providers, new(envSecretProvider))
+	return NewChainSecretProvider(providers...), nil
+}
+
+// NewChainSecretProvider returns a [SecretProvider] that gets each secret from the first of the providers that defines it
+func NewChainSecretProvider(providers ...SecretProvider) SecretProvider {
+	return chainSecretProvider(providers)
+}
+
+type chainSecretProvider []SecretProvider
+
+func (csp chainSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	for _, provider := range csp {
+		value, err := provider.GetSecret(ctx, name)
+		var undefinedErr *SecretUndefinedError
+		if errors.As(err, &undefinedErr) {
+			continue
+		}
+		return value, err
+	}
+	return "", &SecretUndefinedError{Name: name}
+}
+
+type envSecretProvider struct{}
+
+func (ssp *envSecretProvider) GetSecret(_ context.Context, name string) (string, error) {
+	if val, ok := os.LookupEnv(name); ok {
+		return val, nil
+	}
+	return "", &SecretUndefinedError{Name: name}
+}
+
+type mapSecretProvider map[string]string
+
+func (msp mapSecretProvider) GetSecret(_ context.Context, name string) (string, error) {
+	if val, ok := msp[name]; ok {
+		return val, nil
+	}
+	return "", &SecretUndefinedError{Name: name}
+}
+
+// ReadSecretsFile returns a [SecretProvider] with the secrets of a file. Files ending in .yaml or .yml are read as a
+// map of names to values, any other file as dotenv. Files encrypted by 'ccr secrets encrypt' end in .enc and are
+// decrypted with a passphrase, read from CCR_SECRETS_PASSPHRASE or prompted for. Files encrypted with age end in .age
+// and are decrypted by the age command, with the identity at ageIdentity if any, e.g. secrets.yaml.age.
+func ReadSecretsFile(ctx context.Context, secretsPath string, ageIdentity string) (SecretProvider, error) {
+	content, err := os.ReadFile(secretsPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets file '%s': %w", secretsPath, err)
+	}
+	format := secretsPath
+	switch filepath.Ext(secretsPath) {
+	case ".enc":
+		passphrase, err := secretsPassphrase(secretsPath)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = DecryptSecrets(content, passphrase); err != nil {
+			return nil, fmt.Errorf("unable to decrypt secrets file '%s': %w", secretsPath, err)
+		}
+		format = strings.TrimSuffix(secretsPath, ".enc")
+	case ".age":
+		if content, err = decryptAge(ctx, secretsPath, ageIdentity); err != nil {
+			return nil, fmt.Errorf("unable to decrypt secrets file '%s': %w", secretsPath, err)
+		}
+		format = strings.TrimSuffix(secretsPath, ".age")
+	}
+
+	secrets := make(map[string]string)
+	switch filepath.Ext(format) {
+	case ".yaml", ".yml":
+		err = yamlv3.Unmarshal(content, &secrets)
+	default:
+		secrets, err = parseDotenv(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse secrets file '%s': %w", secretsPath, err)
+	}
+	log.Ctx(ctx).Debug().Msgf("read %d secrets from %s", len(secrets), secretsPath)
+	return mapSecretProvider(secrets), nil
+}
+
+// parseDotenv parses lines of NAME=value, optionally prefixed with export. Values can be single quoted, taken as is,
+// or double quoted, with \n, \" and \\ escapes. Blank lines and lines starting with # are ignored.
+func parseDotenv(content []byte) (map[string]string, error) {
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			value = unquoted
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = strings.TrimSpace(value[:comment])
+			}
+		}
+		secrets[name] = value
+	}
+	return secrets, scanner.Err()
+}
+
+// secretsFileHeader starts the files encrypted by [EncryptSecrets]
+const secretsFileHeader = "ccr-secrets-v1\n"
+
+const secretsSaltSize = 16
+
+// EncryptSecrets encrypts content with a key derived from passphrase by scrypt, with XChaCha20-Poly1305
+func EncryptSecrets(content []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, secretsSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := secretsCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encrypted := append([]byte(secretsFileHeader), salt...)
+	encrypted = append(encrypted, nonce...)
+	return aead.Seal(encrypted, nonce, content, []byte(secretsFileHeader)), nil
+}
+
+// DecryptSecrets decrypts content encrypted by [EncryptSecrets]
+func DecryptSecrets(content []byte, passphrase string) ([]byte, error) {
+	if !bytes.HasPrefix(content, []byte(secretsFileHeader)) {
+		return nil, fmt.Errorf("not a file encrypted by 'ccr secrets encrypt'")
+	}
+	content = content[len(secretsFileHeader):]
+	if len(content) < secretsSaltSize+chacha20poly1305.NonceSizeX {
+		return nil, fmt.Errorf("file is truncated")
+	}
+	aead, err := secretsCipher(passphrase, content[:secretsSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	content = content[secretsSaltSize:]
+	decrypted, err := aead.Open(nil, content[:aead.NonceSize()], content[aead.NonceSize():], []byte(secretsFileHeader))
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted file")
+	}
+	return decrypted, nil
+}
+
+// secretsCipher derives the key from passphrase and salt
+func secretsCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// secretsPassphrase returns the passphrase of the secrets file from CCR_SECRETS_PASSPHRASE, or prompts for it
+func secretsPassphrase(secretsPath string) (string, error) {
+	if passphrase, ok := os.LookupEnv("CCR_SECRETS_PASSPHRASE"); ok {
+		return passphrase, nil
+	}
+	return PromptPassphrase(fmt.Sprintf("Passphrase for %s", secretsPath))
+}
+
+// PromptPassphrase prompts for a passphrase without echoing it. It fails without a terminal to prompt.
+func PromptPassphrase(label string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("unable to prompt for '%s' without a terminal, set CCR_SECRETS_PASSPHRASE instead", label)
+	}
+	prompt := promptui.Prompt{
+		Label:  label,
+		Mask:   '*',
+		Stdout: &bellSkipper{},
+	}
+	return prompt.Run()
+}
+
+// decryptAge decrypts the file with the age command, which prompts for the passphrase if the file needs one
+func decryptAge(ctx context.Context, agePath string, ageIdentity string) ([]byte, error) {
+	args := []string{"--decrypt"}
+	if ageIdentity != "" {
+		args = append(args, "--identity", ageIdentity)
+	}
+	args = append(args, agePath)
+	cmd := exec.CommandContext(ctx, "age", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// NewExecSecretProvider returns a [SecretProvider] that runs the helper command with the name of each secret as its last
+// argument, and reads the value of the secret from its output. A helper that exits successfully without printing
+// anything doesn't define the secret. The value of each secret is only asked for once.
+func NewExecSecretProvider(command string) (SecretProvider, error) {
+	args, err := shellquote.Split(command)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse secrets helper '%s': %w", command, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("secrets helper is empty")
+	}
+	return &execSecretProvider{
+		args:    args,
+		secrets: make(map[string]string),
+	}, nil
+}
+
+type execSecretProvider struct {
+	args    []string
+	secrets map[string]string
+	mutex   sync.Mutex
+}
+
+func (esp *execSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	esp.mutex.Lock()
+	defer esp.mutex.Unlock()
+	if value, ok := esp.secrets[name]; ok {
+		return value, nil
+	}
+	stderr := new(bytes.Buffer)
+	cmd := exec.CommandContext(ctx, esp.args[0], append(esp.args[1:], name)...)
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to get secret '%s' from helper: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	if len(output) == 0 {
+		return "", &SecretUndefinedError{Name: name}
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(output), "\n"), "\r")
+	esp.secrets[name] = value
+	return value, nil
+}
@@ -0,0 +1,74 @@
+package workflows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretProviders(t *testing.T) {
+	assert := assert.New(t)
+	ctx := log.Logger.WithContext(context.Background())
+	dir := t.TempDir()
+
+	dotenvPath := filepath.Join(dir, ".env")
+	assert.NoError(os.WriteFile(dotenvPath, []byte(`# deploy secrets
+export API_TOKEN=from-dotenv # trailing comment
+DB_PASSWORD="p@ss\nword"
+RAW='$NOT_EXPANDED'
+`), 0644))
+	dotenv, err := ReadSecretsFile(ctx, dotenvPath, "")
+	assert.NoError(err)
+	assert.Equal(mapSecretProvider{
+		"API_TOKEN":   "from-dotenv",
+		"DB_PASSWORD": "p@ss\nword",
+		"RAW":         "$NOT_EXPANDED",
+	}, dotenv)
+
+	// an encrypted YAML file
+	encrypted, err := EncryptSecrets([]byte("API_TOKEN: from-yaml\nSIGNING_KEY: key\n"), "passphrase")
+	assert.NoError(err)
+	encryptedPath := filepath.Join(dir, "secrets.yaml.enc")
+	assert.NoError(os.WriteFile(encryptedPath, encrypted, 0600))
+	t.Setenv("CCR_SECRETS_PASSPHRASE", "wrong")
+	_, err = ReadSecretsFile(ctx, encryptedPath, "")
+	assert.ErrorContains(err, "wrong passphrase")
+	t.Setenv("CCR_SECRETS_PASSPHRASE", "passphrase")
+	yaml, err := ReadSecretsFile(ctx, encryptedPath, "")
+	assert.NoError(err)
+	assert.Equal(mapSecretProvider{"API_TOKEN": "from-yaml", "SIGNING_KEY": "key"}, yaml)
+
+	// a helper that echoes the name of the secret
+	helper, err := NewExecSecretProvider("echo 'from helper'")
+	assert.NoError(err)
+	value, err := helper.GetSecret(ctx, "ANY")
+	assert.NoError(err)
+	assert.Equal("from helper ANY", value)
+	helper, err = NewExecSecretProvider("false")
+	assert.NoError(err)
+	_, err = helper.GetSecret(ctx, "ANY")
+	assert.ErrorContains(err, "unable to get secret 'ANY' from helper")
+
+	// the first provider that defines a secret wins, then the environment
+	t.Setenv("FROM_ENV", "env")
+	secrets, err := NewSecretProvider(ctx, &SecretProviderParams{SecretsFiles: []string{encryptedPath, dotenvPath}})
+	assert.NoError(err)
+	for name, expected := range map[string]string{
+		"API_TOKEN":   "from-yaml",
+		"DB_PASSWORD": "p@ss\nword",
+		"FROM_ENV":    "env",
+	} {
+		value, err := secrets.GetSecret(ctx, name)
+		assert.NoError(err)
+		assert.Equal(expected, value)
+	}
+	_, err = secrets.GetSecret(ctx, "MISSING")
+	assert.EqualError(err, "secret 'MISSING' undefined")
+
+	_, err = parseDotenv([]byte("NOT_A_SECRET\n"))
+	assert.EqualError(err, "line 1: expected NAME=value")
+}
@@ -0,0 +1,338 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TestSuite is the results of the tests of a suite found in a test report
+type TestSuite struct {
+	Name     string        // name of the suite
+	Passed   int           // number of tests that passed
+	Failed   int           // number of tests that failed, including errors
+	Skipped  int           // number of tests that were skipped or not run
+	Failures []TestFailure // tests that failed
+}
+
+// TestFailure describes a test that failed
+type TestFailure struct {
+	Name    string // name of the test
+	Message string // message of the failure
+}
+
+// add records the result of a test in the suite
+func (ts *TestSuite) add(name string, result testResult, message string) {
+	switch result {
+	case testResultPassed:
+		ts.Passed++
+	case testResultFailed:
+		ts.Failed++
+		ts.Failures = append(ts.Failures, TestFailure{Name: name, Message: strings.TrimSpace(message)})
+	default:
+		ts.Skipped++
+	}
+}
+
+type testResult int
+
+const (
+	testResultPassed testResult = iota
+	testResultFailed
+	testResultSkipped
+)
+
+// addTestSuite adds the suite to the report, unless it doesn't contain any test
+func addTestSuite(report *Report, suite *TestSuite) {
+	if suite.Passed+suite.Failed+suite.Skipped > 0 {
+		report.TestSuites = append(report.TestSuites, *suite)
+	}
+}
+
+// updatePassRate sets the PassRate of the report from its test suites. Skipped tests are not counted.
+func updatePassRate(report *Report) {
+	passed, failed := 0, 0
+	for _, suite := range report.TestSuites {
+		passed += suite.Passed
+		failed += suite.Failed
+	}
+	if passed+failed == 0 {
+		return
+	}
+	passRate := float32(passed) * 100 / float32(passed+failed)
+	report.PassRate = &passRate
+}
+
+// logTestSuites logs the results of each test suite of the report, along with the tests that failed
+func logTestSuites(ctx context.Context, name string, report *Report) {
+	for _, suite := range report.TestSuites {
+		event := log.Ctx(ctx).Info()
+		if suite.Failed > 0 {
+			event = log.Ctx(ctx).Warn()
+		}
+		event.Msgf("🧪 %s %s: %d passed, %d failed, %d skipped", name, suite.Name, suite.Passed, suite.Failed, suite.Skipped)
+		for _, failure := range suite.Failures {
+			if failure.Message == "" {
+				log.Ctx(ctx).Warn().Msgf("   ✗ %s", failure.Name)
+			} else {
+				log.Ctx(ctx).Warn().Msgf("   ✗ %s: %s", failure.Name, firstLine(failure.Message))
+			}
+		}
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// decodeXMLReport decodes a report if its root element is one of the expected elements, and returns false otherwise
+func decodeXMLReport(reader io.Reader, v any, rootElements ...string) bool {
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			for _, rootElement := range rootElements {
+				if start.Name.Local == rootElement {
+					if err := decoder.DecodeElement(v, &start); err != nil {
+						log.Debug().Err(err).Msgf("Skipping invalid %s report", rootElement)
+						return false
+					}
+					return true
+				}
+			}
+			return false
+		}
+	}
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Suites    []junitTestSuite `xml:"testsuite"`
+	TestCases []struct {
+		Name      string `xml:"name,attr"`
+		ClassName string `xml:"classname,attr"`
+		Failure   *struct {
+			Message string `xml:"message,attr"`
+			Text    string `xml:",chardata"`
+		} `xml:"failure"`
+		Error *struct {
+			Message string `xml:"message,attr"`
+			Text    string `xml:",chardata"`
+		} `xml:"error"`
+		Skipped *struct{} `xml:"skipped"`
+	} `xml:"testcase"`
+}
+
+func (s *junitTestSuite) addTo(report *Report) {
+	suite := &TestSuite{Name: s.Name}
+	for _, testCase := range s.TestCases {
+		switch {
+		case testCase.Failure != nil:
+			suite.add(testCase.Name, testResultFailed, firstNonEmpty(testCase.Failure.Message, testCase.Failure.Text))
+		case testCase.Error != nil:
+			suite.add(testCase.Name, testResultFailed, firstNonEmpty(testCase.Error.Message, testCase.Error.Text))
+		case testCase.Skipped != nil:
+			suite.add(testCase.Name, testResultSkipped, "")
+		default:
+			suite.add(testCase.Name, testResultPassed, "")
+		}
+	}
+	addTestSuite(report, suite)
+	for i := range s.Suites {
+		s.Suites[i].addTo(report)
+	}
+}
+
+// junitReportHandler reads the results of JUnit XML reports, with either a testsuites or a testsuite root element
+func junitReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		root := new(junitTestSuite)
+		if !decodeXMLReport(reader, root, "testsuites", "testsuite") {
+			log.Debug().Msgf("Skipping non-junit report")
+			return nil
+		}
+		root.addTo(report)
+		return nil
+	}
+}
+
+// testngReportHandler reads the results of TestNG XML reports. Configuration methods are not counted as tests.
+func testngReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var results struct {
+			Suites []struct {
+				Name    string `xml:"name,attr"`
+				Methods []struct {
+					Name     string `xml:"name,attr"`
+					Status   string `xml:"status,attr"`
+					IsConfig bool   `xml:"is-config,attr"`
+					Message  string `xml:"exception>message"`
+				} `xml:"test>class>test-method"`
+			} `xml:"suite"`
+		}
+		if !decodeXMLReport(reader, &results, "testng-results") {
+			log.Debug().Msgf("Skipping non-testng report")
+			return nil
+		}
+		for _, s := range results.Suites {
+			suite := &TestSuite{Name: s.Name}
+			for _, method := range s.Methods {
+				if method.IsConfig {
+					continue
+				}
+				switch strings.ToUpper(method.Status) {
+				case "PASS":
+					suite.add(method.Name, testResultPassed, "")
+				case "FAIL":
+					suite.add(method.Name, testResultFailed, method.Message)
+				default:
+					suite.add(method.Name, testResultSkipped, "")
+				}
+			}
+			addTestSuite(report, suite)
+		}
+		return nil
+	}
+}
+
+// cucumberReportHandler reads the results of Cucumber JSON reports. Each feature is a suite and each scenario is a test.
+func cucumberReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var features []struct {
+			Name     string `json:"name"`
+			Elements []struct {
+				Name  string `json:"name"`
+				Type  string `json:"type"`
+				Steps []struct {
+					Result struct {
+						Status       string `json:"status"`
+						ErrorMessage string `json:"error_message"`
+					} `json:"result"`
+				} `json:"steps"`
+			} `json:"elements"`
+		}
+		if err := json.NewDecoder(reader).Decode(&features); err != nil {
+			log.Debug().Err(err).Msgf("Skipping non-cucumber report")
+			return nil
+		}
+		for _, feature := range features {
+			suite := &TestSuite{Name: feature.Name}
+			for _, scenario := range feature.Elements {
+				if scenario.Type == "background" {
+					continue
+				}
+				result, message := testResultPassed, ""
+				for _, step := range scenario.Steps {
+					switch step.Result.Status {
+					case "passed":
+					case "failed":
+						result, message = testResultFailed, step.Result.ErrorMessage
+					default:
+						if result == testResultPassed {
+							result = testResultSkipped
+						}
+					}
+					if result == testResultFailed {
+						break
+					}
+				}
+				suite.add(scenario.Name, result, message)
+			}
+			addTestSuite(report, suite)
+		}
+		return nil
+	}
+}
+
+// trxReportHandler reads the results of Visual Studio TRX reports
+func trxReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var testRun struct {
+			Name    string `xml:"name,attr"`
+			Results []struct {
+				TestName string `xml:"testName,attr"`
+				Outcome  string `xml:"outcome,attr"`
+				Message  string `xml:"Output>ErrorInfo>Message"`
+			} `xml:"Results>UnitTestResult"`
+		}
+		if !decodeXMLReport(reader, &testRun, "TestRun") {
+			log.Debug().Msgf("Skipping non-trx report")
+			return nil
+		}
+		suite := &TestSuite{Name: testRun.Name}
+		for _, result := range testRun.Results {
+			switch result.Outcome {
+			case "Passed":
+				suite.add(result.TestName, testResultPassed, "")
+			case "Failed", "Error", "Timeout", "Aborted":
+				suite.add(result.TestName, testResultFailed, result.Message)
+			default:
+				suite.add(result.TestName, testResultSkipped, "")
+			}
+		}
+		addTestSuite(report, suite)
+		return nil
+	}
+}
+
+type nunitTestSuite struct {
+	Name        string           `xml:"name,attr"`
+	Suites      []nunitTestSuite `xml:"test-suite"`
+	ResultSuite []nunitTestSuite `xml:"results>test-suite"`
+	TestCases   []nunitTestCase  `xml:"test-case"`
+	ResultCases []nunitTestCase  `xml:"results>test-case"`
+}
+
+type nunitTestCase struct {
+	Name    string `xml:"name,attr"`
+	Result  string `xml:"result,attr"`
+	Message string `xml:"failure>message"`
+}
+
+func (s *nunitTestSuite) addTo(report *Report) {
+	suite := &TestSuite{Name: s.Name}
+	for _, testCase := range append(s.TestCases, s.ResultCases...) {
+		switch testCase.Result {
+		case "Passed", "Success":
+			suite.add(testCase.Name, testResultPassed, "")
+		case "Failed", "Failure", "Error":
+			suite.add(testCase.Name, testResultFailed, testCase.Message)
+		default:
+			suite.add(testCase.Name, testResultSkipped, "")
+		}
+	}
+	addTestSuite(report, suite)
+	for _, child := range append(s.Suites, s.ResultSuite...) {
+		child.addTo(report)
+	}
+}
+
+// nunitReportHandler reads the results of NUnit 2 and NUnit 3 XML reports. Each suite that contains tests is reported.
+func nunitReportHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		root := new(nunitTestSuite)
+		if !decodeXMLReport(reader, root, "test-results", "test-run") {
+			log.Debug().Msgf("Skipping non-nunit report")
+			return nil
+		}
+		root.addTo(report)
+		return nil
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package workflows
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestReportHandlers(t *testing.T) {
+	type TestParams struct {
+		TestCase           string
+		Format             ReportFormat
+		ReportPath         string
+		ExpectedTestSuites []TestSuite
+	}
+
+	cartSuite := func(name string, removesItem string, message string) TestSuite {
+		return TestSuite{Name: name, Passed: 1, Failed: 1, Skipped: 1, Failures: []TestFailure{{Name: removesItem, Message: message}}}
+	}
+	for _, tt := range []*TestParams{
+		{
+			TestCase:   "JUnit",
+			Format:     ReportFormatJUnitXML,
+			ReportPath: "testdata/reports/tests/junit.xml",
+			ExpectedTestSuites: []TestSuite{
+				cartSuite("com.example.CartTest", "removesItem", "expected 0 but was 1"),
+				{Name: "com.example.CheckoutTest", Passed: 1, Failed: 1, Failures: []TestFailure{{Name: "paysByInvoice", Message: "java.lang.NullPointerException\n\tat com.example.Checkout.pay(Checkout.java:42)"}}},
+			},
+		},
+		{
+			TestCase:   "TestNG",
+			Format:     ReportFormatTestNG,
+			ReportPath: "testdata/reports/tests/testng.xml",
+			ExpectedTestSuites: []TestSuite{
+				{Name: "Regression", Passed: 2, Failed: 1, Skipped: 1, Failures: []TestFailure{{Name: "removesItem", Message: "expected [0] but found [1]"}}},
+			},
+		},
+		{
+			TestCase:   "Cucumber",
+			Format:     ReportFormatCucumberJSON,
+			ReportPath: "testdata/reports/tests/cucumber.json",
+			ExpectedTestSuites: []TestSuite{
+				cartSuite("Checkout", "Pay by invoice", "invoice service unavailable"),
+			},
+		},
+		{
+			TestCase:   "Visual Studio TRX",
+			Format:     ReportFormatVisualStudioTRX,
+			ReportPath: "testdata/reports/tests/results.trx",
+			ExpectedTestSuites: []TestSuite{
+				cartSuite("ci@build 2024-05-01", "RemovesItem", "Assert.AreEqual failed. Expected:<0>. Actual:<1>."),
+			},
+		},
+		{
+			TestCase:   "NUnit 2",
+			Format:     ReportFormatNUnitXML,
+			ReportPath: "testdata/reports/tests/nunit2.xml",
+			ExpectedTestSuites: []TestSuite{
+				cartSuite("CartTests", "Example.CartTests.RemovesItem", "Expected: 0\n  But was:  1"),
+			},
+		},
+		{
+			TestCase:   "NUnit 3",
+			Format:     ReportFormatNUnit3XML,
+			ReportPath: "testdata/reports/tests/nunit3.xml",
+			ExpectedTestSuites: []TestSuite{
+				cartSuite("CartTests", "RemovesItem", "Expected: 0 But was: 1"),
+			},
+		},
+		{
+			TestCase:   "Wrong format",
+			Format:     ReportFormatJUnitXML,
+			ReportPath: "testdata/reports/tests/testng.xml",
+		},
+		{
+			TestCase:   "SARIF",
+			Format:     ReportFormatCucumberJSON,
+			ReportPath: "testdata/reports/sarif-high-severity/sarif.json",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			handlers := reportHandlers(tt.Format)
+			assert.Len(handlers, 1)
+			reportFile, err := os.Open(tt.ReportPath)
+			assert.NoError(err)
+			defer reportFile.Close()
+
+			// run the handler
+			report := new(Report)
+			err = handlers[0](reportFile, report)
+
+			// assert the results
+			assert.NoError(err)
+			assert.Equal(tt.ExpectedTestSuites, report.TestSuites)
+		})
+	}
+}
+
+func TestTestReportPassRate(t *testing.T) {
+	type TestParams struct {
+		TestCase         string
+		Format           ReportFormat
+		PassRate         float32
+		ExpectedPassRate float32
+		ExpectedResult   Result
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:         "Pass rate met",
+			Format:           ReportFormatJUnitXML,
+			PassRate:         50,
+			ExpectedPassRate: 50,
+			ExpectedResult:   ResultSucceeded,
+		},
+		{
+			TestCase:         "Pass rate not met",
+			Format:           ReportFormatJUnitXML,
+			PassRate:         90,
+			ExpectedPassRate: 50,
+			ExpectedResult:   ResultFailed,
+		},
+		{
+			TestCase:         "Auto discovered",
+			PassRate:         60,
+			ExpectedPassRate: float32(100) * 8 / 15,
+			ExpectedResult:   ResultFailed,
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			ctx := context.Background()
+			report := new(Report)
+			config := &ReportConfig{
+				Format:          tt.Format,
+				SuccessCriteria: &SuccessCriteria{PassRate: tt.PassRate},
+			}
+			feature := ReportProcessor("test", config, report, "testdata/reports/tests")
+
+			// setup the mock
+			m := new(runner.MockPlanExecutor)
+			m.OnExecute(ctx).Return(nil)
+
+			// run the feature
+			err := m.Execute(ctx, feature)
+
+			// assert the results
+			if tt.ExpectedResult == ResultSucceeded {
+				assert.NoError(err)
+			} else {
+				assert.EqualError(err, "report test status FAILED")
+			}
+			assert.Equal(tt.ExpectedResult, report.Result)
+			if assert.NotNil(report.PassRate) {
+				assert.InDelta(tt.ExpectedPassRate, *report.PassRate, 0.01)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/owenrumney/go-sarif/sarif"
+	"github.com/rs/zerolog/log"
+)
+
+// cyclonedxVulnerabilityHandler reads the vulnerabilities of CycloneDX JSON reports, such as a VEX document or the
+// vulnerabilities section of an SBOM. Vulnerabilities that the analysis marks as not affecting the components are suppressed.
+func cyclonedxVulnerabilityHandler() reportHandler {
+	return func(reader io.Reader, report *Report) error {
+		var bom struct {
+			BOMFormat       string `json:"bomFormat"`
+			Vulnerabilities []struct {
+				ID          string `json:"id"`
+				Description string `json:"description"`
+				Detail      string `json:"detail"`
+				Ratings     []struct {
+					Severity string `json:"severity"`
+				} `json:"ratings"`
+				Affects []struct {
+					Ref string `json:"ref"`
+				} `json:"affects"`
+				Analysis *struct {
+					State         string `json:"state"`
+					Justification string `json:"justification"`
+					Detail        string `json:"detail"`
+				} `json:"analysis"`
+			} `json:"vulnerabilities"`
+		}
+		if err := json.NewDecoder(reader).Decode(&bom); err != nil || bom.BOMFormat != "CycloneDX" {
+			log.Debug().Err(err).Msgf("Skipping non-cyclonedx report")
+			return nil
+		}
+		for _, v := range bom.Vulnerabilities {
+			severity := VulnerabilitySeverity("")
+			for _, rating := range v.Ratings {
+				if s := cyclonedxSeverity(rating.Severity); severityOrdinal(s) > severityOrdinal(severity) {
+					severity = s
+				}
+			}
+			if severity == "" {
+				severity = VulnerabilitySeverityMedium
+			}
+			message := v.Description
+			if message == "" {
+				message = v.Detail
+			}
+			var locations []Location
+			for _, affect := range v.Affects {
+				locations = append(locations, Location{URI: affect.Ref})
+			}
+			var suppressions []Suppression
+			if v.Analysis != nil {
+				switch v.Analysis.State {
+				case "not_affected", "false_positive", "resolved", "resolved_with_pedigree":
+					justification := v.Analysis.Justification
+					if v.Analysis.Detail != "" {
+						justification = strings.TrimSpace(justification + " " + v.Analysis.Detail)
+					}
+					suppressions = append(suppressions, Suppression{
+						Kind:          "external",
+						Justification: justification,
+					})
+				}
+			}
+			log.Debug().Msgf("Got vulnerability %s with severity %s", v.ID, severity)
+			report.Vulnerabilities = append(report.Vulnerabilities, Vulnerability{
+				Severity:     severity,
+				RuleID:       v.ID,
+				Message:      message,
+				Locations:    locations,
+				Suppressions: suppressions,
+			})
+		}
+		return nil
+	}
+}
+
+func cyclonedxSeverity(severity string) VulnerabilitySeverity {
+	switch severity {
+	case "critical":
+		return VulnerabilitySeverityCritical
+	case "high":
+		return VulnerabilitySeverityHigh
+	case "medium":
+		return VulnerabilitySeverityMedium
+	case "low":
+		return VulnerabilitySeverityLow
+	case "info", "none":
+		return VulnerabilitySeverityInformational
+	default:
+		return ""
+	}
+}
+
+// VulnerabilitySummary collects the vulnerabilities reported by the actions of a workflow run, de-duplicated by rule and location
+type VulnerabilitySummary struct {
+	mutex           sync.Mutex
+	vulnerabilities []*Vulnerability
+	index           map[string]*Vulnerability
+}
+
+// VulnerabilityCollector is a Feature that adds the vulnerabilities of the report to the summary once the report is processed
+func VulnerabilityCollector(summary *VulnerabilitySummary, report *Report) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER VulnerabilityCollector")
+		err := e(ctx)
+		if summary != nil {
+			summary.Add(report.Vulnerabilities)
+		}
+		log.Ctx(ctx).Debug().Msg("EXIT VulnerabilityCollector")
+		return err
+	}
+}
+
+// Add adds the vulnerabilities of a report. A vulnerability already found keeps the highest severity and is
+// suppressed only if every occurrence is suppressed.
+func (vs *VulnerabilitySummary) Add(vulnerabilities []Vulnerability) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	if vs.index == nil {
+		vs.index = make(map[string]*Vulnerability)
+	}
+	for _, vulnerability := range vulnerabilities {
+		key := fmt.Sprintf("%s|%s", vulnerability.RuleID, vulnerabilityLocation(vulnerability))
+		existing, ok := vs.index[key]
+		if !ok {
+			added := vulnerability
+			existing = &added
+			vs.index[key] = existing
+			vs.vulnerabilities = append(vs.vulnerabilities, existing)
+		} else {
+			if severityOrdinal(vulnerability.Severity) > severityOrdinal(existing.Severity) {
+				existing.Severity = vulnerability.Severity
+			}
+			if len(vulnerability.Suppressions) == 0 {
+				existing.Suppressions = nil
+			}
+		}
+	}
+}
+
+// Vulnerabilities returns the de-duplicated vulnerabilities, by decreasing severity then by rule and location
+func (vs *VulnerabilitySummary) Vulnerabilities() []Vulnerability {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	sorted := make([]*Vulnerability, len(vs.vulnerabilities))
+	copy(sorted, vs.vulnerabilities)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if a, b := severityOrdinal(sorted[i].Severity), severityOrdinal(sorted[j].Severity); a != b {
+			return a > b
+		}
+		if sorted[i].RuleID != sorted[j].RuleID {
+			return sorted[i].RuleID < sorted[j].RuleID
+		}
+		return vulnerabilityLocation(*sorted[i]) < vulnerabilityLocation(*sorted[j])
+	})
+	vulnerabilities := make([]Vulnerability, 0, len(sorted))
+	for _, v := range sorted {
+		vulnerabilities = append(vulnerabilities, *v)
+	}
+	return vulnerabilities
+}
+
+// Log logs a table of the vulnerabilities found during the run
+func (vs *VulnerabilitySummary) Log(ctx context.Context) {
+	vulnerabilities := vs.Vulnerabilities()
+	if len(vulnerabilities) == 0 {
+		return
+	}
+	suppressed := 0
+	for _, v := range vulnerabilities {
+		if len(v.Suppressions) > 0 {
+			suppressed++
+		}
+	}
+	log.Ctx(ctx).Info().Msgf("🛡️ %d vulnerabilities found, %d suppressed", len(vulnerabilities), suppressed)
+	log.Ctx(ctx).Info().Msgf("   %-13s %-24s %-48s %s", "SEVERITY", "RULE", "LOCATION", "SUPPRESSION")
+	for _, v := range vulnerabilities {
+		suppression := "-"
+		if len(v.Suppressions) > 0 {
+			suppression = v.Suppressions[0].Kind
+			if v.Suppressions[0].Justification != "" {
+				suppression = fmt.Sprintf("%s (%s)", suppression, v.Suppressions[0].Justification)
+			}
+		}
+		location := vulnerabilityLocation(v)
+		if location == "" {
+			location = "-"
+		}
+		log.Ctx(ctx).Info().Msgf("   %-13s %-24s %-48s %s", v.Severity, v.RuleID, location, suppression)
+	}
+}
+
+// WriteSARIF writes the vulnerabilities found during the run to a SARIF file at path
+func (vs *VulnerabilitySummary) WriteSARIF(path string) error {
+	sarifReport, err := sarif.New(sarif.Version210)
+	if err != nil {
+		return err
+	}
+	run := sarif.NewRun("codecatalyst-runner", "https://github.com/aws/codecatalyst-runner-cli")
+	for _, v := range vs.Vulnerabilities() {
+		run.AddRule(v.RuleID)
+		result := run.AddResult(v.RuleID).
+			WithLevel(severityToLevel(v.Severity)).
+			WithMessage(sarif.NewTextMessage(v.Message))
+		for _, l := range v.Locations {
+			physicalLocation := sarif.NewPhysicalLocation().WithArtifactLocation(sarif.NewSimpleArtifactLocation(l.URI))
+			if l.StartLine != nil {
+				region := sarif.NewRegion().WithStartLine(*l.StartLine)
+				if l.EndLine != nil {
+					region.WithEndLine(*l.EndLine)
+				}
+				if l.Snippet != "" {
+					region.WithSnippet(sarif.NewArtifactContent().WithText(l.Snippet))
+				}
+				physicalLocation.WithRegion(region)
+			}
+			result.WithLocation(sarif.NewLocationWithPhysicalLocation(physicalLocation))
+		}
+		for _, s := range v.Suppressions {
+			result.WithSuppression(sarif.NewSuppression(s.Kind).WithJustifcation(s.Justification))
+		}
+	}
+	sarifReport.AddRun(run)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return sarifReport.PrettyWrite(file)
+}
+
+// vulnerabilityLocation returns the file:line of the first location of the vulnerability
+func vulnerabilityLocation(vulnerability Vulnerability) string {
+	if len(vulnerability.Locations) == 0 {
+		return ""
+	}
+	location := vulnerability.Locations[0]
+	if location.StartLine == nil {
+		return location.URI
+	}
+	return fmt.Sprintf("%s:%d", location.URI, *location.StartLine)
+}
+
+func severityToLevel(severity VulnerabilitySeverity) string {
+	switch severity {
+	case VulnerabilitySeverityCritical, VulnerabilitySeverityHigh:
+		return "error"
+	case VulnerabilitySeverityMedium:
+		return "warning"
+	case VulnerabilitySeverityLow:
+		return "note"
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,109 @@
+package workflows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/owenrumney/go-sarif/sarif"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCycloneDXVulnerabilityHandler(t *testing.T) {
+	type TestParams struct {
+		TestCase                string
+		ReportPath              string
+		ExpectedVulnerabilities []Vulnerability
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:   "CycloneDX VEX",
+			ReportPath: "testdata/reports/cyclonedx/vex.json",
+			ExpectedVulnerabilities: []Vulnerability{
+				{
+					Severity:  VulnerabilitySeverityCritical,
+					RuleID:    "CVE-2021-23337",
+					Message:   "Command injection in lodash template",
+					Locations: []Location{{URI: "pkg:npm/lodash@4.17.20"}},
+				},
+				{
+					Severity:     VulnerabilitySeverityMedium,
+					RuleID:       "CVE-2021-44906",
+					Message:      "Prototype pollution in minimist",
+					Locations:    []Location{{URI: "pkg:npm/minimist@1.2.5"}},
+					Suppressions: []Suppression{{Kind: "external", Justification: "code_not_reachable"}},
+				},
+			},
+		},
+		{
+			TestCase:   "SPDX SBOM",
+			ReportPath: "testdata/reports/spdx/sbom.json",
+		},
+		{
+			TestCase:   "SARIF report",
+			ReportPath: "testdata/reports/sarif-high-severity/sarif.json",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			reportFile, err := os.Open(tt.ReportPath)
+			assert.NoError(err)
+			defer reportFile.Close()
+
+			report := new(Report)
+			err = cyclonedxVulnerabilityHandler()(reportFile, report)
+
+			assert.NoError(err)
+			assert.Equal(tt.ExpectedVulnerabilities, report.Vulnerabilities)
+		})
+	}
+}
+
+func TestVulnerabilitySummary(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	summary := new(VulnerabilitySummary)
+
+	// the same SARIF and CycloneDX reports are found by two actions
+	for i := 0; i < 2; i++ {
+		report := new(Report)
+		feature := VulnerabilityCollector(summary, report)
+		m := new(runner.MockPlanExecutor)
+		m.OnExecute(ctx).Return(nil)
+		err := m.Execute(ctx, ReportProcessor("sca", &ReportConfig{Format: ReportFormatSARIFSCA}, report, "testdata/reports/sarif-high-severity"))
+		assert.NoError(err)
+		err = m.Execute(ctx, ReportProcessor("vex", &ReportConfig{Format: ReportFormatSARIFSCA}, report, "testdata/reports/cyclonedx"))
+		assert.NoError(err)
+		err = m.Execute(ctx, feature)
+		assert.NoError(err)
+	}
+
+	vulnerabilities := summary.Vulnerabilities()
+	if assert.Len(vulnerabilities, 3) {
+		assert.Equal("CVE-2021-23337", vulnerabilities[0].RuleID)
+		assert.Equal("no-unused-vars", vulnerabilities[1].RuleID)
+		assert.Equal("CVE-2021-44906", vulnerabilities[2].RuleID)
+		assert.Equal("file:///C:/dev/sarif/sarif-tutorials/samples/Introduction/simple-example.js:1", vulnerabilityLocation(vulnerabilities[1]))
+	}
+
+	// a finding that isn't suppressed by every report isn't suppressed
+	summary.Add([]Vulnerability{{Severity: VulnerabilitySeverityLow, RuleID: "CVE-2021-44906", Locations: []Location{{URI: "pkg:npm/minimist@1.2.5"}}}})
+	vulnerabilities = summary.Vulnerabilities()
+	if assert.Len(vulnerabilities, 3) {
+		assert.Equal(VulnerabilitySeverityMedium, vulnerabilities[2].Severity)
+		assert.Empty(vulnerabilities[2].Suppressions)
+	}
+
+	sarifPath := filepath.Join(t.TempDir(), "reports", "vulnerabilities.sarif")
+	assert.NoError(summary.WriteSARIF(sarifPath))
+	sarifReport, err := sarif.Open(sarifPath)
+	if assert.NoError(err) && assert.Len(sarifReport.Runs, 1) {
+		assert.Len(sarifReport.Runs[0].Results, 3)
+		assert.Len(sarifReport.Runs[0].Tool.Driver.Rules, 3)
+		assert.Equal("error", *sarifReport.Runs[0].Results[0].Level)
+	}
+}
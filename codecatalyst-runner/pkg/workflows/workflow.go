@@ -1,6 +1,9 @@
 package workflows
 
 import (
+	"fmt"
+	"sort"
+
 	"gopkg.in/yaml.v2"
 )
 
@@ -16,6 +19,39 @@ type Workflow struct {
 	} `yaml:"Compute"`
 }
 
+// ActionsByID returns every action in the workflow keyed by its ID, along with the IDs in the order they are defined.
+// Actions within an action group are identified as Group@Action.
+func (w *Workflow) ActionsByID() (map[string]*Action, []string, error) {
+	workflowActions := make(map[string]*Action)
+	ids := make([]string, 0)
+	for _, mapItem := range w.Actions {
+		actionName := fmt.Sprint(mapItem.Key)
+		var actionOrGroup ActionOrGroup
+		if buf, err := yaml.Marshal(mapItem.Value); err != nil {
+			return nil, nil, err
+		} else if err := yaml.Unmarshal(buf, &actionOrGroup); err != nil {
+			return nil, nil, err
+		}
+		if actionOrGroup.Action.Identifier != "" {
+			action := actionOrGroup.Action
+			workflowActions[actionName] = &action
+			ids = append(ids, actionName)
+		} else {
+			subNames := make([]string, 0, len(actionOrGroup.Actions))
+			for subName := range actionOrGroup.Actions {
+				subNames = append(subNames, subName)
+			}
+			sort.Strings(subNames)
+			for _, subName := range subNames {
+				fullName := fmt.Sprintf("%s@%s", actionName, subName)
+				workflowActions[fullName] = actionOrGroup.Actions[subName]
+				ids = append(ids, fullName)
+			}
+		}
+	}
+	return workflowActions, ids, nil
+}
+
 // ActionOrGroup is a union of types Action and ActionGroup. Only 1 should be present
 type ActionOrGroup struct {
 	Action      `yaml:",inline"`
@@ -26,6 +62,7 @@ type ActionOrGroup struct {
 type Action struct {
 	Identifier    string         `yaml:"Identifier"`
 	DependsOn     []string       `yaml:"DependsOn"`
+	Timeout       int            `yaml:"Timeout"` // minutes the action may run before it is stopped
 	Configuration map[string]any `yaml:"Configuration"`
 	Inputs        struct {
 		Sources   []string `yaml:"Sources"`
@@ -36,9 +73,11 @@ type Action struct {
 		} `yaml:"Variables"`
 	} `yaml:"Inputs"`
 	Outputs struct {
-		Sources   []string          `yaml:"Sources"`
-		Artifacts []*OutputArtifact `yaml:"Artifacts"`
-		Variables []string          `yaml:"Variables"`
+		Sources             []string                 `yaml:"Sources"`
+		Artifacts           []*OutputArtifact        `yaml:"Artifacts"`
+		Variables           []string                 `yaml:"Variables"`
+		Reports             map[string]*ReportConfig `yaml:"Reports"`
+		AutoDiscoverReports AutoDiscoverReports      `yaml:"AutoDiscoverReports"`
 	} `yaml:"Outputs"`
 	Caching struct {
 		FileCaching FileCaching `yaml:"FileCaching"`
@@ -0,0 +1,276 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+)
+
+// DependencyType describes why an action depends on another action
+type DependencyType string
+
+const (
+	// DependencyTypeDependsOn is a dependency declared in the DependsOn list of an action
+	DependencyTypeDependsOn DependencyType = "DependsOn"
+	// DependencyTypeArtifact is a dependency on the action that produces an input artifact
+	DependencyTypeArtifact DependencyType = "Artifact"
+	// DependencyTypeVariable is a dependency on the action that produces a referenced output variable
+	DependencyTypeVariable DependencyType = "Variable"
+)
+
+// Dependency is an edge between two actions of a workflow
+type Dependency struct {
+	Action   string         // ID of the action that has the dependency
+	Upstream string         // ID of the action that must finish first
+	Type     DependencyType // reason for the dependency
+	Name     string         // the DependsOn entry, artifact name or variable reference that caused the dependency
+}
+
+// ResolveDependencies returns the dependencies between the provided actions, in the order of actionIDs.
+// Dependencies are derived from DependsOn, from the producers of input artifacts and from ${Action.Variable} references.
+// References that can't be resolved to an action are ignored.
+func ResolveDependencies(workflowActions map[string]*Action, actionIDs []string) []*Dependency {
+	dependencies := make([]*Dependency, 0)
+	add := func(dependency *Dependency) {
+		if dependency.Action == dependency.Upstream {
+			return
+		}
+		for _, d := range dependencies {
+			if *d == *dependency {
+				return
+			}
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	artifactProducers := make(map[string]string)
+	for _, actionID := range actionIDs {
+		for _, artifact := range workflowActions[actionID].Outputs.Artifacts {
+			if artifact != nil {
+				artifactProducers[artifact.Name] = actionID
+			}
+		}
+	}
+
+	for _, actionID := range actionIDs {
+		action := workflowActions[actionID]
+		for _, dependsOn := range action.DependsOn {
+			for _, candidateID := range actionIDs {
+				if runner.MatchesDependency(actionID, dependsOn, candidateID) {
+					add(&Dependency{Action: actionID, Upstream: candidateID, Type: DependencyTypeDependsOn, Name: dependsOn})
+				}
+			}
+		}
+		for _, artifact := range action.Inputs.Artifacts {
+			if producerID, ok := artifactProducers[artifact]; ok {
+				add(&Dependency{Action: actionID, Upstream: producerID, Type: DependencyTypeArtifact, Name: artifact})
+			}
+		}
+		for _, reference := range actionVariableReferences(action) {
+			if _, ok := workflowActions[reference.PlanID]; ok {
+				add(&Dependency{Action: actionID, Upstream: reference.PlanID, Type: DependencyTypeVariable, Name: reference.String()})
+			}
+		}
+	}
+	return dependencies
+}
+
+// CheckDependencies verifies that every DependsOn entry, input artifact and variable reference of the provided actions
+// resolves to an action of the workflow, and that the resulting dependencies don't contain a cycle.
+// Without this check, a plan waiting on a dependency that can never finish would block the run.
+func CheckDependencies(workflowActions map[string]*Action, actionIDs []string) error {
+	var rtnError error
+	for _, issue := range dependencyIssues(workflowActions, actionIDs) {
+		rtnError = errors.Join(rtnError, issue.Err)
+	}
+	return rtnError
+}
+
+// dependencyIssue is a problem with the dependencies of an action
+type dependencyIssue struct {
+	Action string // ID of the action with the problem
+	Key    string // path of the offending value, relative to the action definition
+	Err    error
+}
+
+func dependencyIssues(workflowActions map[string]*Action, actionIDs []string) []*dependencyIssue {
+	issues := make([]*dependencyIssue, 0)
+	add := func(actionID string, key string, err error) {
+		issues = append(issues, &dependencyIssue{Action: actionID, Key: key, Err: err})
+	}
+	artifactProducers := make(map[string]string)
+	for _, actionID := range actionIDs {
+		for _, artifact := range workflowActions[actionID].Outputs.Artifacts {
+			if artifact != nil {
+				artifactProducers[artifact.Name] = actionID
+			}
+		}
+	}
+	for _, actionID := range actionIDs {
+		action := workflowActions[actionID]
+		for i, dependsOn := range action.DependsOn {
+			found := false
+			for _, candidateID := range actionIDs {
+				if candidateID != actionID && runner.MatchesDependency(actionID, dependsOn, candidateID) {
+					found = true
+				}
+			}
+			key := fmt.Sprintf("DependsOn[%d]", i)
+			// an action of a group refers to itself by its name within the group
+			if runner.MatchesDependency(actionID, dependsOn, actionID) {
+				add(actionID, key, fmt.Errorf("dependency cycle detected: %s -> %s", actionID, actionID))
+			} else if !found {
+				add(actionID, key, fmt.Errorf("action '%s' depends on '%s', which is not an action or action group in the workflow", actionID, dependsOn))
+			}
+		}
+		for i, artifact := range action.Inputs.Artifacts {
+			if _, ok := artifactProducers[artifact]; !ok {
+				add(actionID, fmt.Sprintf("Inputs.Artifacts[%d]", i), fmt.Errorf("action '%s' consumes artifact '%s', which is not produced by any action in the workflow", actionID, artifact))
+			}
+		}
+		for _, reference := range actionVariableReferences(action) {
+			if _, ok := workflowActions[reference.PlanID]; !ok {
+				add(actionID, "", fmt.Errorf("action '%s' references variable '${%s}', but there is no action '%s' in the workflow", actionID, reference, reference.PlanID))
+			}
+		}
+	}
+
+	for _, cycle := range findCycles(actionIDs, ResolveDependencies(workflowActions, actionIDs)) {
+		add(cycle[0], "DependsOn", fmt.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> ")))
+	}
+	return issues
+}
+
+// findCycles returns the cycles in the dependency graph. Each cycle is a path of action IDs that starts and ends with the same action.
+func findCycles(actionIDs []string, dependencies []*Dependency) [][]string {
+	upstreams := make(map[string][]string)
+	for _, dependency := range dependencies {
+		if !slices.Contains(upstreams[dependency.Action], dependency.Upstream) {
+			upstreams[dependency.Action] = append(upstreams[dependency.Action], dependency.Upstream)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	cycles := make([][]string, 0)
+	path := make([]string, 0)
+	var visit func(actionID string)
+	visit = func(actionID string) {
+		state[actionID] = visiting
+		path = append(path, actionID)
+		for _, upstream := range upstreams[actionID] {
+			switch state[upstream] {
+			case unvisited:
+				visit(upstream)
+			case visiting:
+				start := slices.Index(path, upstream)
+				cycle := slices.Clone(path[start:])
+				cycles = append(cycles, append(cycle, upstream))
+			}
+		}
+		path = path[:len(path)-1]
+		state[actionID] = visited
+	}
+	for _, actionID := range actionIDs {
+		if state[actionID] == unvisited {
+			visit(actionID)
+		}
+	}
+	return cycles
+}
+
+// addImplicitDependencies adds the producers of input artifacts and referenced variables to the DependsOn of each plan,
+// so that the full dependency graph is known before any plan is run
+func addImplicitDependencies(workflowActions map[string]*Action, actionIDs []string, plans []runner.Plan) {
+	dependencies := ResolveDependencies(workflowActions, actionIDs)
+	for _, plan := range plans {
+		for _, dependency := range dependencies {
+			if dependency.Action != plan.ID() || dependency.Type == DependencyTypeDependsOn {
+				continue
+			}
+			if !slices.Contains(plan.DependsOn(), dependency.Upstream) {
+				plan.AddDependsOn(dependency.Upstream)
+			}
+		}
+	}
+}
+
+// variableReference is a reference to an output variable of an action, such as ${Build.IMAGE_TAG}
+type variableReference struct {
+	Prefix string // the action name as written in the reference, e.g. Group.Action
+	Name   string // the name of the variable
+	PlanID string // the ID of the plan that produces the variable, e.g. Group@Action
+}
+
+func (vr variableReference) String() string {
+	return fmt.Sprintf("%s.%s", vr.Prefix, vr.Name)
+}
+
+// actionVariableReferences returns the references to output variables of other actions in the Configuration and Inputs of an action.
+// The secrets and the predefined variables of the sources, such as ${WorkflowSource.CommitId}, are not references to actions.
+func actionVariableReferences(action *Action) []variableReference {
+	values := make([]string, 0)
+	values = appendStrings(values, action.Configuration)
+	for _, variable := range action.Inputs.Variables {
+		values = append(values, variable.Value)
+	}
+	references := make([]variableReference, 0)
+	for _, value := range values {
+		for _, m := range replacementVariablePattern.FindAllStringSubmatch(value, -1) {
+			if m[1] == "Secrets" || m[1] == "WorkflowSource" || slices.Contains(action.Inputs.Sources, m[1]) {
+				continue
+			}
+			reference := variableReference{
+				Prefix: m[1],
+				Name:   m[2],
+				PlanID: strings.Replace(m[1], ".", "@", 1),
+			}
+			if !slices.Contains(references, reference) {
+				references = append(references, reference)
+			}
+		}
+	}
+	return references
+}
+
+// appendStrings appends every string found in value, walking through nested maps and lists
+func appendStrings(values []string, value any) []string {
+	switch v := value.(type) {
+	case string:
+		values = append(values, v)
+	case []any:
+		for _, item := range v {
+			values = appendStrings(values, item)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			values = appendStrings(values, v[key])
+		}
+	case map[any]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, fmt.Sprint(key))
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			for k, item := range v {
+				if fmt.Sprint(k) == key {
+					values = appendStrings(values, item)
+				}
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,110 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveDependencies(t *testing.T) {
+	for _, tt := range []struct {
+		TestCase             string
+		WorkflowPath         string
+		ExpectedDependencies []*Dependency
+	}{
+		{
+			TestCase:     "sample",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml",
+			ExpectedDependencies: []*Dependency{
+				{Action: "Group1@SubAction1", Upstream: "FirstAction", Type: DependencyTypeVariable, Name: "FirstAction.VAR1"},
+				{Action: "Group1@SubAction1", Upstream: "FirstAction", Type: DependencyTypeVariable, Name: "FirstAction.VAR2"},
+				{Action: "Group1@SubAction2", Upstream: "FirstAction", Type: DependencyTypeArtifact, Name: "ARTIFACT1"},
+				{Action: "FinalAction", Upstream: "Group1@SubAction1", Type: DependencyTypeArtifact, Name: "ARTIFACT2"},
+				{Action: "FinalAction", Upstream: "Group1@SubAction1", Type: DependencyTypeVariable, Name: "Group1.SubAction1.VAR3"},
+			},
+		},
+		{
+			TestCase:     "shared",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/shared.yaml",
+			ExpectedDependencies: []*Dependency{
+				{Action: "FirstAction", Upstream: "Custom", Type: DependencyTypeDependsOn, Name: "Custom"},
+				{Action: "Group1@SubAction2", Upstream: "FirstAction", Type: DependencyTypeDependsOn, Name: "FirstAction"},
+			},
+		},
+		{
+			TestCase:     "custom",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/custom.yaml",
+			ExpectedDependencies: []*Dependency{
+				{Action: "Custom", Upstream: "FirstAction", Type: DependencyTypeVariable, Name: "FirstAction.HowToGreet"},
+				{Action: "Custom", Upstream: "FirstAction", Type: DependencyTypeVariable, Name: "FirstAction.WhoToGreet"},
+				{Action: "FinalAction", Upstream: "Custom", Type: DependencyTypeVariable, Name: "Custom.greeting"},
+			},
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			workflow, err := readWorkflow(tt.WorkflowPath)
+			assert.NoError(err)
+			workflowActions, actionIDs, err := workflow.ActionsByID()
+			assert.NoError(err)
+			assert.Equal(tt.ExpectedDependencies, ResolveDependencies(workflowActions, actionIDs))
+		})
+	}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	for _, tt := range []struct {
+		TestCase      string
+		WorkflowPath  string
+		ExpectedError string
+	}{
+		{
+			TestCase:     "sample",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml",
+		},
+		{
+			TestCase:     "shared",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/shared.yaml",
+		},
+		{
+			TestCase:     "sources",
+			WorkflowPath: "testdata/dependencies/sources.yaml",
+		},
+		{
+			TestCase:      "cycle",
+			WorkflowPath:  "testdata/dependencies/cycle.yaml",
+			ExpectedError: "dependency cycle detected: Build -> Test -> Build",
+		},
+		{
+			TestCase:      "group-cycle",
+			WorkflowPath:  "testdata/dependencies/group-cycle.yaml",
+			ExpectedError: "dependency cycle detected: Build -> Tests@Lint -> Tests@Unit -> Build",
+		},
+		{
+			TestCase:      "group-self",
+			WorkflowPath:  "testdata/dependencies/group-self.yaml",
+			ExpectedError: "dependency cycle detected: Tests@Unit -> Tests@Unit",
+		},
+		{
+			TestCase:     "dangling",
+			WorkflowPath: "testdata/dependencies/dangling.yaml",
+			ExpectedError: "action 'Deploy' depends on 'Bulid', which is not an action or action group in the workflow\n" +
+				"action 'Deploy' consumes artifact 'BuildOutput', which is not produced by any action in the workflow\n" +
+				"action 'Deploy' references variable '${Package.IMAGE_TAG}', but there is no action 'Package' in the workflow",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			workflow, err := readWorkflow(tt.WorkflowPath)
+			assert.NoError(err)
+			workflowActions, actionIDs, err := workflow.ActionsByID()
+			assert.NoError(err)
+			err = CheckDependencies(workflowActions, actionIDs)
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -1,69 +1,99 @@
 package workflows
 
 import (
-	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
+	"time"
 
 	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/actions"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
-
-	"gopkg.in/yaml.v2"
 )
 
 // NewWorkflowFeaturesProviderParams contains the params to create a new FeaturesProvider
 type NewWorkflowFeaturesProviderParams struct {
-	runner.EnvironmentConfiguration                   // The configuration of the environments
-	OutputMode                      OutputMode        // Mode to use for output
-	NoOutput                        bool              // Disable output from the action execution
-	NoCache                         bool              // Disable file caches
-	Dryrun                          bool              // Dryrun skips execution of the action
-	BindWorkingDir                  bool              // BindWorkingDir will mount the working directory into the container, rather than copying
-	EnvironmentProfiles             map[string]string // Map of workflow environment names to AWS CLI profile names
-	Workflow                        *Workflow         // Workflow to load features for
-	SecretProvider                  SecretProvider    // Secret provider to use for secrets
+	runner.EnvironmentConfiguration                                  // The configuration of the environments
+	OutputMode                      OutputMode                       // Mode to use for output
+	NoOutput                        bool                             // Disable output from the action execution
+	NoCache                         bool                             // Disable file caches
+	Dryrun                          bool                             // Dryrun skips execution of the action
+	BindWorkingDir                  bool                             // BindWorkingDir will mount the working directory into the container, rather than copying
+	EnvironmentProfiles             map[string]string                // Map of workflow environment names to AWS CLI profile names
+	Workflow                        *Workflow                        // Workflow to load features for
+	SecretProvider                  SecretProvider                   // Secret provider to use for secrets
+	Variables                       map[string]string                // Output variables of actions that aren't run, e.g. Build.IMAGE_TAG=abc123
+	Artifacts                       map[string]string                // Artifacts of actions that aren't run, mapped to a directory or zip file
+	ContinueOnFailure               string                           // Selector of the actions whose failure doesn't skip the actions that depend on them
+	RetryPolicies                   map[string]*features.RetryPolicy // Retry policies keyed by action selector
+	ActionTimeout                   time.Duration                    // Maximum duration of each action, on top of its own Timeout, zero for no limit
+	Vulnerabilities                 *VulnerabilitySummary            // Summary to collect the vulnerabilities reported by the actions into
+	Annotations                     *actions.Annotations             // Collection of the annotations printed by the actions
+	Summaries                       *actions.RunSummaries            // Collection of the summary messages of the actions
+	PlanTracker                     *features.PlanTracker            // Tracker of the progress of the actions, with the failures of those that continue on failure
+	SBOMs                           *SBOMSummary                     // Summary to collect the components of the SBOMs detected in the reports of the actions into
+	Events                          *features.EventStream            // Stream to write the events of the actions to, required for OutputModeJSON
+	JUnitReport                     *features.JUnitReport            // Report to record the result and output of each action in, if any
+	Run                             *RunRecord                       // Record of the run to keep the history of each action in, if any
+	Stubs                           map[string]string                // Path of the stub file of each action to stub, by action ID
+	RecordStubs                     bool                             // Run the stubbed actions and record their outputs and artifacts to their stub files
 }
 
 // NewWorkflowFeaturesProvider creates a FeaturesProvider for [Workflow]
 func NewWorkflowFeaturesProvider(params *NewWorkflowFeaturesProviderParams) (runner.FeaturesProvider, error) {
-	workflowActions := make(map[string]*Action)
-	for _, mapItem := range params.Workflow.Actions {
-		actionName := mapItem.Key.(string)
-		var actionOrGroup ActionOrGroup
-		if buf, err := yaml.Marshal(mapItem.Value); err != nil {
-			return nil, err
-		} else if err := yaml.Unmarshal(buf, &actionOrGroup); err != nil {
-			return nil, err
-		}
-		if actionOrGroup.Action.Identifier != "" {
-			workflowActions[actionName] = &actionOrGroup.Action
-		} else {
-			for subName, action := range actionOrGroup.Actions {
-				fullName := fmt.Sprintf("%s@%s", actionName, subName)
-				workflowActions[fullName] = action
-			}
+	workflowActions, actionIDs, err := params.Workflow.ActionsByID()
+	if err != nil {
+		return nil, err
+	}
+
+	planTracker := params.PlanTracker
+	if planTracker == nil {
+		planTracker = new(features.PlanTracker)
+	}
+	if params.ContinueOnFailure != "" {
+		if planTracker.ContinueOnFailure, err = SelectActions(params.ContinueOnFailure, workflowActions, actionIDs); err != nil {
+			return nil, fmt.Errorf("invalid continue on failure selection: %w", err)
 		}
 	}
 
-	cacheDir, err := os.UserCacheDir()
+	cacheDir, err := workflowCacheDir(params.Workflow)
 	if err != nil {
 		return nil, err
 	}
-	sha := sha256.Sum256([]byte(params.Workflow.Path))
-	workflowHash := hex.EncodeToString(sha[:])
-	cacheDir = filepath.Join(cacheDir, "codecatalyst-runner", "workflows", workflowHash)
 
 	secretProvider := params.SecretProvider
 	if secretProvider == nil {
 		secretProvider = new(envSecretProvider)
 	}
 
+	retryPolicies, err := actionRetryPolicies(params.RetryPolicies, workflowActions, actionIDs, filepath.Join(cacheDir, "attempts"))
+	if err != nil {
+		return nil, err
+	}
+
+	stubs, err := actionStubs(params.Stubs, workflowActions, params.RecordStubs)
+	if err != nil {
+		return nil, err
+	}
+
+	artifactPlans := make(map[string]string)
+	if err := seedOutputs(workflowActions, params.Variables, params.Artifacts, artifactPlans, cacheDir); err != nil {
+		return nil, err
+	}
+
+	summaries := params.Summaries
+	if summaries == nil {
+		summaries = new(actions.RunSummaries)
+	}
+
+	if params.OutputMode == OutputModeJSON && params.Events == nil {
+		return nil, fmt.Errorf("an event stream is required for the %s output mode", OutputModeJSON)
+	}
+
 	return &workflowFeaturesProvider{
 		EnvironmentConfiguration: params.EnvironmentConfiguration,
 		cacheDir:                 cacheDir,
@@ -74,18 +104,42 @@ func NewWorkflowFeaturesProvider(params *NewWorkflowFeaturesProviderParams) (run
 		bindWorkingDir:           params.BindWorkingDir,
 		sharedCompute:            params.Workflow.Compute.SharedInstance,
 		workflowActions:          workflowActions,
-		artifactPlans:            make(map[string]string),
+		artifactPlans:            artifactPlans,
 		environmentProfiles:      params.EnvironmentProfiles,
-		planTracker:              new(features.PlanTracker),
+		planTracker:              planTracker,
+		retryPolicies:            retryPolicies,
+		actionTimeout:            params.ActionTimeout,
 		secretProvider:           secretProvider,
+		vulnerabilities:          params.Vulnerabilities,
+		annotations:              params.Annotations,
+		summaries:                summaries,
+		sboms:                    params.SBOMs,
+		events:                   params.Events,
+		junitReport:              params.JUnitReport,
+		run:                      params.Run,
+		stubs:                    stubs,
+		stubPaths:                params.Stubs,
+		recordStubs:              params.RecordStubs,
 	}, nil
 }
 
+// workflowCacheDir returns the directory of the user cache dir that holds the caches, artifacts and reports of the workflow
+func workflowCacheDir(workflow *Workflow) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sha := sha256.Sum256([]byte(workflow.Path))
+	workflowHash := hex.EncodeToString(sha[:])
+	return filepath.Join(cacheDir, "codecatalyst-runner", "workflows", workflowHash), nil
+}
+
 type OutputMode string
 
 const (
 	OutputModeText OutputMode = "text"
 	OutputModeTUI  OutputMode = "tui"
+	OutputModeJSON OutputMode = "json"
 )
 
 type workflowFeaturesProvider struct {
@@ -103,6 +157,18 @@ type workflowFeaturesProvider struct {
 	isWorkingDirSetup   bool
 	planTracker         *features.PlanTracker
 	secretProvider      SecretProvider
+	retryPolicies       map[string]*features.RetryPolicy // retry policy of each action
+	actionTimeout       time.Duration
+	vulnerabilities     *VulnerabilitySummary
+	annotations         *actions.Annotations
+	summaries           *actions.RunSummaries
+	sboms               *SBOMSummary
+	events              *features.EventStream
+	junitReport         *features.JUnitReport
+	run                 *RunRecord
+	stubs               map[string]*Stub  // stub of each stubbed action
+	stubPaths           map[string]string // path of the stub file of each stubbed action
+	recordStubs         bool
 }
 
 var planOutputs = make(map[string]map[string]string)
@@ -124,12 +190,34 @@ func (wfp *workflowFeaturesProvider) Features(plan runner.Plan) ([]runner.Featur
 		loggerFeature = features.TUILogger(plan.ID())
 	case OutputModeText:
 		loggerFeature = features.ConsoleLogger()
+	case OutputModeJSON:
+		loggerFeature = features.JSONLogger(wfp.events, plan.ID())
+	}
+	artifacts := make([]string, 0)
+	if action != nil {
+		for _, artifact := range action.Outputs.Artifacts {
+			artifacts = append(artifacts, artifact.Name)
+		}
 	}
-	ft := []runner.Feature{
+
+	ft := make([]runner.Feature, 0)
+	if stub, ok := wfp.stubs[plan.ID()]; ok {
+		ft = append(ft, StubAction(stub, outputs, wfp.cacheDir))
+	}
+	if wfp.junitReport != nil {
+		ft = append(ft, features.JUnitOutputCapturer(wfp.junitReport, plan.ID()))
+	}
+	ft = append(ft,
 		features.Reuse(wfp.Reuse),
-		actions.ActionOutputHandler(outputs, false),
-		features.Dryrun(wfp.dryrun),
+		actions.ActionOutputHandler(outputs, wfp.annotations, wfp.summaries, false),
+	)
+	if stubPath, ok := wfp.stubPaths[plan.ID()]; ok && wfp.recordStubs {
+		ft = append(ft, StubRecorder(stubPath, outputs, artifacts, wfp.cacheDir))
 	}
+	ft = append(ft,
+		features.Dryrun(wfp.dryrun),
+		features.Retry(wfp.retryPolicies[plan.ID()]),
+	)
 
 	if wfp.sharedCompute || (action != nil && slices.Contains(action.Inputs.Sources, "WorkflowSource")) {
 		ft = append(ft,
@@ -156,7 +244,35 @@ func (wfp *workflowFeaturesProvider) Features(plan runner.Plan) ([]runner.Featur
 		ft = append(ft, FileCache(wfp.EnvironmentConfiguration.WorkingDir, action.Caching.FileCaching, staticCacheDirProvider(wfp.cacheDir)))
 	}
 
+	reportResults := make(map[string]*Report)
+	if action != nil {
+		reports := actionReports(action)
+		sboms := make([]features.SBOM, 0)
+		for _, name := range sortedReportNames(reports) {
+			reportDir := filepath.Join(wfp.cacheDir, "reports", plan.ID(), name)
+			report := new(Report)
+			reportResults[name] = report
+			ft = append(ft,
+				ReportProcessor(name, reports[name], report, reportDir),
+				VulnerabilityCollector(wfp.vulnerabilities, report),
+				features.SBOMsDetector(reportDir, &sboms),
+				ReportCollector(reports[name], reportDir),
+			)
+		}
+		if len(reports) > 0 {
+			ft = append(ft, SBOMCollector(wfp.sboms, plan.ID(), &sboms))
+		}
+	}
+
+	var timeout time.Duration
+	if action != nil {
+		timeout = time.Duration(action.Timeout) * time.Minute
+	}
+	if wfp.actionTimeout > 0 && (timeout <= 0 || wfp.actionTimeout < timeout) {
+		timeout = wfp.actionTimeout
+	}
 	ft = append(ft,
+		features.Timeout(timeout),
 		features.StatusLogger(plan.ID()),
 	)
 	if action != nil {
@@ -168,17 +284,16 @@ func (wfp *workflowFeaturesProvider) Features(plan runner.Plan) ([]runner.Featur
 	ft = append(ft,
 		ReplaceVariableHandler(planOutputs, wfp.secretProvider),
 		InputVariableHandler(inputs),
+	)
+	if wfp.run != nil {
+		ft = append(ft, ActionRecorder(wfp.run, plan.ID(), outputs, reportResults, artifacts, wfp.summaries, wfp.cacheDir))
+	}
+	ft = append(ft,
 		features.DependsOn(wfp.planTracker.ProgressHandle(plan.ID())),
-		loggerFeature,
 	)
-	return ft, nil
-}
-
-type envSecretProvider struct{}
-
-func (ssp *envSecretProvider) GetSecret(_ context.Context, name string) (string, error) {
-	if val, ok := os.LookupEnv(name); ok {
-		return val, nil
+	if wfp.junitReport != nil {
+		ft = append(ft, features.JUnitRecorder(wfp.junitReport, plan.ID()))
 	}
-	return "", fmt.Errorf("secret '%s' undefined", name)
+	ft = append(ft, loggerFeature)
+	return ft, nil
 }
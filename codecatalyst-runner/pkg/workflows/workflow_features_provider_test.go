@@ -1,8 +1,10 @@
 package workflows
 
 import (
+	"io"
 	"testing"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
 	"github.com/stretchr/testify/assert"
@@ -24,6 +26,27 @@ func TestNewActionFeaturesProvider(t *testing.T) {
 		mp := new(runner.MockPlan).WithID("test1")
 		features, err := featuresProvider.Features(mp)
 		assert.NoError(err)
-		assert.Len(features, 8)
+		assert.Len(features, 10)
+	}
+}
+
+func TestNewActionFeaturesProviderJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	// the event stream of the json output mode is created by the caller
+	_, err := NewWorkflowFeaturesProvider(&NewWorkflowFeaturesProviderParams{
+		Workflow:   new(Workflow),
+		OutputMode: OutputModeJSON,
+	})
+	assert.EqualError(err, "an event stream is required for the json output mode")
+
+	featuresProvider, err := NewWorkflowFeaturesProvider(&NewWorkflowFeaturesProviderParams{
+		Workflow:   new(Workflow),
+		OutputMode: OutputModeJSON,
+		Events:     features.NewEventStream(io.Discard),
+	})
+	if assert.NoError(err) {
+		_, err = featuresProvider.Features(new(runner.MockPlan).WithID("test1"))
+		assert.NoError(err)
 	}
 }
@@ -0,0 +1,289 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+)
+
+// GraphFormat is the format used to write an [ActionGraph]
+type GraphFormat string
+
+const (
+	// GraphFormatDOT writes the graph in the Graphviz DOT language
+	GraphFormatDOT GraphFormat = "dot"
+	// GraphFormatMermaid writes the graph as a Mermaid flowchart
+	GraphFormatMermaid GraphFormat = "mermaid"
+	// GraphFormatJSON writes the graph as a JSON adjacency list
+	GraphFormatJSON GraphFormat = "json"
+)
+
+// ActionGraph describes the actions of a workflow and the dependencies between them
+type ActionGraph struct {
+	Workflow string             `json:"workflow"` // name of the workflow
+	Actions  []*ActionGraphNode `json:"actions"`  // actions of the workflow, in the order they are defined
+}
+
+// ActionGraphNode is an action in an [ActionGraph]
+type ActionGraphNode struct {
+	ID         string             `json:"id"`              // ID of the action, e.g. Group@Action
+	Name       string             `json:"name"`            // name of the action within its group
+	Group      string             `json:"group,omitempty"` // name of the action group, if any
+	Identifier string             `json:"identifier"`      // identifier of the action, e.g. aws/build@v1
+	DependsOn  []*ActionGraphEdge `json:"dependsOn"`       // the actions that must finish before this action is run
+}
+
+// ActionGraphEdge is a dependency on an upstream action in an [ActionGraph]
+type ActionGraphEdge struct {
+	Upstream string         `json:"upstream"` // ID of the upstream action
+	Type     DependencyType `json:"type"`     // reason for the dependency
+	Name     string         `json:"name"`     // the DependsOn entry, artifact name or variable reference that caused the dependency
+}
+
+// Label returns a description of why the dependency exists
+func (e *ActionGraphEdge) Label() string {
+	switch e.Type {
+	case DependencyTypeArtifact:
+		return e.Name
+	case DependencyTypeVariable:
+		return fmt.Sprintf("${%s}", e.Name)
+	default:
+		return string(e.Type)
+	}
+}
+
+// NewActionGraphParams contains the parameters to create a new [ActionGraph]
+type NewActionGraphParams struct {
+	NewWorkflowPlansProviderParams
+	WorkflowPath string // path of the workflow file
+	WorkflowName string // name of the workflow to use if no path is provided
+}
+
+// NewActionGraph creates an [ActionGraph] from the plans of a workflow, labelling each edge with the reason for the dependency
+func NewActionGraph(ctx context.Context, params *NewActionGraphParams) (*ActionGraph, error) {
+	workingDir, workflowPath, err := locateWorkflow(params.WorkingDir, params.WorkflowPath, params.WorkflowName)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := readWorkflow(workflowPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read workflow file '%s': %w", workflowPath, err)
+	}
+	workflowActions, actionIDs, err := workflow.ActionsByID()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read actions of workflow file '%s': %w", workflowPath, err)
+	}
+
+	plansProviderParams := params.NewWorkflowPlansProviderParams
+	plansProviderParams.WorkingDir = workingDir
+	plansProviderParams.Workflow = workflow
+	plans, err := NewWorkflowPlansProvider(&plansProviderParams).Plans(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := ResolveDependencies(workflowActions, actionIDs)
+	graph := &ActionGraph{
+		Workflow: workflow.Name,
+		Actions:  make([]*ActionGraphNode, 0, len(plans)),
+	}
+	planIDs := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		planIDs = append(planIDs, plan.ID())
+	}
+	for _, plan := range plans {
+		node := &ActionGraphNode{
+			ID:         plan.ID(),
+			Name:       plan.ID(),
+			Identifier: workflowActions[plan.ID()].Identifier,
+			DependsOn:  planEdges(plan, planIDs, dependencies),
+		}
+		if group, name, ok := strings.Cut(plan.ID(), "@"); ok {
+			node.Group = group
+			node.Name = name
+		}
+		graph.Actions = append(graph.Actions, node)
+	}
+	return graph, nil
+}
+
+// planEdges returns the edges from the upstream plans of the provided plan
+func planEdges(plan runner.Plan, planIDs []string, dependencies []*Dependency) []*ActionGraphEdge {
+	edges := make([]*ActionGraphEdge, 0)
+	for _, dependency := range dependencies {
+		if dependency.Action == plan.ID() && slices.Contains(planIDs, dependency.Upstream) {
+			edges = append(edges, &ActionGraphEdge{
+				Upstream: dependency.Upstream,
+				Type:     dependency.Type,
+				Name:     dependency.Name,
+			})
+		}
+	}
+	return edges
+}
+
+// Write the graph in the provided format
+func (g *ActionGraph) Write(w io.Writer, format GraphFormat) error {
+	switch format {
+	case GraphFormatDOT:
+		return g.writeDOT(w)
+	case GraphFormatMermaid:
+		return g.writeMermaid(w)
+	case GraphFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(g)
+	default:
+		return fmt.Errorf("unsupported graph format '%s'", format)
+	}
+}
+
+// groups returns the names of the action groups in the order they are defined, with the actions of each group
+func (g *ActionGraph) groups() ([]string, map[string][]*ActionGraphNode) {
+	names := make([]string, 0)
+	members := make(map[string][]*ActionGraphNode)
+	for _, node := range g.Actions {
+		if _, ok := members[node.Group]; !ok {
+			names = append(names, node.Group)
+		}
+		members[node.Group] = append(members[node.Group], node)
+	}
+	return names, members
+}
+
+// edgeLabels returns the labels of the edges from each upstream action, so that multiple reasons share a single edge
+func edgeLabels(node *ActionGraphNode) ([]string, map[string][]string) {
+	upstreams := make([]string, 0)
+	labels := make(map[string][]string)
+	for _, edge := range node.DependsOn {
+		if _, ok := labels[edge.Upstream]; !ok {
+			upstreams = append(upstreams, edge.Upstream)
+		}
+		labels[edge.Upstream] = append(labels[edge.Upstream], edge.Label())
+	}
+	return upstreams, labels
+}
+
+func (g *ActionGraph) writeDOT(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(g.Workflow))
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+	groups, members := g.groups()
+	for _, group := range groups {
+		indent := "  "
+		if group != "" {
+			fmt.Fprintf(&sb, "  subgraph %s {\n", dotQuote("cluster_"+group))
+			fmt.Fprintf(&sb, "    label=%s;\n", dotQuote(group))
+			indent = "    "
+		}
+		for _, node := range members[group] {
+			fmt.Fprintf(&sb, "%s%s [label=%s];\n", indent, dotQuote(node.ID), dotQuote(fmt.Sprintf("%s\n%s", node.Name, node.Identifier)))
+		}
+		if group != "" {
+			sb.WriteString("  }\n")
+		}
+	}
+	for _, node := range g.Actions {
+		upstreams, labels := edgeLabels(node)
+		for _, upstream := range upstreams {
+			fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", dotQuote(upstream), dotQuote(node.ID), dotQuote(strings.Join(labels[upstream], "\n")))
+		}
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return fmt.Sprintf(`"%s"`, s)
+}
+
+var mermaidIDPattern = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func (g *ActionGraph) writeMermaid(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	ids := newMermaidIDs()
+	groups, members := g.groups()
+	for _, group := range groups {
+		indent := "  "
+		if group != "" {
+			fmt.Fprintf(&sb, "  subgraph %s [%s]\n", ids.group(group), mermaidQuote(group))
+			indent = "    "
+		}
+		for _, node := range members[group] {
+			fmt.Fprintf(&sb, "%s%s[%s]\n", indent, ids.action(node.ID), mermaidQuote(fmt.Sprintf("%s<br/>%s", node.Name, node.Identifier)))
+		}
+		if group != "" {
+			sb.WriteString("  end\n")
+		}
+	}
+	for _, node := range g.Actions {
+		upstreams, labels := edgeLabels(node)
+		for _, upstream := range upstreams {
+			fmt.Fprintf(&sb, "  %s -->|%s| %s\n", ids.action(upstream), mermaidQuote(strings.Join(labels[upstream], "<br/>")), ids.action(node.ID))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// mermaidIDs assigns the node IDs of a Mermaid flowchart. The characters that aren't allowed in an ID are replaced
+// with '_', and a suffix is added when that makes the IDs of distinct actions or groups the same, e.g. A-B and A_B, or when
+// the ID is a keyword of Mermaid, e.g. end.
+type mermaidIDs struct {
+	ids  map[string]string // node ID of each action ID, and of each group name prefixed with '@'
+	used map[string]bool
+}
+
+func newMermaidIDs() *mermaidIDs {
+	m := &mermaidIDs{
+		ids:  make(map[string]string),
+		used: make(map[string]bool),
+	}
+	for _, keyword := range mermaidKeywords {
+		m.used[keyword] = true
+	}
+	return m
+}
+
+// mermaidKeywords can't be used as node IDs in a flowchart
+var mermaidKeywords = []string{"end", "graph", "flowchart", "subgraph", "direction", "style", "linkStyle", "class", "classDef", "click", "default"}
+
+// action returns the node ID of an action
+func (m *mermaidIDs) action(actionID string) string {
+	return m.id(actionID, actionID)
+}
+
+// group returns the node ID of the subgraph of an action group
+func (m *mermaidIDs) group(group string) string {
+	return m.id("@"+group, "group_"+group)
+}
+
+func (m *mermaidIDs) id(key string, name string) string {
+	if id, ok := m.ids[key]; ok {
+		return id
+	}
+	base := mermaidIDPattern.ReplaceAllString(name, "_")
+	id := base
+	for i := 2; m.used[id]; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	m.ids[key] = id
+	m.used[id] = true
+	return id
+}
+
+func mermaidQuote(s string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(s, `"`, "#quot;"))
+}
@@ -0,0 +1,154 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionGraph(t *testing.T) {
+	for _, tt := range []struct {
+		TestCase       string
+		WorkflowPath   string
+		Action         string
+		Format         GraphFormat
+		ExpectedOutput string
+	}{
+		{
+			TestCase:     "sample-dot",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml",
+			Format:       GraphFormatDOT,
+			ExpectedOutput: `digraph "sample" {
+  rankdir=LR;
+  node [shape=box];
+  "FirstAction" [label="FirstAction\naws/build@v1"];
+  "FinalAction" [label="FinalAction\naws/build@v1"];
+  subgraph "cluster_Group1" {
+    label="Group1";
+    "Group1@SubAction1" [label="SubAction1\naws/build@v1"];
+    "Group1@SubAction2" [label="SubAction2\naws/build@v1"];
+  }
+  "FirstAction" -> "Group1@SubAction1" [label="${FirstAction.VAR1}\n${FirstAction.VAR2}"];
+  "FirstAction" -> "Group1@SubAction2" [label="ARTIFACT1"];
+  "Group1@SubAction1" -> "FinalAction" [label="ARTIFACT2\n${Group1.SubAction1.VAR3}"];
+}
+`,
+		},
+		{
+			TestCase:     "sample-mermaid",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml",
+			Format:       GraphFormatMermaid,
+			ExpectedOutput: `flowchart LR
+  FirstAction["FirstAction<br/>aws/build@v1"]
+  FinalAction["FinalAction<br/>aws/build@v1"]
+  subgraph group_Group1 ["Group1"]
+    Group1_SubAction1["SubAction1<br/>aws/build@v1"]
+    Group1_SubAction2["SubAction2<br/>aws/build@v1"]
+  end
+  FirstAction -->|"${FirstAction.VAR1}<br/>${FirstAction.VAR2}"| Group1_SubAction1
+  FirstAction -->|"ARTIFACT1"| Group1_SubAction2
+  Group1_SubAction1 -->|"ARTIFACT2<br/>${Group1.SubAction1.VAR3}"| FinalAction
+`,
+		},
+		{
+			TestCase:     "shared-dot",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/shared.yaml",
+			Format:       GraphFormatDOT,
+			ExpectedOutput: `digraph "shared" {
+  rankdir=LR;
+  node [shape=box];
+  "Custom" [label="Custom\n."];
+  "FirstAction" [label="FirstAction\naws/build@v1"];
+  subgraph "cluster_Group1" {
+    label="Group1";
+    "Group1@SubAction2" [label="SubAction2\naws/build@v1"];
+  }
+  "Custom" -> "FirstAction" [label="DependsOn"];
+  "FirstAction" -> "Group1@SubAction2" [label="DependsOn"];
+}
+`,
+		},
+		{
+			TestCase:     "single-action",
+			WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml",
+			Action:       "FinalAction",
+			Format:       GraphFormatMermaid,
+			ExpectedOutput: `flowchart LR
+  FinalAction["FinalAction<br/>aws/build@v1"]
+`,
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			graph, err := NewActionGraph(context.Background(), &NewActionGraphParams{
+				NewWorkflowPlansProviderParams: NewWorkflowPlansProviderParams{
+					ExecutionType: runner.ExecutionTypeShell,
+					Action:        tt.Action,
+				},
+				WorkflowPath: tt.WorkflowPath,
+			})
+			assert.NoError(err)
+			var out bytes.Buffer
+			assert.NoError(graph.Write(&out, tt.Format))
+			assert.Equal(tt.ExpectedOutput, out.String())
+		})
+	}
+}
+
+func TestActionGraphJSON(t *testing.T) {
+	assert := assert.New(t)
+	graph, err := NewActionGraph(context.Background(), &NewActionGraphParams{
+		NewWorkflowPlansProviderParams: NewWorkflowPlansProviderParams{
+			ExecutionType: runner.ExecutionTypeShell,
+		},
+		WorkflowPath: "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml",
+	})
+	assert.NoError(err)
+	var out bytes.Buffer
+	assert.NoError(graph.Write(&out, GraphFormatJSON))
+
+	decoded := new(ActionGraph)
+	assert.NoError(json.Unmarshal(out.Bytes(), decoded))
+	assert.Equal(graph, decoded)
+	assert.Equal("Group1", decoded.Actions[2].Group)
+	assert.Equal([]*ActionGraphEdge{
+		{Upstream: "FirstAction", Type: DependencyTypeArtifact, Name: "ARTIFACT1"},
+	}, decoded.Actions[2].DependsOn)
+
+	assert.EqualError(graph.Write(&out, "svg"), "unsupported graph format 'svg'")
+}
+
+func TestActionGraphMermaidIDs(t *testing.T) {
+	assert := assert.New(t)
+	graph := &ActionGraph{
+		Workflow: "collisions",
+		Actions: []*ActionGraphNode{
+			{ID: "A-B", Name: "A-B", Identifier: "aws/build@v1"},
+			{ID: "A_B", Name: "A_B", Identifier: "aws/build@v1", DependsOn: []*ActionGraphEdge{
+				{Upstream: "A-B", Type: DependencyTypeDependsOn, Name: "A-B"},
+			}},
+			{ID: "A@B", Name: "B", Group: "A", Identifier: "aws/build@v1", DependsOn: []*ActionGraphEdge{
+				{Upstream: "A_B", Type: DependencyTypeDependsOn, Name: "A_B"},
+			}},
+			{ID: "end", Name: "end", Identifier: "aws/build@v1"},
+			{ID: "subgraph", Name: "subgraph", Identifier: "aws/build@v1"},
+		},
+	}
+	var out bytes.Buffer
+	assert.NoError(graph.Write(&out, GraphFormatMermaid))
+	assert.Equal(`flowchart LR
+  A_B["A-B<br/>aws/build@v1"]
+  A_B_2["A_B<br/>aws/build@v1"]
+  end_2["end<br/>aws/build@v1"]
+  subgraph_2["subgraph<br/>aws/build@v1"]
+  subgraph group_A ["A"]
+    A_B_3["B<br/>aws/build@v1"]
+  end
+  A_B -->|"DependsOn"| A_B_2
+  A_B_2 -->|"DependsOn"| A_B_3
+`, out.String())
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/actions"
@@ -17,7 +18,6 @@ import (
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
 	"github.com/rs/zerolog/log"
-	"gopkg.in/yaml.v2"
 )
 
 var ActionsUrlTemplate = "https://amazon-codecatalyst-public-action-source-us-west-2.s3.us-west-2.amazonaws.com/us-west-2/%s/%s/action-repo.zip"
@@ -48,8 +48,9 @@ var ActionVersions = map[string]string{
 type NewWorkflowPlansProviderParams struct {
 	ExecutionType runner.ExecutionType // The [ExecutionType] to use in the created plans
 	WorkingDir    string               // The working directory to use for each plan
-	Action        string               // the name of the action to run
+	Action        string               // expression selecting the actions to run, see [SelectActions]
 	Workflow      *Workflow            // The [Workflow] to use
+	Satisfied     []string             // IDs of actions whose outputs are provided up front, which are not planned
 }
 
 // NewWorkflowPlansProvider creates a plan provider based on [Workflow]s
@@ -59,6 +60,7 @@ func NewWorkflowPlansProvider(params *NewWorkflowPlansProviderParams) runner.Pla
 		workingDir:    params.WorkingDir,
 		action:        params.Action,
 		workflow:      params.Workflow,
+		satisfied:     params.Satisfied,
 	}
 }
 
@@ -67,39 +69,31 @@ type workflowPlansProvider struct {
 	workingDir    string
 	action        string
 	workflow      *Workflow
+	satisfied     []string
 }
 
 func (wpp *workflowPlansProvider) Plans(ctx context.Context) ([]runner.Plan, error) {
+	workflowActions, actionIDs, err := wpp.workflow.ActionsByID()
+	if err != nil {
+		return nil, err
+	}
+	selectedIDs, err := SelectActions(wpp.action, workflowActions, actionIDs)
+	if err != nil {
+		return nil, err
+	}
 	plans := make([]runner.Plan, 0)
-	for _, mapItem := range wpp.workflow.Actions {
-		actionName := mapItem.Key.(string)
-		var actionOrGroup ActionOrGroup
-		if buf, err := yaml.Marshal(mapItem.Value); err != nil {
-			return nil, err
-		} else if err := yaml.Unmarshal(buf, &actionOrGroup); err != nil {
-			return nil, err
+	for _, actionID := range selectedIDs {
+		if slices.Contains(wpp.satisfied, actionID) {
+			log.Ctx(ctx).Debug().Msgf("skipping plan for action %s, its outputs have been provided", actionID)
+			continue
 		}
-		if actionOrGroup.Action.Identifier != "" {
-			plan, err := wpp.planAction(ctx, actionName, &actionOrGroup.Action)
-			if err != nil {
-				return nil, fmt.Errorf("unable to create plan for action %s: %w", actionName, err)
-			}
-			if plan != nil {
-				plans = append(plans, plan)
-			}
-		} else {
-			for subName, action := range actionOrGroup.Actions {
-				fullName := fmt.Sprintf("%s@%s", actionName, subName)
-				plan, err := wpp.planAction(ctx, fullName, action)
-				if err != nil {
-					return nil, fmt.Errorf("unable to create plan for action %s: %w", fullName, err)
-				}
-				if plan != nil {
-					plans = append(plans, plan)
-				}
-			}
+		plan, err := wpp.planAction(ctx, actionID, workflowActions[actionID])
+		if err != nil {
+			return nil, fmt.Errorf("unable to create plan for action %s: %w", actionID, err)
 		}
+		plans = append(plans, plan)
 	}
+	addImplicitDependencies(workflowActions, actionIDs, plans)
 	if log.Debug().Enabled() {
 		log.Debug().Msgf("created plans from workflow=%+v", wpp.workflow)
 		for _, plan := range plans {
@@ -110,9 +104,6 @@ func (wpp *workflowPlansProvider) Plans(ctx context.Context) ([]runner.Plan, err
 }
 
 func (wpp *workflowPlansProvider) planAction(ctx context.Context, actionName string, action *Action) (runner.Plan, error) {
-	if wpp.action != "" && wpp.action != actionName {
-		return nil, nil
-	}
 	log.Ctx(ctx).Debug().Msgf("creating action plan for action %s", action.Identifier)
 	var plan runner.Plan
 	var err error
@@ -209,7 +200,12 @@ func downloadHttpExtractZip(_ context.Context, url string, destDir string) error
 	}
 	_ = actionZip.Close()
 
-	archive, err := zip.OpenReader(actionZip.Name())
+	return extractZip(actionZip.Name(), destDir)
+}
+
+// extractZip extracts the contents of the zip file into destDir
+func extractZip(zipPath string, destDir string) error {
+	archive, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return fmt.Errorf("unable to open zip: %w", err)
 	}
@@ -0,0 +1,79 @@
+package workflows
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// RetryConfig is a local file that configures how the actions of a workflow are retried when they fail, e.g.
+//
+//	Retries:
+//	  Test@Integration:
+//	    MaxAttempts: 3
+//	    Backoff: 10s
+//	    ExitCodes: [1, 137]
+type RetryConfig struct {
+	Retries map[string]*RetryConfigPolicy `yaml:"Retries"` // retry policies keyed by action selector
+}
+
+// RetryConfigPolicy is the retry policy of the actions matched by a selector in a [RetryConfig]
+type RetryConfigPolicy struct {
+	MaxAttempts int           `yaml:"MaxAttempts"` // maximum number of attempts, including the first one
+	Backoff     time.Duration `yaml:"Backoff"`     // delay before the second attempt, doubled before each further attempt
+	ExitCodes   []int         `yaml:"ExitCodes"`   // exit codes to retry, any failure is retried if empty
+}
+
+// ReadRetryPolicies reads the retry policies from a [RetryConfig] file, keyed by action selector
+func ReadRetryPolicies(configPath string) (map[string]*features.RetryPolicy, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read retry config '%s': %w", configPath, err)
+	}
+	config := new(RetryConfig)
+	if err := yamlv3.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("unable to parse retry config '%s': %w", configPath, err)
+	}
+	policies := make(map[string]*features.RetryPolicy, len(config.Retries))
+	for selector, policy := range config.Retries {
+		if policy == nil || policy.MaxAttempts < 1 {
+			return nil, fmt.Errorf("invalid retry config '%s': MaxAttempts of '%s' must be at least 1", configPath, selector)
+		}
+		policies[selector] = &features.RetryPolicy{
+			MaxAttempts: policy.MaxAttempts,
+			Backoff:     policy.Backoff,
+			ExitCodes:   policy.ExitCodes,
+		}
+	}
+	return policies, nil
+}
+
+// actionRetryPolicies resolves retry policies keyed by action selector to the policy of each action. If several selectors match
+// an action, the last selector in sorted order wins. The attempts of each action are logged to a directory in logDir.
+func actionRetryPolicies(policies map[string]*features.RetryPolicy, workflowActions map[string]*Action, actionIDs []string, logDir string) (map[string]*features.RetryPolicy, error) {
+	selectors := make([]string, 0, len(policies))
+	for selector := range policies {
+		selectors = append(selectors, selector)
+	}
+	sort.Strings(selectors)
+
+	actionPolicies := make(map[string]*features.RetryPolicy)
+	for _, selector := range selectors {
+		selected, err := SelectActions(selector, workflowActions, actionIDs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry selection: %w", err)
+		}
+		for _, actionID := range selected {
+			policy := *policies[selector]
+			policy.LogDir = filepath.Join(logDir, actionID)
+			actionPolicies[actionID] = &policy
+		}
+	}
+	return actionPolicies, nil
+}
@@ -0,0 +1,75 @@
+package workflows
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRetryPolicies(t *testing.T) {
+	for _, tt := range []struct {
+		TestCase         string
+		ConfigPath       string
+		ExpectedPolicies map[string]*features.RetryPolicy
+		ExpectedError    string
+	}{
+		{
+			TestCase:   "valid",
+			ConfigPath: "testdata/retry/retry.yaml",
+			ExpectedPolicies: map[string]*features.RetryPolicy{
+				"Test@*":           {MaxAttempts: 3, Backoff: 10 * time.Second, ExitCodes: []int{1, 137}},
+				"Test@Integration": {MaxAttempts: 5},
+			},
+		},
+		{
+			TestCase:      "invalid",
+			ConfigPath:    "testdata/retry/invalid.yaml",
+			ExpectedError: "invalid retry config 'testdata/retry/invalid.yaml': MaxAttempts of 'Build' must be at least 1",
+		},
+		{
+			TestCase:      "missing",
+			ConfigPath:    "testdata/retry/missing.yaml",
+			ExpectedError: "unable to read retry config 'testdata/retry/missing.yaml': open testdata/retry/missing.yaml: no such file or directory",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			policies, err := ReadRetryPolicies(tt.ConfigPath)
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tt.ExpectedPolicies, policies)
+			}
+		})
+	}
+}
+
+func TestActionRetryPolicies(t *testing.T) {
+	assert := assert.New(t)
+
+	workflowActions := map[string]*Action{
+		"Build":            {},
+		"Test@Unit":        {},
+		"Test@Integration": {},
+	}
+	actionIDs := []string{"Build", "Test@Unit", "Test@Integration"}
+	policies := map[string]*features.RetryPolicy{
+		"Test@*":           {MaxAttempts: 3},
+		"Test@Integration": {MaxAttempts: 5},
+	}
+
+	actionPolicies, err := actionRetryPolicies(policies, workflowActions, actionIDs, "logs")
+	assert.NoError(err)
+	assert.Equal(map[string]*features.RetryPolicy{
+		"Test@Unit":        {MaxAttempts: 3, LogDir: filepath.Join("logs", "Test@Unit")},
+		"Test@Integration": {MaxAttempts: 5, LogDir: filepath.Join("logs", "Test@Integration")},
+	}, actionPolicies)
+
+	_, err = actionRetryPolicies(map[string]*features.RetryPolicy{"Deploy": {MaxAttempts: 2}}, workflowActions, actionIDs, "logs")
+	assert.EqualError(err, "invalid retry selection: action selector 'Deploy' doesn't match any action in the workflow")
+}
@@ -6,7 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"time"
 
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/actions"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
 	"github.com/manifoldco/promptui"
@@ -20,20 +25,231 @@ type RunParams struct {
 	Concurrency  int
 	WorkflowPath string
 	WorkflowName string
+	FailFast     bool   // Cancel the running actions and skip the rest once any action fails
+	SARIFReport  string // Path of the SARIF file to write the vulnerabilities found to, defaults to the reports directory of the run
+	SBOMReport   string // Path of the CycloneDX file to write the merged SBOM to, defaults to the reports directory of the run
+	JUnitReport  string // Path of the JUnit XML file to write the result of each action to, if any
+	Resume       string // ID of a previous run to resume, running only the actions that didn't succeed
+	RerunFailed  bool   // Resume the most recent run of the workflow
 }
 
 func Run(ctx context.Context, params *RunParams) error {
 	log.Ctx(ctx).Debug().Msgf("running workflow with params %+v", *params)
-	if params.WorkflowPath != "" {
-		if _, err := os.Stat(params.WorkflowPath); err != nil {
-			return fmt.Errorf("unable to load workflow file '%s': %w", params.WorkflowPath, err)
+	var previous *RunRecord
+	if params.Resume != "" {
+		var err error
+		if previous, err = LoadRun(params.Resume); err != nil {
+			return err
 		}
+		if params.WorkflowPath == "" && params.WorkflowName == "" {
+			params.WorkflowPath = previous.WorkflowPath
+		}
+	}
+	workingDir, workflowPath, err := locateWorkflow(params.WorkingDir, params.WorkflowPath, params.WorkflowName)
+	if err != nil {
+		return err
+	}
+	params.WorkingDir = workingDir
+	params.WorkflowPath = workflowPath
 
-		params.WorkingDir, _ = filepath.Abs(filepath.Dir(filepath.Dir(filepath.Dir(params.WorkflowPath))))
-	} else {
-		params.WorkingDir, _ = filepath.Abs(params.WorkingDir)
-		if workflows, err := os.ReadDir(filepath.Join(params.WorkingDir, ".codecatalyst", "workflows")); err != nil {
+	log.Debug().Msgf("🚚 Running workflow file '%s'", params.WorkflowPath)
+
+	workflow, err := readWorkflow(params.WorkflowPath)
+	if err != nil {
+		return fmt.Errorf("unable to read workflow file '%s': %w", params.WorkflowPath, err)
+	}
+
+	workflowActions, actionIDs, err := workflow.ActionsByID()
+	if err != nil {
+		return fmt.Errorf("unable to read actions of workflow file '%s': %w", params.WorkflowPath, err)
+	}
+	if err := CheckDependencies(workflowActions, actionIDs); err != nil {
+		return fmt.Errorf("invalid dependencies in workflow file '%s':\n%w", params.WorkflowPath, err)
+	}
+
+	if params.RerunFailed && previous == nil {
+		if previous, err = latestRun(params.WorkflowPath); err != nil {
 			return err
+		}
+	}
+	resumed := make([]string, 0)
+	if previous != nil {
+		if resumed, err = resumeRun(ctx, params, previous, workflowActions); err != nil {
+			return err
+		}
+	}
+
+	satisfied, err := seededProducers(workflowActions, params.Variables, params.Artifacts)
+	if err != nil {
+		return err
+	}
+	for _, actionID := range resumed {
+		if !slices.Contains(satisfied, actionID) {
+			satisfied = append(satisfied, actionID)
+		}
+	}
+	selectedIDs, err := SelectActions(params.Action, workflowActions, actionIDs)
+	if err != nil {
+		return err
+	}
+	if err := checkInputArtifacts(workflowActions, selectedIDs, satisfied); err != nil {
+		return err
+	}
+
+	params.NewWorkflowPlansProviderParams.Workflow = workflow
+	params.NewWorkflowPlansProviderParams.Satisfied = satisfied
+	plans := NewWorkflowPlansProvider(&params.NewWorkflowPlansProviderParams)
+
+	params.NewWorkflowFeaturesProviderParams.Workflow = workflow
+	params.NewWorkflowFeaturesProviderParams.EnvironmentConfiguration.WorkingDir = params.WorkingDir
+	record, err := newRunRecord(ctx, workflow, params.WorkingDir, params.Action)
+	if err == nil && previous != nil {
+		err = record.resume(previous, resumed)
+	}
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("Unable to record the history of the run")
+		record = nil
+	}
+	params.NewWorkflowFeaturesProviderParams.Run = record
+	vulnerabilities := new(VulnerabilitySummary)
+	params.NewWorkflowFeaturesProviderParams.Vulnerabilities = vulnerabilities
+	sboms := new(SBOMSummary)
+	params.NewWorkflowFeaturesProviderParams.SBOMs = sboms
+	annotations := new(actions.Annotations)
+	params.NewWorkflowFeaturesProviderParams.Annotations = annotations
+	summaries := new(actions.RunSummaries)
+	params.NewWorkflowFeaturesProviderParams.Summaries = summaries
+	planTracker := new(features.PlanTracker)
+	params.NewWorkflowFeaturesProviderParams.PlanTracker = planTracker
+	events := params.NewWorkflowFeaturesProviderParams.Events
+	var junitReport *features.JUnitReport
+	if params.JUnitReport != "" {
+		junitReport = features.NewJUnitReport(workflow.Name)
+		params.NewWorkflowFeaturesProviderParams.JUnitReport = junitReport
+	}
+	featuresProvider, err := NewWorkflowFeaturesProvider(&params.NewWorkflowFeaturesProviderParams)
+	if err != nil {
+		return fmt.Errorf("unable to create features provider: %w", err)
+	}
+	start := time.Now()
+	if events != nil {
+		event := &runner.Event{Type: runner.EventRunStarted, Workflow: workflow.Name}
+		if record != nil {
+			event.RunID = record.ID
+		}
+		events.Emit(event)
+	}
+	err = runner.RunAll(ctx, &runner.RunAllParams{
+		Namespace:     workflow.Name,
+		Plans:         plans,
+		Features:      featuresProvider,
+		Concurrency:   params.Concurrency,
+		ExecutionType: params.ExecutionType,
+		FailFast:      params.FailFast,
+	})
+	if err == nil {
+		// the actions that continued on failure still fail the run once all actions ran
+		err = planTracker.Err()
+	}
+	if events != nil {
+		durationMs := time.Since(start).Milliseconds()
+		event := &runner.Event{
+			Type:       runner.EventRunFinished,
+			Workflow:   workflow.Name,
+			Status:     features.EventStatusSucceeded,
+			DurationMs: &durationMs,
+		}
+		if err != nil {
+			event.Status = features.EventStatusFailed
+			event.Error = err.Error()
+		}
+		events.Emit(event)
+	}
+	summaries.Log(ctx)
+	annotations.Log(ctx, params.WorkingDir)
+	if sarifErr := writeVulnerabilities(ctx, vulnerabilities, params.SARIFReport, runReportPath(workflow, record, "vulnerabilities.sarif")); sarifErr != nil {
+		log.Ctx(ctx).Warn().Err(sarifErr).Msg("Failed to write vulnerabilities")
+	}
+	if sbomErr := writeSBOM(ctx, workflow, sboms, params.SBOMReport, runReportPath(workflow, record, "sbom.cdx.json")); sbomErr != nil {
+		log.Ctx(ctx).Warn().Err(sbomErr).Msg("Failed to write SBOM")
+	}
+	if junitReport != nil {
+		if junitErr := junitReport.WriteFile(params.JUnitReport); junitErr != nil {
+			log.Ctx(ctx).Warn().Err(junitErr).Msg("Failed to write JUnit report")
+		} else {
+			log.Ctx(ctx).Info().Msgf("🧪 JUnit report written to %s", params.JUnitReport)
+		}
+	}
+	if record != nil {
+		if recordErr := record.finish(err); recordErr != nil {
+			log.Ctx(ctx).Warn().Err(recordErr).Msg("Failed to record the history of the run")
+		} else {
+			log.Ctx(ctx).Info().Msgf("📼 Run %s recorded, see 'ccr runs show %s'", record.ID, record.ID)
+		}
+	}
+	return err
+}
+
+// writeVulnerabilities logs the vulnerabilities found during the run and writes them to a SARIF file. The file is
+// only written to defaultPath if vulnerabilities were found.
+func writeVulnerabilities(ctx context.Context, vulnerabilities *VulnerabilitySummary, sarifPath string, defaultPath string) error {
+	vulnerabilities.Log(ctx)
+	if sarifPath == "" {
+		if len(vulnerabilities.Vulnerabilities()) == 0 {
+			return nil
+		}
+		sarifPath = defaultPath
+	}
+	if err := vulnerabilities.WriteSARIF(sarifPath); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info().Msgf("🛡️ Vulnerabilities written to %s", sarifPath)
+	return nil
+}
+
+// writeSBOM logs the number of components of the SBOMs of each action and writes them to a merged CycloneDX SBOM.
+// The file is only written to defaultPath if SBOMs were found.
+func writeSBOM(ctx context.Context, workflow *Workflow, sboms *SBOMSummary, sbomPath string, defaultPath string) error {
+	sboms.Log(ctx)
+	if sbomPath == "" {
+		if len(sboms.ComponentCounts()) == 0 {
+			return nil
+		}
+		sbomPath = defaultPath
+	}
+	if err := sboms.WriteCycloneDX(sbomPath, workflow.Name); err != nil {
+		return err
+	}
+	log.Ctx(ctx).Info().Msgf("📦 SBOM written to %s", sbomPath)
+	return nil
+}
+
+// runReportPath returns the absolute path of a report of the run in the directory of the run, or in the reports
+// directory of the workflow cache if the run isn't recorded, falling back to the temp dir without a user cache dir
+func runReportPath(workflow *Workflow, record *RunRecord, name string) string {
+	if record != nil {
+		return record.ReportPath(name)
+	}
+	cacheDir, err := workflowCacheDir(workflow)
+	if err != nil {
+		cacheDir = filepath.Join(os.TempDir(), "codecatalyst-runner")
+	}
+	return filepath.Join(cacheDir, "reports", name)
+}
+
+// locateWorkflow returns the working directory and absolute path of the workflow to use. If no workflow path is provided,
+// the workflow is selected by name from the .codecatalyst/workflows directory of the working directory, or by prompting the user.
+func locateWorkflow(workingDir string, workflowPath string, workflowName string) (string, string, error) {
+	if workflowPath != "" {
+		if _, err := os.Stat(workflowPath); err != nil {
+			return "", "", fmt.Errorf("unable to load workflow file '%s': %w", workflowPath, err)
+		}
+
+		workingDir, _ = filepath.Abs(filepath.Dir(filepath.Dir(filepath.Dir(workflowPath))))
+	} else {
+		workingDir, _ = filepath.Abs(workingDir)
+		if workflows, err := os.ReadDir(filepath.Join(workingDir, ".codecatalyst", "workflows")); err != nil {
+			return "", "", err
 		} else {
 			workflowOptions := make(map[string]string, 0)
 			for _, workflow := range workflows {
@@ -41,19 +257,19 @@ func Run(ctx context.Context, params *RunParams) error {
 				if ext != ".yml" && ext != ".yaml" {
 					continue
 				}
-				workflowFile := filepath.Join(params.WorkingDir, ".codecatalyst", "workflows", workflow.Name())
+				workflowFile := filepath.Join(workingDir, ".codecatalyst", "workflows", workflow.Name())
 				log.Debug().Msgf("considering workflow file %s", workflowFile)
 				if workflow, err := readWorkflow(workflowFile); err != nil {
-					return fmt.Errorf("unable to read workflow file '%s': %w", workflowFile, err)
+					return "", "", fmt.Errorf("unable to read workflow file '%s': %w", workflowFile, err)
 				} else {
 					workflowOptions[workflow.Name] = workflowFile
 				}
 			}
-			if params.WorkflowName != "" {
-				if val, ok := workflowOptions[params.WorkflowName]; !ok {
-					return fmt.Errorf("no workflow defined named '%s'", params.WorkflowName)
+			if workflowName != "" {
+				if val, ok := workflowOptions[workflowName]; !ok {
+					return "", "", fmt.Errorf("no workflow defined named '%s'", workflowName)
 				} else {
-					params.WorkflowPath = val
+					workflowPath = val
 				}
 			} else {
 				// prompt to select a workflow
@@ -63,45 +279,23 @@ func Run(ctx context.Context, params *RunParams) error {
 					Stdout: &bellSkipper{},
 				}
 				if _, result, err := prompt.Run(); err != nil {
-					return fmt.Errorf("unable to select a workflow: %w", err)
+					return "", "", fmt.Errorf("unable to select a workflow: %w", err)
 				} else {
-					params.WorkflowPath = workflowOptions[result]
+					workflowPath = workflowOptions[result]
 				}
 			}
 		}
 	}
 
-	if !filepath.IsAbs(params.WorkflowPath) {
-		if absWorkflowPath, err := filepath.Abs(params.WorkflowPath); err != nil {
-			return err
+	if !filepath.IsAbs(workflowPath) {
+		if absWorkflowPath, err := filepath.Abs(workflowPath); err != nil {
+			return "", "", err
 		} else {
-			params.WorkflowPath = absWorkflowPath
+			workflowPath = absWorkflowPath
 		}
 	}
 
-	log.Debug().Msgf("🚚 Running workflow file '%s'", params.WorkflowPath)
-
-	workflow, err := readWorkflow(params.WorkflowPath)
-	if err != nil {
-		return fmt.Errorf("unable to read workflow file '%s': %w", params.WorkflowPath, err)
-	}
-
-	params.NewWorkflowPlansProviderParams.Workflow = workflow
-	plans := NewWorkflowPlansProvider(&params.NewWorkflowPlansProviderParams)
-
-	params.NewWorkflowFeaturesProviderParams.Workflow = workflow
-	params.NewWorkflowFeaturesProviderParams.EnvironmentConfiguration.WorkingDir = params.WorkingDir
-	features, err := NewWorkflowFeaturesProvider(&params.NewWorkflowFeaturesProviderParams)
-	if err != nil {
-		return fmt.Errorf("unable to create features provider: %w", err)
-	}
-	return runner.RunAll(ctx, &runner.RunAllParams{
-		Namespace:     workflow.Name,
-		Plans:         plans,
-		Features:      features,
-		Concurrency:   params.Concurrency,
-		ExecutionType: params.ExecutionType,
-	})
+	return workingDir, workflowPath, nil
 }
 
 func readWorkflow(workflowPath string) (*Workflow, error) {
@@ -2,10 +2,14 @@ package workflows
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/features"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
 	"github.com/rs/zerolog"
@@ -23,7 +27,14 @@ func TestRun(t *testing.T) {
 	type TestRunTargetParams struct {
 		TestCase      string
 		WorkflowPath  string
+		Action        string
+		Variables     map[string]string
+		Artifacts     map[string]string
 		Secrets       map[string]string
+		Timeout       time.Duration
+		FailFast      bool
+		Continue      string
+		Retries       map[string]*features.RetryPolicy
 		ExecutionType runner.ExecutionType
 		ExpectError   error
 	}
@@ -107,6 +118,53 @@ func TestRun(t *testing.T) {
 				"SAMPLE_SECRET": "mysecretvalue",
 			},
 		},
+		{
+			TestCase:      "seeded-shell",
+			WorkflowPath:  "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml",
+			Action:        "FinalAction",
+			ExecutionType: runner.ExecutionTypeShell,
+			Variables: map[string]string{
+				"Group1.SubAction1.VAR3": "foooo",
+			},
+			Artifacts: map[string]string{
+				"ARTIFACT2": "testdata/seed/artifact2",
+			},
+		},
+		{
+			TestCase:      "timeout-shell",
+			WorkflowPath:  "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/timeout.yaml",
+			ExecutionType: runner.ExecutionTypeShell,
+			Timeout:       time.Second,
+			ExpectError:   &common.TimeoutError{Timeout: time.Second},
+		},
+		{
+			TestCase:      "continue-on-failure-shell",
+			WorkflowPath:  "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/failure.yaml",
+			ExecutionType: runner.ExecutionTypeShell,
+			Continue:      "Flaky",
+			ExpectError:   errors.New("Flaky failed: exit status 1"),
+		},
+		{
+			TestCase:      "fail-fast-shell",
+			WorkflowPath:  "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/failure.yaml",
+			ExecutionType: runner.ExecutionTypeShell,
+			FailFast:      true,
+			ExpectError:   errors.New("exit status 1"),
+		},
+		{
+			TestCase:      "retry-shell",
+			WorkflowPath:  "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/retry.yaml",
+			ExecutionType: runner.ExecutionTypeShell,
+			Retries: map[string]*features.RetryPolicy{
+				"Flaky": {MaxAttempts: 2, ExitCodes: []int{1}},
+			},
+		},
+		{
+			TestCase:      "reports-shell",
+			WorkflowPath:  "testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/reports.yaml",
+			ExecutionType: runner.ExecutionTypeShell,
+			ExpectError:   errors.New("report Strict status FAILED"),
+		},
 	} {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
 		log.Logger = log.Logger.With().Caller().Stack().Logger()
@@ -118,19 +176,26 @@ func TestRun(t *testing.T) {
 			mockSecrets := newMockSecrets(tt.Secrets)
 			err := Run(ctx, &RunParams{
 				WorkflowPath: tt.WorkflowPath,
+				FailFast:     tt.FailFast,
 				NewWorkflowFeaturesProviderParams: NewWorkflowFeaturesProviderParams{
-					OutputMode:     OutputModeText,
-					SecretProvider: mockSecrets,
+					OutputMode:        OutputModeText,
+					SecretProvider:    mockSecrets,
+					Variables:         tt.Variables,
+					Artifacts:         tt.Artifacts,
+					ContinueOnFailure: tt.Continue,
+					RetryPolicies:     tt.Retries,
+					ActionTimeout:     tt.Timeout,
 				},
 				NewWorkflowPlansProviderParams: NewWorkflowPlansProviderParams{
 					ExecutionType: tt.ExecutionType,
+					Action:        tt.Action,
 				},
 			})
 			if err != nil && strings.HasPrefix(err.Error(), "service provider is unavailable:") {
 				t.Skip(err.Error())
 			}
 			if tt.ExpectError != nil {
-				assert.Equal(tt.ExpectError, err)
+				assert.EqualError(err, tt.ExpectError.Error())
 			} else {
 				assert.NoError(err)
 			}
@@ -0,0 +1,419 @@
+package workflows
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// schemaType is the type of value expected at a node of the workflow definition
+type schemaType string
+
+const (
+	schemaTypeAny     schemaType = "any"
+	schemaTypeString  schemaType = "string"
+	schemaTypeInteger schemaType = "integer"
+	schemaTypeNumber  schemaType = "number"
+	schemaTypeBoolean schemaType = "boolean"
+	schemaTypeMapping schemaType = "mapping"
+	schemaTypeList    schemaType = "list"
+)
+
+// schema describes the structure of a node in the workflow definition
+type schema struct {
+	Type     schemaType
+	Fields   map[string]*schema         // known keys of a mapping
+	Values   *schema                    // schema of values in a mapping with user defined keys
+	Items    *schema                    // schema of items in a list
+	Required []string                   // keys that must be present in a mapping
+	Enum     []string                   // allowed values of a scalar (case insensitive)
+	Select   func(*yamlv3.Node) *schema // selects the schema to use based on the content of the node
+}
+
+var (
+	anySchema     = &schema{Type: schemaTypeAny}
+	stringSchema  = &schema{Type: schemaTypeString}
+	integerSchema = &schema{Type: schemaTypeInteger}
+	numberSchema  = &schema{Type: schemaTypeNumber}
+	booleanSchema = &schema{Type: schemaTypeBoolean}
+)
+
+func listOf(items *schema) *schema {
+	return &schema{Type: schemaTypeList, Items: items}
+}
+
+func mappingOf(values *schema) *schema {
+	return &schema{Type: schemaTypeMapping, Values: values}
+}
+
+func enumOf(values ...string) *schema {
+	return &schema{Type: schemaTypeString, Enum: values}
+}
+
+var computeSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"Type":           enumOf("EC2", "LAMBDA"),
+		"Fleet":          stringSchema,
+		"SharedInstance": booleanSchema,
+	},
+}
+
+var severitySchema = enumOf("CRITICAL", "HIGH", "MEDIUM", "LOW", "INFORMATIONAL")
+
+var severityCountSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"Severity": severitySchema,
+		"Number":   integerSchema,
+	},
+}
+
+var successCriteriaSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"PassRate":               numberSchema,
+		"LineCoverage":           numberSchema,
+		"BranchCoverage":         numberSchema,
+		"Vulnerabilities":        severityCountSchema,
+		"StaticAnalysisBug":      severityCountSchema,
+		"StaticAnalysisSecurity": severityCountSchema,
+		"StaticAnalysisQuality":  severityCountSchema,
+		"StaticAnalysisFinding":  severityCountSchema,
+	},
+}
+
+var reportFormats = []string{
+	"JUNITXML", "TESTNG", "CUCUMBERJSON", "VISUALSTUDIOTRX", "NUNITXML", "NUNIT3XML",
+	"COBERTURAXML", "JACOCOXML", "CLOVERXML", "LCOV", "SIMPLECOV",
+	"SARIFSCA", "SARIFSA",
+}
+
+var reportSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"Format":          enumOf(reportFormats...),
+		"IncludePaths":    listOf(stringSchema),
+		"ExcludePaths":    listOf(stringSchema),
+		"SuccessCriteria": successCriteriaSchema,
+	},
+	Required: []string{"Format"},
+}
+
+var actionSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"Identifier":    stringSchema,
+		"DependsOn":     listOf(stringSchema),
+		"Compute":       computeSchema,
+		"Timeout":       integerSchema,
+		"Configuration": mappingOf(anySchema),
+		"Environment": {
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"Name": stringSchema,
+				"Connections": listOf(&schema{
+					Type: schemaTypeMapping,
+					Fields: map[string]*schema{
+						"Name": stringSchema,
+						"Role": stringSchema,
+					},
+					Required: []string{"Name", "Role"},
+				}),
+			},
+			Required: []string{"Name"},
+		},
+		"Caching": {
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"FileCaching": mappingOf(&schema{
+					Type: schemaTypeMapping,
+					Fields: map[string]*schema{
+						"Path":        stringSchema,
+						"RestoreKeys": listOf(stringSchema),
+					},
+					Required: []string{"Path"},
+				}),
+			},
+		},
+		"Inputs": {
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"Sources":   listOf(stringSchema),
+				"Artifacts": listOf(stringSchema),
+				"Variables": listOf(&schema{
+					Type: schemaTypeMapping,
+					Fields: map[string]*schema{
+						"Name":  stringSchema,
+						"Value": stringSchema,
+					},
+					Required: []string{"Name", "Value"},
+				}),
+			},
+		},
+		"Outputs": {
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"Sources": listOf(stringSchema),
+				"Artifacts": listOf(&schema{
+					Type: schemaTypeMapping,
+					Fields: map[string]*schema{
+						"Name":  stringSchema,
+						"Files": {Type: schemaTypeAny},
+					},
+					Required: []string{"Name", "Files"},
+				}),
+				"Variables": listOf(stringSchema),
+				"Reports":   mappingOf(reportSchema),
+				"AutoDiscoverReports": {
+					Type: schemaTypeMapping,
+					Fields: map[string]*schema{
+						"Enabled":          booleanSchema,
+						"ReportNamePrefix": stringSchema,
+						"IncludePaths":     listOf(stringSchema),
+						"ExcludePaths":     listOf(stringSchema),
+						"SuccessCriteria":  successCriteriaSchema,
+					},
+				},
+			},
+		},
+	},
+	Required: []string{"Identifier"},
+}
+
+var actionGroupSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"DependsOn": listOf(stringSchema),
+		"Actions":   mappingOf(actionSchema),
+	},
+	Required: []string{"Actions"},
+}
+
+var actionOrGroupSchema = &schema{
+	Type: schemaTypeMapping,
+	Select: func(node *yamlv3.Node) *schema {
+		if mappingValue(node, "Actions") != nil && mappingValue(node, "Identifier") == nil {
+			return actionGroupSchema
+		}
+		return actionSchema
+	},
+}
+
+// buildConfigurationSchema is the schema of the Configuration of the built-in aws/build and aws/managed-test actions
+var buildConfigurationSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"Steps": listOf(&schema{
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"Run": stringSchema,
+			},
+			Required: []string{"Run"},
+		}),
+		"Container": {
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"Registry": enumOf("DockerHub", "CODECATALYST"),
+				"Image":    stringSchema,
+			},
+			Required: []string{"Registry", "Image"},
+		},
+		"Packages": {
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"NpmConfiguration": {
+					Type: schemaTypeMapping,
+					Fields: map[string]*schema{
+						"PackageRegistries": listOf(&schema{
+							Type: schemaTypeMapping,
+							Fields: map[string]*schema{
+								"PackagesRepository": stringSchema,
+								"Scopes":             listOf(stringSchema),
+							},
+							Required: []string{"PackagesRepository"},
+						}),
+					},
+				},
+				"ExportAuthorizationToken": booleanSchema,
+			},
+		},
+	},
+	Required: []string{"Steps"},
+}
+
+var workflowSchema = &schema{
+	Type: schemaTypeMapping,
+	Fields: map[string]*schema{
+		"Name":          stringSchema,
+		"SchemaVersion": stringSchema,
+		"RunMode":       enumOf("QUEUED", "SUPERSEDED", "PARALLEL"),
+		"Triggers": listOf(&schema{
+			Type: schemaTypeMapping,
+			Fields: map[string]*schema{
+				"Type":         enumOf("PUSH", "PULLREQUEST", "SCHEDULE"),
+				"Events":       listOf(enumOf("OPEN", "REVISION", "CLOSED")),
+				"Branches":     listOf(stringSchema),
+				"FilesChanged": listOf(stringSchema),
+				"Expression":   stringSchema,
+			},
+			Required: []string{"Type"},
+		}),
+		"Compute": computeSchema,
+		"Actions": mappingOf(actionOrGroupSchema),
+	},
+	Required: []string{"Name", "SchemaVersion", "Actions"},
+}
+
+// schemaVisitor receives the findings of a schema walk, along with every node visited by path
+type schemaVisitor interface {
+	addFinding(node *yamlv3.Node, format string, args ...any)
+	visit(path string, node *yamlv3.Node)
+	reject(node *yamlv3.Node) // the scalar value can't be decoded into the type of its field
+}
+
+// walkSchema checks that the node conforms to the schema, reporting any unknown keys, missing keys or wrong types
+func walkSchema(visitor schemaVisitor, path string, node *yamlv3.Node, s *schema) {
+	if node.Kind == yamlv3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	visitor.visit(path, node)
+	if s.Select != nil {
+		s = s.Select(node)
+	}
+	if isNull(node) {
+		return
+	}
+	switch s.Type {
+	case schemaTypeAny:
+		return
+	case schemaTypeMapping:
+		if node.Kind != yamlv3.MappingNode {
+			visitor.addFinding(node, "'%s' must be a %s, but found %s", path, s.Type, nodeType(node))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			value := node.Content[i+1]
+			childPath := joinPath(path, key.Value)
+			if s.Values != nil {
+				walkSchema(visitor, childPath, value, s.Values)
+			} else if fieldSchema, ok := s.Fields[key.Value]; ok {
+				walkSchema(visitor, childPath, value, fieldSchema)
+			} else {
+				visitor.addFinding(key, "unknown key '%s' in '%s'%s", key.Value, path, suggestKey(key.Value, s.Fields))
+			}
+		}
+		for _, required := range s.Required {
+			if mappingValue(node, required) == nil {
+				visitor.addFinding(node, "missing required key '%s' in '%s'", required, path)
+			}
+		}
+	case schemaTypeList:
+		if node.Kind != yamlv3.SequenceNode {
+			visitor.addFinding(node, "'%s' must be a %s, but found %s", path, s.Type, nodeType(node))
+			return
+		}
+		for i, item := range node.Content {
+			walkSchema(visitor, fmt.Sprintf("%s[%d]", path, i), item, s.Items)
+		}
+	default:
+		if node.Kind != yamlv3.ScalarNode {
+			visitor.addFinding(node, "'%s' must be a %s, but found %s", path, s.Type, nodeType(node))
+			return
+		}
+		switch s.Type {
+		case schemaTypeInteger:
+			if node.ShortTag() != "!!int" {
+				visitor.addFinding(node, "'%s' must be an %s, but found '%s'", path, s.Type, node.Value)
+				visitor.reject(node)
+			}
+		case schemaTypeNumber:
+			if node.ShortTag() != "!!int" && node.ShortTag() != "!!float" {
+				visitor.addFinding(node, "'%s' must be a %s, but found '%s'", path, s.Type, node.Value)
+				visitor.reject(node)
+			}
+		case schemaTypeBoolean:
+			if node.ShortTag() != "!!bool" {
+				visitor.addFinding(node, "'%s' must be a %s, but found '%s'", path, s.Type, node.Value)
+				visitor.reject(node)
+			}
+		}
+		if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(v string) bool { return strings.EqualFold(v, node.Value) }) {
+			visitor.addFinding(node, "'%s' must be one of [%s], but found '%s'", path, strings.Join(s.Enum, ", "), node.Value)
+		}
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
+
+func isNull(node *yamlv3.Node) bool {
+	return node.Kind == yamlv3.ScalarNode && node.ShortTag() == "!!null"
+}
+
+func nodeType(node *yamlv3.Node) schemaType {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return schemaTypeMapping
+	case yamlv3.SequenceNode:
+		return schemaTypeList
+	default:
+		return schemaType(fmt.Sprintf("'%s'", node.Value))
+	}
+}
+
+// mappingValue returns the value of key in a mapping node, or nil if the key isn't present
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// suggestKey returns a hint for a known key that is similar to an unknown key
+func suggestKey(key string, fields map[string]*schema) string {
+	best := ""
+	bestDistance := 3
+	for field := range fields {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(field)); d < bestDistance || (d == bestDistance && field < best) {
+			best = field
+			bestDistance = d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean '%s'?)", best)
+}
+
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,168 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// parseSeededVariable splits a seeded variable such as Build.IMAGE_TAG or Group.Action.VAR into the ID of the producing plan and the variable name
+func parseSeededVariable(key string) (string, string, error) {
+	m := replacementVariablePattern.FindStringSubmatch(fmt.Sprintf("${%s}", key))
+	if m == nil || m[0] != fmt.Sprintf("${%s}", key) {
+		return "", "", fmt.Errorf("invalid variable '%s', expected <action>.<variable>", key)
+	}
+	return strings.Replace(m[1], ".", "@", 1), m[2], nil
+}
+
+// artifactProducer returns the ID of the action that produces the artifact
+func artifactProducer(workflowActions map[string]*Action, artifact string) (string, bool) {
+	for actionID, action := range workflowActions {
+		for _, outputArtifact := range action.Outputs.Artifacts {
+			if outputArtifact != nil && outputArtifact.Name == artifact {
+				return actionID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// seededProducers returns the IDs of the actions that produce the provided variables and artifacts, in sorted order.
+// These actions are considered satisfied and don't need to run.
+func seededProducers(workflowActions map[string]*Action, variables map[string]string, artifacts map[string]string) ([]string, error) {
+	producers := make([]string, 0)
+	add := func(actionID string) {
+		if !slices.Contains(producers, actionID) {
+			producers = append(producers, actionID)
+		}
+	}
+	for key := range variables {
+		planID, _, err := parseSeededVariable(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := workflowActions[planID]; !ok {
+			return nil, fmt.Errorf("unable to provide variable '%s': there is no action '%s' in the workflow", key, planID)
+		}
+		add(planID)
+	}
+	for artifact := range artifacts {
+		producer, ok := artifactProducer(workflowActions, artifact)
+		if !ok {
+			return nil, fmt.Errorf("unable to provide artifact '%s': it is not produced by any action in the workflow", artifact)
+		}
+		add(producer)
+	}
+	slices.Sort(producers)
+	return producers, nil
+}
+
+// checkInputArtifacts returns an error for each input artifact of a selected action that is neither produced by a planned
+// action nor provided, as the action would wait for it forever
+func checkInputArtifacts(workflowActions map[string]*Action, selectedIDs []string, satisfied []string) error {
+	var err error
+	for _, actionID := range selectedIDs {
+		if slices.Contains(satisfied, actionID) {
+			continue
+		}
+		for _, artifact := range workflowActions[actionID].Inputs.Artifacts {
+			producer, ok := artifactProducer(workflowActions, artifact)
+			if !ok || slices.Contains(selectedIDs, producer) || slices.Contains(satisfied, producer) {
+				continue
+			}
+			err = errors.Join(err, fmt.Errorf("action '%s' consumes artifact '%s' of action '%s', which is not selected: provide it with --artifact %s=PATH or select +%s", actionID, artifact, producer, artifact, actionID))
+		}
+	}
+	return err
+}
+
+// seedOutputs stores the provided variables in planOutputs and the provided artifacts in the artifact cache, as if the
+// producing actions had run. Artifacts are either a directory or a zip file.
+func seedOutputs(workflowActions map[string]*Action, variables map[string]string, artifacts map[string]string, artifactPlans map[string]string, cacheDir string) error {
+	for key, value := range variables {
+		planID, name, err := parseSeededVariable(key)
+		if err != nil {
+			return err
+		}
+		if _, ok := planOutputs[planID]; !ok {
+			planOutputs[planID] = make(map[string]string)
+		}
+		planOutputs[planID][name] = value
+	}
+	for artifact, source := range artifacts {
+		producer, ok := artifactProducer(workflowActions, artifact)
+		if !ok {
+			return fmt.Errorf("unable to provide artifact '%s': it is not produced by any action in the workflow", artifact)
+		}
+		if err := provideArtifact(artifact, source, cacheDir); err != nil {
+			return err
+		}
+		artifactPlans[artifact] = producer
+	}
+	return nil
+}
+
+// provideArtifact replaces the artifact in the artifact cache with the files of source, either a directory or a zip file
+func provideArtifact(artifact string, source string, cacheDir string) error {
+	artifactDir := filepath.Join(cacheDir, "artifacts", artifact)
+	if err := os.RemoveAll(artifactDir); err != nil {
+		return fmt.Errorf("unable to cleanup artifact dir %s: %w", artifactDir, err)
+	}
+	if err := os.MkdirAll(artifactDir, 0755); err != nil {
+		return fmt.Errorf("unable to create artifact dir %s: %w", artifactDir, err)
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return fmt.Errorf("unable to provide artifact '%s': %w", artifact, err)
+	}
+	if info.IsDir() {
+		err = copyDir(source, artifactDir)
+	} else {
+		err = extractZip(source, artifactDir)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to provide artifact '%s' from %s: %w", artifact, source, err)
+	}
+	return nil
+}
+
+// copyDir copies the files in sourceDir to destDir
+func copyDir(sourceDir string, destDir string) error {
+	return filepath.WalkDir(sourceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(destDir, relPath)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(targetPath, info.Mode().Perm()|0700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+}
@@ -0,0 +1,145 @@
+package workflows
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeededProducers(t *testing.T) {
+	workflow, err := readWorkflow("testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml")
+	assert.NoError(t, err)
+	workflowActions, _, err := workflow.ActionsByID()
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		TestCase          string
+		Variables         map[string]string
+		Artifacts         map[string]string
+		ExpectedProducers []string
+		ExpectedError     string
+	}{
+		{
+			TestCase:          "none",
+			ExpectedProducers: []string{},
+		},
+		{
+			TestCase:          "variables-and-artifacts",
+			Variables:         map[string]string{"FirstAction.VAR1": "foo", "Group1.SubAction1.VAR3": "foooo"},
+			Artifacts:         map[string]string{"ARTIFACT2": "./dist"},
+			ExpectedProducers: []string{"FirstAction", "Group1@SubAction1"},
+		},
+		{
+			TestCase:      "unknown-action",
+			Variables:     map[string]string{"Build.IMAGE_TAG": "abc123"},
+			ExpectedError: "unable to provide variable 'Build.IMAGE_TAG': there is no action 'Build' in the workflow",
+		},
+		{
+			TestCase:      "invalid-variable",
+			Variables:     map[string]string{"IMAGE_TAG": "abc123"},
+			ExpectedError: "invalid variable 'IMAGE_TAG', expected <action>.<variable>",
+		},
+		{
+			TestCase:      "unknown-artifact",
+			Artifacts:     map[string]string{"BuildOutput": "./dist"},
+			ExpectedError: "unable to provide artifact 'BuildOutput': it is not produced by any action in the workflow",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			producers, err := seededProducers(workflowActions, tt.Variables, tt.Artifacts)
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tt.ExpectedProducers, producers)
+			}
+		})
+	}
+}
+
+func TestCheckInputArtifacts(t *testing.T) {
+	workflow, err := readWorkflow("testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml")
+	assert.NoError(t, err)
+	workflowActions, actionIDs, err := workflow.ActionsByID()
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		TestCase      string
+		Action        string
+		Satisfied     []string
+		ExpectedError string
+	}{
+		{
+			TestCase: "all",
+		},
+		{
+			TestCase: "upstream",
+			Action:   "+FinalAction",
+		},
+		{
+			TestCase:  "provided",
+			Action:    "FinalAction",
+			Satisfied: []string{"Group1@SubAction1"},
+		},
+		{
+			TestCase:      "missing",
+			Action:        "FinalAction",
+			ExpectedError: "action 'FinalAction' consumes artifact 'ARTIFACT2' of action 'Group1@SubAction1', which is not selected: provide it with --artifact ARTIFACT2=PATH or select +FinalAction",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			selectedIDs, err := SelectActions(tt.Action, workflowActions, actionIDs)
+			assert.NoError(err)
+			err = checkInputArtifacts(workflowActions, selectedIDs, tt.Satisfied)
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestSeedOutputs(t *testing.T) {
+	assert := assert.New(t)
+	workflow, err := readWorkflow("testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml")
+	assert.NoError(err)
+	workflowActions, _, err := workflow.ActionsByID()
+	assert.NoError(err)
+
+	tmpDir := t.TempDir()
+	zipPath := filepath.Join(tmpDir, "artifact1.zip")
+	zipFile, err := os.Create(zipPath)
+	assert.NoError(err)
+	zipWriter := zip.NewWriter(zipFile)
+	entry, err := zipWriter.Create(".out/output.txt")
+	assert.NoError(err)
+	_, err = entry.Write([]byte("hello world"))
+	assert.NoError(err)
+	assert.NoError(zipWriter.Close())
+	assert.NoError(zipFile.Close())
+
+	cacheDir := filepath.Join(tmpDir, "cache")
+	artifactPlans := make(map[string]string)
+	err = seedOutputs(workflowActions, map[string]string{
+		"Group1.SubAction1.VAR3": "foooo",
+	}, map[string]string{
+		"ARTIFACT1": zipPath,
+		"ARTIFACT2": "testdata/seed/artifact2",
+	}, artifactPlans, cacheDir)
+	assert.NoError(err)
+
+	assert.Equal("foooo", planOutputs["Group1@SubAction1"]["VAR3"])
+	assert.Equal(map[string]string{"ARTIFACT1": "FirstAction", "ARTIFACT2": "Group1@SubAction1"}, artifactPlans)
+	content, err := os.ReadFile(filepath.Join(cacheDir, "artifacts", "ARTIFACT1", ".out", "output.txt"))
+	assert.NoError(err)
+	assert.Equal("hello world", string(content))
+	content, err = os.ReadFile(filepath.Join(cacheDir, "artifacts", "ARTIFACT2", ".out", "output2.txt"))
+	assert.NoError(err)
+	assert.Equal("hello again\n", string(content))
+}
@@ -0,0 +1,141 @@
+package workflows
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// actionSelector is a single term of an action selection expression
+type actionSelector struct {
+	Pattern    string // action ID, action group name or glob pattern
+	Exclude    bool   // remove the matching actions from the selection
+	Upstream   bool   // also select every action that the matching actions depend on
+	Downstream bool   // also select every action that depends on the matching actions
+}
+
+// parseActionSelectors parses a comma separated list of selectors such as 'Build+', '+Deploy', 'Test@*' or '!Deploy*'
+func parseActionSelectors(expression string) ([]*actionSelector, error) {
+	selectors := make([]*actionSelector, 0)
+	for _, term := range strings.Split(expression, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		selector := new(actionSelector)
+		original := term
+		if strings.HasPrefix(term, "!") {
+			selector.Exclude = true
+			term = term[1:]
+		}
+		if strings.HasPrefix(term, "+") {
+			selector.Upstream = true
+			term = term[1:]
+		}
+		if strings.HasSuffix(term, "+") {
+			selector.Downstream = true
+			term = term[:len(term)-1]
+		}
+		if term == "" {
+			return nil, fmt.Errorf("action selector '%s' is missing an action name", original)
+		}
+		if _, err := path.Match(term, ""); err != nil {
+			return nil, fmt.Errorf("invalid action selector '%s': %w", term, err)
+		}
+		selector.Pattern = term
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
+
+// matches returns true if the selector pattern matches the action ID, the name of its group, or is a glob matching the action ID
+func (s *actionSelector) matches(actionID string) bool {
+	if s.Pattern == actionID || strings.HasPrefix(actionID, s.Pattern+"@") {
+		return true
+	}
+	matched, _ := path.Match(s.Pattern, actionID)
+	return matched
+}
+
+// SelectActions returns the IDs of the actions selected by the expression, in the order of actionIDs.
+//
+// The expression is a comma separated list of selectors. A selector is an action ID, an action group name or a glob pattern,
+// optionally prefixed with '+' to add everything upstream of the matching actions and suffixed with '+' to add everything
+// downstream of them. Selectors prefixed with '!' remove the matching actions from the selection. If the expression contains
+// no selectors other than exclusions, every action is selected before the exclusions are applied.
+//
+// Upstream and downstream are based on the resolved dependencies, including the producers of input artifacts and variables.
+func SelectActions(expression string, workflowActions map[string]*Action, actionIDs []string) ([]string, error) {
+	selectors, err := parseActionSelectors(expression)
+	if err != nil {
+		return nil, err
+	}
+	upstreams := make(map[string][]string)
+	downstreams := make(map[string][]string)
+	for _, dependency := range ResolveDependencies(workflowActions, actionIDs) {
+		upstreams[dependency.Action] = append(upstreams[dependency.Action], dependency.Upstream)
+		downstreams[dependency.Upstream] = append(downstreams[dependency.Upstream], dependency.Action)
+	}
+
+	resolve := func(selector *actionSelector) (map[string]bool, error) {
+		resolved := make(map[string]bool)
+		queue := make([]string, 0)
+		for _, actionID := range actionIDs {
+			if selector.matches(actionID) {
+				resolved[actionID] = true
+				queue = append(queue, actionID)
+			}
+		}
+		if len(resolved) == 0 {
+			return nil, fmt.Errorf("action selector '%s' doesn't match any action in the workflow", selector.Pattern)
+		}
+		for len(queue) > 0 {
+			actionID := queue[0]
+			queue = queue[1:]
+			next := make([]string, 0)
+			if selector.Upstream {
+				next = append(next, upstreams[actionID]...)
+			}
+			if selector.Downstream {
+				next = append(next, downstreams[actionID]...)
+			}
+			for _, nextID := range next {
+				if !resolved[nextID] {
+					resolved[nextID] = true
+					queue = append(queue, nextID)
+				}
+			}
+		}
+		return resolved, nil
+	}
+
+	selected := make(map[string]bool)
+	if !slices.ContainsFunc(selectors, func(s *actionSelector) bool { return !s.Exclude }) {
+		for _, actionID := range actionIDs {
+			selected[actionID] = true
+		}
+	}
+	for _, exclude := range []bool{false, true} {
+		for _, selector := range selectors {
+			if selector.Exclude != exclude {
+				continue
+			}
+			resolved, err := resolve(selector)
+			if err != nil {
+				return nil, err
+			}
+			for actionID := range resolved {
+				selected[actionID] = !exclude
+			}
+		}
+	}
+
+	selectedIDs := make([]string, 0, len(selected))
+	for _, actionID := range actionIDs {
+		if selected[actionID] {
+			selectedIDs = append(selectedIDs, actionID)
+		}
+	}
+	return selectedIDs, nil
+}
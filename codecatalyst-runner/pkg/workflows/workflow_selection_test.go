@@ -0,0 +1,88 @@
+package workflows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectActions(t *testing.T) {
+	workflow, err := readWorkflow("testdata/exemplar-codecatalyst-action/.codecatalyst/workflows/sample.yaml")
+	assert.NoError(t, err)
+	workflowActions, actionIDs, err := workflow.ActionsByID()
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		TestCase      string
+		Expression    string
+		ExpectedIDs   []string
+		ExpectedError string
+	}{
+		{
+			TestCase:    "all",
+			Expression:  "",
+			ExpectedIDs: []string{"FirstAction", "Group1@SubAction1", "Group1@SubAction2", "FinalAction"},
+		},
+		{
+			TestCase:    "exact",
+			Expression:  "FinalAction",
+			ExpectedIDs: []string{"FinalAction"},
+		},
+		{
+			TestCase:    "downstream",
+			Expression:  "Group1@SubAction1+",
+			ExpectedIDs: []string{"Group1@SubAction1", "FinalAction"},
+		},
+		{
+			TestCase:    "upstream",
+			Expression:  "+FinalAction",
+			ExpectedIDs: []string{"FirstAction", "Group1@SubAction1", "FinalAction"},
+		},
+		{
+			TestCase:    "group",
+			Expression:  "Group1",
+			ExpectedIDs: []string{"Group1@SubAction1", "Group1@SubAction2"},
+		},
+		{
+			TestCase:    "glob",
+			Expression:  "Group1@*",
+			ExpectedIDs: []string{"Group1@SubAction1", "Group1@SubAction2"},
+		},
+		{
+			TestCase:    "exclude",
+			Expression:  "!F*",
+			ExpectedIDs: []string{"Group1@SubAction1", "Group1@SubAction2"},
+		},
+		{
+			TestCase:    "combined",
+			Expression:  "FirstAction+, !Group1@SubAction2",
+			ExpectedIDs: []string{"FirstAction", "Group1@SubAction1", "FinalAction"},
+		},
+		{
+			TestCase:      "no-match",
+			Expression:    "Deploy",
+			ExpectedError: "action selector 'Deploy' doesn't match any action in the workflow",
+		},
+		{
+			TestCase:      "missing-name",
+			Expression:    "!+",
+			ExpectedError: "action selector '!+' is missing an action name",
+		},
+		{
+			TestCase:      "bad-pattern",
+			Expression:    "Group1@[",
+			ExpectedError: "invalid action selector 'Group1@[': syntax error in pattern",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			selectedIDs, err := SelectActions(tt.Expression, workflowActions, actionIDs)
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tt.ExpectedIDs, selectedIDs)
+			}
+		})
+	}
+}
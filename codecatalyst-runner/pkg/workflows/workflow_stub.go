@@ -0,0 +1,153 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Stub is a local file with the recorded outputs of an action. A stubbed action doesn't run its commands, it only sets
+// the recorded output variables and produces the recorded output artifacts, e.g.
+//
+//	Outputs:
+//	  STACK_ID: arn:aws:cloudformation:us-west-2:111111111111:stack/my-stack/1
+//	Artifacts:
+//	  DeployOutput: deploy-artifacts/DeployOutput.zip
+type Stub struct {
+	Outputs   map[string]string `yaml:"Outputs,omitempty"`   // output variables of the action
+	Artifacts map[string]string `yaml:"Artifacts,omitempty"` // directory or zip file of each output artifact, relative to the stub file
+}
+
+// StubConfig is a local file that maps the actions of a workflow to their stub files, e.g.
+//
+//	Stubs:
+//	  Deploy: stubs/deploy.yaml
+type StubConfig struct {
+	Stubs map[string]string `yaml:"Stubs"` // path of the stub file of each action, relative to the config file
+}
+
+// ReadStubConfig reads the paths of the stub files from a [StubConfig] file, keyed by action ID
+func ReadStubConfig(configPath string) (map[string]string, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stub config '%s': %w", configPath, err)
+	}
+	config := new(StubConfig)
+	if err := yamlv3.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("unable to parse stub config '%s': %w", configPath, err)
+	}
+	stubs := make(map[string]string, len(config.Stubs))
+	for actionID, stubPath := range config.Stubs {
+		if !filepath.IsAbs(stubPath) {
+			stubPath = filepath.Join(filepath.Dir(configPath), stubPath)
+		}
+		stubs[actionID] = stubPath
+	}
+	return stubs, nil
+}
+
+// readStub reads the stub file at stubPath. The paths of the artifacts are resolved relative to the stub file.
+func readStub(stubPath string) (*Stub, error) {
+	content, err := os.ReadFile(stubPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read stub '%s': %w", stubPath, err)
+	}
+	stub := new(Stub)
+	if err := yamlv3.Unmarshal(content, stub); err != nil {
+		return nil, fmt.Errorf("unable to parse stub '%s': %w", stubPath, err)
+	}
+	for artifact, source := range stub.Artifacts {
+		if !filepath.IsAbs(source) {
+			stub.Artifacts[artifact] = filepath.Join(filepath.Dir(stubPath), source)
+		}
+	}
+	return stub, nil
+}
+
+// actionStubs checks that the stubbed actions are in the workflow and reads their stubs. When recording, the stub
+// files are written rather than read, so only the actions are checked.
+func actionStubs(stubPaths map[string]string, workflowActions map[string]*Action, record bool) (map[string]*Stub, error) {
+	stubs := make(map[string]*Stub, len(stubPaths))
+	for actionID, stubPath := range stubPaths {
+		if _, ok := workflowActions[actionID]; !ok {
+			return nil, fmt.Errorf("unable to stub action '%s': there is no action '%s' in the workflow", actionID, actionID)
+		}
+		if record {
+			continue
+		}
+		stub, err := readStub(stubPath)
+		if err != nil {
+			return nil, err
+		}
+		stubs[actionID] = stub
+	}
+	return stubs, nil
+}
+
+// StubAction is a Feature that replaces the command groups of the plan with a no-op. The output variables of the stub,
+// including multi-line values, are set in the outputs collected by the ActionOutputHandler that wraps it, and its
+// artifacts are copied to the artifacts directory of cacheDir.
+func StubAction(stub *Stub, outputs map[string]string, cacheDir string) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, _ runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER StubAction")
+		log.Ctx(ctx).Info().Msg("🎭 STUBBED with recorded outputs")
+		for name, value := range stub.Outputs {
+			log.Ctx(ctx).Debug().Msgf("Setting output %s = %s", name, value)
+			outputs[name] = value
+		}
+		for _, artifact := range sortedKeys(stub.Artifacts) {
+			if err := provideArtifact(artifact, stub.Artifacts[artifact], cacheDir); err != nil {
+				return err
+			}
+		}
+		log.Ctx(ctx).Debug().Msg("EXIT StubAction")
+		return nil
+	}
+}
+
+// StubRecorder is a Feature that writes the output variables and artifacts of an action that succeeded to a stub file.
+// The artifacts are zipped from the artifacts directory of cacheDir into a directory next to the stub file.
+func StubRecorder(stubPath string, outputs map[string]string, artifacts []string, cacheDir string) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER StubRecorder")
+		if err := e(ctx); err != nil {
+			return err
+		}
+		stub := &Stub{
+			Outputs:   make(map[string]string),
+			Artifacts: make(map[string]string),
+		}
+		for name, value := range outputs {
+			stub.Outputs[name] = value
+		}
+		artifactsDir := fmt.Sprintf("%s-artifacts", strings.TrimSuffix(filepath.Base(stubPath), filepath.Ext(stubPath)))
+		for _, artifact := range artifacts {
+			zipName := filepath.Join(artifactsDir, fmt.Sprintf("%s.zip", artifact))
+			if zipped, err := zipDir(filepath.Join(cacheDir, "artifacts", artifact), filepath.Join(filepath.Dir(stubPath), zipName)); err != nil {
+				return fmt.Errorf("unable to record artifact '%s': %w", artifact, err)
+			} else if zipped {
+				stub.Artifacts[artifact] = filepath.ToSlash(zipName)
+			}
+		}
+		content, err := yamlv3.Marshal(stub)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(stubPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(stubPath, content, 0644); err != nil {
+			return fmt.Errorf("unable to write stub '%s': %w", stubPath, err)
+		}
+		log.Ctx(ctx).Info().Msgf("🎭 Recorded %d outputs and artifacts %v to %s", len(stub.Outputs), sortedKeys(stub.Artifacts), stubPath)
+		log.Ctx(ctx).Debug().Msg("EXIT StubRecorder")
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStub(t *testing.T) {
+	assert := assert.New(t)
+	ctx := log.Logger.WithContext(context.Background())
+	stubDir := t.TempDir()
+	stubPath := filepath.Join(stubDir, "stubs", "deploy.yaml")
+
+	// record the outputs and artifacts of an action that succeeds
+	cacheDir := t.TempDir()
+	assert.NoError(os.MkdirAll(filepath.Join(cacheDir, "artifacts", "DeployOutput"), 0755))
+	assert.NoError(os.WriteFile(filepath.Join(cacheDir, "artifacts", "DeployOutput", "stack.json"), []byte("{}"), 0644))
+	outputs := map[string]string{"STACK_ID": "my-stack"}
+	feature := StubRecorder(stubPath, outputs, []string{"DeployOutput", "Missing"}, cacheDir)
+	m := new(runner.MockPlanExecutor)
+	m.OnExecute(mock.Anything).Return(nil)
+	assert.NoError(m.Execute(ctx, feature))
+	assert.FileExists(filepath.Join(stubDir, "stubs", "deploy-artifacts", "DeployOutput.zip"))
+
+	// nothing is recorded when the action fails
+	failedPath := filepath.Join(stubDir, "failed.yaml")
+	feature = StubRecorder(failedPath, outputs, nil, cacheDir)
+	m = new(runner.MockPlanExecutor)
+	m.OnExecute(mock.Anything).Return(fmt.Errorf("mock-error"))
+	assert.EqualError(m.Execute(ctx, feature), "mock-error")
+	assert.NoFileExists(failedPath)
+
+	// the config resolves the stub files relative to itself
+	configPath := filepath.Join(stubDir, "stubs.yaml")
+	assert.NoError(os.WriteFile(configPath, []byte("Stubs:\n  Deploy: stubs/deploy.yaml\n"), 0644))
+	stubPaths, err := ReadStubConfig(configPath)
+	assert.NoError(err)
+	assert.Equal(map[string]string{"Deploy": stubPath}, stubPaths)
+
+	workflowActions := map[string]*Action{"Deploy": {}}
+	_, err = actionStubs(map[string]string{"Missing": stubPath}, workflowActions, false)
+	assert.EqualError(err, "unable to stub action 'Missing': there is no action 'Missing' in the workflow")
+	stubs, err := actionStubs(stubPaths, workflowActions, false)
+	assert.NoError(err)
+	assert.Equal(outputs, stubs["Deploy"].Outputs)
+	stubs, err = actionStubs(stubPaths, workflowActions, true)
+	assert.NoError(err)
+	assert.Empty(stubs)
+
+	// the stubbed action sets its outputs and provides its artifacts without running
+	stubs, err = actionStubs(stubPaths, workflowActions, false)
+	assert.NoError(err)
+	stubCacheDir := t.TempDir()
+	stubOutputs := make(map[string]string)
+	m = new(runner.MockPlanExecutor)
+	assert.NoError(m.Execute(ctx, StubAction(stubs["Deploy"], stubOutputs, stubCacheDir)))
+	m.AssertNotCalled(t, "Execute", mock.Anything)
+	assert.Equal(outputs, stubOutputs)
+	assert.FileExists(filepath.Join(stubCacheDir, "artifacts", "DeployOutput", "stack.json"))
+
+	// multi-line values are recorded and replayed as they are
+	multiLinePath := filepath.Join(stubDir, "multi-line.yaml")
+	multiLineOutputs := map[string]string{"LINES": "a\nb\n", "SINGLE": "c"}
+	m = new(runner.MockPlanExecutor)
+	m.OnExecute(mock.Anything).Return(nil)
+	assert.NoError(m.Execute(ctx, StubRecorder(multiLinePath, multiLineOutputs, nil, cacheDir)))
+	stubs, err = actionStubs(map[string]string{"Deploy": multiLinePath}, workflowActions, false)
+	assert.NoError(err)
+	stubOutputs = make(map[string]string)
+	m = new(runner.MockPlanExecutor)
+	assert.NoError(m.Execute(ctx, StubAction(stubs["Deploy"], stubOutputs, stubCacheDir)))
+	assert.Equal(multiLineOutputs, stubOutputs)
+}
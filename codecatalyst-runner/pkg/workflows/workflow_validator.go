@@ -0,0 +1,395 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/actions"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Severity of a [Finding]
+type Severity string
+
+const (
+	// SeverityError is a problem that prevents the workflow from running
+	SeverityError Severity = "error"
+	// SeverityWarning is a problem that may cause unexpected behavior
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem found while validating a workflow file
+type Finding struct {
+	File     string   // path of the workflow file
+	Line     int      // line of the offending value, starting at 1
+	Column   int      // column of the offending value, starting at 1
+	Severity Severity // severity of the problem
+	Message  string   // description of the problem
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", f.File, f.Line, f.Column, f.Severity, f.Message)
+}
+
+// Findings is a list of [Finding]
+type Findings []*Finding
+
+// Count returns the number of findings with the provided severity
+func (f Findings) Count(severity Severity) int {
+	count := 0
+	for _, finding := range f {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// ActionLoader loads the definition of an action by its identifier, without the version
+type ActionLoader func(ctx context.Context, workingDir string, identifier string) (*actions.Action, error)
+
+// ValidateParams contains the parameters to validate workflow files
+type ValidateParams struct {
+	WorkingDir   string       // directory containing the .codecatalyst/workflows directory to validate
+	WorkflowPath string       // path of a single workflow file to validate. If empty, every workflow in the working directory is validated
+	ActionLoader ActionLoader // loads the action definitions used to validate Configuration. Defaults to loading the local or remote action
+}
+
+// CodeCatalyst limits checked by [Validate]
+const (
+	maxActions             = 50
+	maxNameLength          = 100
+	maxOutputVariables     = 10
+	maxVariableNameLength  = 255
+	maxActionTimeout       = 480
+	supportedSchemaVersion = "1.0"
+)
+
+var (
+	namePattern         = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	artifactNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	variableNamePattern = regexp.MustCompile(`^[A-Za-z0-9@\-_]+$`)
+	yamlErrorPattern    = regexp.MustCompile(`^yaml: line (\d+): (.+)$`)
+)
+
+// Validate checks workflow files against the CodeCatalyst workflow definition. It reports unknown keys, values of the wrong type,
+// unknown action identifiers, missing required Configuration, duplicate artifact names, invalid dependencies and CodeCatalyst limits.
+// An error is only returned if the workflow files can't be read.
+func Validate(ctx context.Context, params *ValidateParams) (Findings, error) {
+	loader := params.ActionLoader
+	if loader == nil {
+		loader = loadAction
+	}
+	workflowPaths := make([]string, 0)
+	if params.WorkflowPath != "" {
+		workflowPaths = append(workflowPaths, params.WorkflowPath)
+	} else {
+		workflowsDir := filepath.Join(params.WorkingDir, ".codecatalyst", "workflows")
+		entries, err := os.ReadDir(workflowsDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list workflows: %w", err)
+		}
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+				workflowPaths = append(workflowPaths, filepath.Join(workflowsDir, entry.Name()))
+			}
+		}
+	}
+
+	findings := make(Findings, 0)
+	for _, workflowPath := range workflowPaths {
+		workingDir := params.WorkingDir
+		if params.WorkflowPath != "" || workingDir == "" {
+			workingDir, _ = filepath.Abs(filepath.Dir(filepath.Dir(filepath.Dir(workflowPath))))
+		}
+		content, err := os.ReadFile(workflowPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read workflow file '%s': %w", workflowPath, err)
+		}
+		v := &workflowValidator{
+			file:       workflowPath,
+			workingDir: workingDir,
+			loader:     loader,
+			nodes:      make(map[string]*yamlv3.Node),
+			findings:   make(Findings, 0),
+		}
+		v.validate(ctx, content)
+		sort.SliceStable(v.findings, func(i, j int) bool {
+			if v.findings[i].Line != v.findings[j].Line {
+				return v.findings[i].Line < v.findings[j].Line
+			}
+			return v.findings[i].Column < v.findings[j].Column
+		})
+		findings = append(findings, v.findings...)
+	}
+	return findings, nil
+}
+
+func loadAction(ctx context.Context, workingDir string, identifier string) (*actions.Action, error) {
+	if identifier == "." {
+		return actions.Load(workingDir)
+	}
+	return loadRemoteAction(ctx, identifier)
+}
+
+type workflowValidator struct {
+	file       string
+	workingDir string
+	loader     ActionLoader
+	nodes      map[string]*yamlv3.Node
+	rejected   []*yamlv3.Node
+	findings   Findings
+}
+
+func (v *workflowValidator) visit(path string, node *yamlv3.Node) {
+	v.nodes[path] = node
+}
+
+func (v *workflowValidator) reject(node *yamlv3.Node) {
+	v.rejected = append(v.rejected, node)
+}
+
+func (v *workflowValidator) addFinding(node *yamlv3.Node, format string, args ...any) {
+	v.add(SeverityError, node, format, args...)
+}
+
+func (v *workflowValidator) add(severity Severity, node *yamlv3.Node, format string, args ...any) {
+	finding := &Finding{
+		File:     v.file,
+		Line:     1,
+		Column:   1,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if node != nil {
+		finding.Line = max(node.Line, 1)
+		finding.Column = max(node.Column, 1)
+	}
+	v.findings = append(v.findings, finding)
+}
+
+// node returns the node at path, or the closest parent of path that was visited
+func (v *workflowValidator) node(path string) *yamlv3.Node {
+	for path != "" {
+		if node, ok := v.nodes[path]; ok {
+			return node
+		}
+		if i := strings.LastIndexAny(path, ".["); i >= 0 {
+			path = path[:i]
+		} else {
+			path = ""
+		}
+	}
+	return v.nodes[""]
+}
+
+func (v *workflowValidator) validate(ctx context.Context, content []byte) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &document); err != nil {
+		line := 1
+		message := err.Error()
+		if m := yamlErrorPattern.FindStringSubmatch(message); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			message = m[2]
+		}
+		v.add(SeverityError, &yamlv3.Node{Line: line, Column: 1}, "invalid YAML: %s", message)
+		return
+	}
+	if len(document.Content) == 0 {
+		v.add(SeverityError, nil, "workflow file is empty")
+		return
+	}
+	root := document.Content[0]
+	walkSchema(v, "", root, workflowSchema)
+	if v.findings.Count(SeverityError) > 0 && root.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	if len(v.rejected) > 0 {
+		// clear the values with the wrong type, they are already reported and would prevent decoding the rest of the workflow
+		for _, node := range v.rejected {
+			node.Tag = "!!null"
+			node.Value = ""
+			node.Style = 0
+		}
+		var err error
+		if content, err = yamlv3.Marshal(&document); err != nil {
+			return
+		}
+	}
+	workflow := new(Workflow)
+	if err := yaml.Unmarshal(content, workflow); err != nil {
+		// the schema findings describe why the workflow couldn't be decoded
+		return
+	}
+	workflowActions, actionIDs, err := workflow.ActionsByID()
+	if err != nil {
+		return
+	}
+
+	if workflow.SchemaVersion != "" && workflow.SchemaVersion != supportedSchemaVersion {
+		v.add(SeverityError, v.node("SchemaVersion"), "unsupported SchemaVersion '%s', expected '%s'", workflow.SchemaVersion, supportedSchemaVersion)
+	}
+	v.checkName(v.node("Name"), "workflow", workflow.Name)
+	if len(actionIDs) > maxActions {
+		v.add(SeverityError, v.node("Actions"), "workflow defines %d actions, but at most %d are allowed", len(actionIDs), maxActions)
+	}
+
+	artifactProducers := make(map[string]string)
+	for _, actionID := range actionIDs {
+		action := workflowActions[actionID]
+		path := actionPath(actionID)
+		for _, name := range strings.Split(actionID, "@") {
+			v.checkName(v.node(path), "action", name)
+		}
+		v.checkIdentifier(ctx, path, action)
+		v.checkLimits(path, action)
+		for i, artifact := range action.Outputs.Artifacts {
+			if artifact == nil {
+				continue
+			}
+			artifactPath := fmt.Sprintf("%s.Outputs.Artifacts[%d].Name", path, i)
+			if producer, ok := artifactProducers[artifact.Name]; ok {
+				v.add(SeverityError, v.node(artifactPath), "duplicate artifact name '%s', already produced by action '%s'", artifact.Name, producer)
+			} else {
+				artifactProducers[artifact.Name] = actionID
+			}
+			if !artifactNamePattern.MatchString(artifact.Name) || len(artifact.Name) > maxNameLength {
+				v.add(SeverityError, v.node(artifactPath), "invalid artifact name '%s', must be at most %d alphanumeric or '_' characters", artifact.Name, maxNameLength)
+			}
+		}
+	}
+
+	for _, issue := range dependencyIssues(workflowActions, actionIDs) {
+		path := actionPath(issue.Action)
+		if issue.Key != "" {
+			path = joinPath(path, issue.Key)
+		}
+		v.add(SeverityError, v.node(path), "%s", issue.Err.Error())
+	}
+}
+
+// actionPath returns the path of the action definition in the workflow file
+func actionPath(actionID string) string {
+	if group, name, ok := strings.Cut(actionID, "@"); ok {
+		return fmt.Sprintf("Actions.%s.Actions.%s", group, name)
+	}
+	return fmt.Sprintf("Actions.%s", actionID)
+}
+
+func (v *workflowValidator) checkName(node *yamlv3.Node, kind string, name string) {
+	if name == "" {
+		return
+	}
+	if !namePattern.MatchString(name) || len(name) > maxNameLength {
+		v.add(SeverityError, node, "invalid %s name '%s', must be at most %d alphanumeric, '-' or '_' characters", kind, name, maxNameLength)
+	}
+}
+
+func (v *workflowValidator) checkIdentifier(ctx context.Context, path string, action *Action) {
+	identifierNode := v.node(joinPath(path, "Identifier"))
+	identifier, version, hasVersion := strings.Cut(action.Identifier, "@")
+	if identifier != "." && (!hasVersion || version == "") {
+		v.add(SeverityError, identifierNode, "action identifier '%s' must include a version, such as '%s@v1'", action.Identifier, identifier)
+	}
+
+	configurationPath := joinPath(path, "Configuration")
+	switch identifier {
+	case "aws/build", "aws/managed-test":
+		if node, ok := v.nodes[configurationPath]; ok {
+			walkSchema(v, configurationPath, node, buildConfigurationSchema)
+		} else {
+			v.add(SeverityError, v.node(path), "missing required key 'Configuration' in '%s'", path)
+		}
+		return
+	case "aws/github-actions-runner":
+		v.add(SeverityError, identifierNode, "GitHub actions are not currently supported")
+		return
+	case ".":
+	default:
+		if _, ok := ActionVersions[identifier]; !ok {
+			v.add(SeverityError, identifierNode, "unknown action identifier '%s'", action.Identifier)
+			return
+		}
+	}
+
+	actionSpec, err := v.loader(ctx, v.workingDir, identifier)
+	if err != nil {
+		v.add(SeverityWarning, identifierNode, "unable to load action '%s' to validate its Configuration: %s", action.Identifier, err.Error())
+		return
+	}
+	names := make([]string, 0, len(actionSpec.Configuration))
+	for name := range actionSpec.Configuration {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		param := actionSpec.Configuration[name]
+		if _, ok := action.Configuration[name]; !ok && param.Required && param.Default == "" {
+			v.add(SeverityError, v.node(configurationPath), "missing required Configuration '%s' for action '%s'", name, action.Identifier)
+		}
+	}
+	keys := make([]string, 0, len(action.Configuration))
+	for key := range action.Configuration {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if _, ok := actionSpec.Configuration[key]; !ok {
+			v.add(SeverityWarning, v.keyNode(configurationPath, key), "unknown Configuration '%s' for action '%s'%s", key, action.Identifier, suggestKey(key, parameterSchemas(actionSpec)))
+		}
+	}
+}
+
+func (v *workflowValidator) checkLimits(path string, action *Action) {
+	if len(action.Outputs.Variables) > maxOutputVariables {
+		v.add(SeverityError, v.node(joinPath(path, "Outputs.Variables")), "action defines %d output variables, but at most %d are allowed", len(action.Outputs.Variables), maxOutputVariables)
+	}
+	for i, name := range action.Outputs.Variables {
+		v.checkVariableName(fmt.Sprintf("%s.Outputs.Variables[%d]", path, i), name)
+	}
+	for i, variable := range action.Inputs.Variables {
+		v.checkVariableName(fmt.Sprintf("%s.Inputs.Variables[%d].Name", path, i), variable.Name)
+	}
+	if node, ok := v.nodes[joinPath(path, "Timeout")]; ok && node.ShortTag() == "!!int" {
+		if action.Timeout < 1 || action.Timeout > maxActionTimeout {
+			v.add(SeverityError, node, "Timeout must be between 1 and %d minutes, but found %d", maxActionTimeout, action.Timeout)
+		}
+	}
+}
+
+func (v *workflowValidator) checkVariableName(path string, name string) {
+	if !variableNamePattern.MatchString(name) || len(name) > maxVariableNameLength {
+		v.add(SeverityError, v.node(path), "invalid variable name '%s', must be at most %d alphanumeric, '@', '-' or '_' characters", name, maxVariableNameLength)
+	}
+}
+
+// keyNode returns the node of key in the mapping at path
+func (v *workflowValidator) keyNode(path string, key string) *yamlv3.Node {
+	node := v.node(path)
+	if node != nil && node.Kind == yamlv3.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i]
+			}
+		}
+	}
+	return node
+}
+
+func parameterSchemas(actionSpec *actions.Action) map[string]*schema {
+	fields := make(map[string]*schema, len(actionSpec.Configuration))
+	for name := range actionSpec.Configuration {
+		fields[name] = anySchema
+	}
+	return fields
+}
@@ -0,0 +1,107 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/codecatalyst-runner/pkg/actions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	loader := func(_ context.Context, _ string, identifier string) (*actions.Action, error) {
+		if identifier != "." {
+			return nil, fmt.Errorf("unexpected action %s", identifier)
+		}
+		return &actions.Action{
+			Configuration: map[string]actions.Parameter{
+				"WhoToGreet": {Required: true},
+				"HowToGreet": {Required: true, Default: "Hello"},
+			},
+		}, nil
+	}
+	for _, tt := range []struct {
+		TestCase         string
+		WorkflowPath     string
+		ExpectedFindings []string
+	}{
+		{
+			TestCase:         "valid",
+			WorkflowPath:     "testdata/validate/valid.yaml",
+			ExpectedFindings: []string{},
+		},
+		{
+			TestCase:     "invalid",
+			WorkflowPath: "testdata/validate/invalid.yaml",
+			ExpectedFindings: []string{
+				"1:7: error: invalid workflow name 'invalid workflow', must be at most 100 alphanumeric, '-' or '_' characters",
+				"3:10: error: 'RunMode' must be one of [QUEUED, SUPERSEDED, PARALLEL], but found 'SERIAL'",
+				"7:14: error: 'Actions.Build.Timeout' must be an integer, but found 'ten'",
+				"14:7: error: unknown key 'Step' in 'Actions.Build.Configuration' (did you mean 'Steps'?)",
+				"14:7: error: missing required key 'Steps' in 'Actions.Build.Configuration'",
+				"19:23: error: 'Actions.Package.Compute.SharedInstance' must be a boolean, but found 'maybe'",
+				"22:17: error: duplicate artifact name 'BuildOutput', already produced by action 'Build'",
+				"29:5: error: unknown key 'Identifer' in 'Actions.Deploy' (did you mean 'Identifier'?)",
+				"30:17: error: unknown action identifier 'acme/deploy@v1'",
+				"34:9: error: action 'Greet' depends on 'Bulid', which is not an action or action group in the workflow",
+				"36:7: error: missing required Configuration 'WhoToGreet' for action '.'",
+				"36:7: warning: unknown Configuration 'WhoToGreat' for action '.' (did you mean 'WhoToGreet'?)",
+			},
+		},
+		{
+			TestCase:     "malformed",
+			WorkflowPath: "testdata/validate/malformed.yaml",
+			ExpectedFindings: []string{
+				"3:1: error: invalid YAML: did not find expected key",
+			},
+		},
+		{
+			TestCase:     "timeout",
+			WorkflowPath: "testdata/validate/timeout.yaml",
+			ExpectedFindings: []string{
+				"6:14: error: Timeout must be between 1 and 480 minutes, but found 600",
+				"12:14: error: Timeout must be between 1 and 480 minutes, but found 0",
+			},
+		},
+		{
+			TestCase:     "cycle",
+			WorkflowPath: "testdata/dependencies/cycle.yaml",
+			ExpectedFindings: []string{
+				"7:7: error: dependency cycle detected: Build -> Test -> Build",
+			},
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			findings, err := Validate(context.Background(), &ValidateParams{
+				WorkflowPath: tt.WorkflowPath,
+				ActionLoader: loader,
+			})
+			assert.NoError(err)
+			actualFindings := make([]string, 0)
+			for _, finding := range findings {
+				assert.Equal(tt.WorkflowPath, finding.File)
+				actualFindings = append(actualFindings, strings.TrimPrefix(finding.String(), tt.WorkflowPath+":"))
+			}
+			assert.Equal(tt.ExpectedFindings, actualFindings)
+		})
+	}
+}
+
+func TestValidateWorkingDir(t *testing.T) {
+	assert := assert.New(t)
+	findings, err := Validate(context.Background(), &ValidateParams{
+		WorkingDir: "testdata/exemplar-codecatalyst-action",
+		ActionLoader: func(_ context.Context, workingDir string, identifier string) (*actions.Action, error) {
+			if identifier == "." {
+				return actions.Load(workingDir)
+			}
+			return nil, fmt.Errorf("offline")
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(0, findings.Count(SeverityError), "%v", findings)
+	assert.Equal(1, findings.Count(SeverityWarning), "%v", findings)
+}
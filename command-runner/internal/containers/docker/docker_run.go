@@ -303,9 +303,9 @@ func (cr *dockerContainer) exec(cmd []string, env map[string]string, user, workd
 		case 0:
 			return nil
 		case 127:
-			return fmt.Errorf("exitcode '%d': command not found", inspectResp.ExitCode)
+			return &common.ExitCodeError{Code: inspectResp.ExitCode, Message: "command not found"}
 		default:
-			return fmt.Errorf("exitcode '%d': failure", inspectResp.ExitCode)
+			return &common.ExitCodeError{Code: inspectResp.ExitCode, Message: "failure"}
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+type attemptsContextKey string
+
+const attemptsContextKeyVal = attemptsContextKey("attempts")
+
+// Attempts records how many times the commands of a plan have been attempted
+type Attempts struct {
+	attempt     int
+	maxAttempts int
+	mu          sync.Mutex
+}
+
+// Start records the start of an attempt
+func (a *Attempts) Start(attempt int, maxAttempts int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.attempt = attempt
+	a.maxAttempts = maxAttempts
+}
+
+// Get returns the current attempt and the maximum number of attempts. Both are zero if no attempt has started.
+func (a *Attempts) Get() (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.attempt, a.maxAttempts
+}
+
+// WithAttempts returns a context that records the attempts of the executors it is passed to, along with the [Attempts].
+// If the context already records attempts, the existing [Attempts] is used.
+func WithAttempts(ctx context.Context) (context.Context, *Attempts) {
+	if attempts := ContextAttempts(ctx); attempts != nil {
+		return ctx, attempts
+	}
+	attempts := new(Attempts)
+	return context.WithValue(ctx, attemptsContextKeyVal, attempts), attempts
+}
+
+// ContextAttempts returns the [Attempts] recorded by the context, or nil if the context doesn't record attempts
+func ContextAttempts(ctx context.Context) *Attempts {
+	if attempts, ok := ctx.Value(attemptsContextKeyVal).(*Attempts); ok {
+		return attempts
+	}
+	return nil
+}
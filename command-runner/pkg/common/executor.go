@@ -24,6 +24,74 @@ func (w Warning) Error() string {
 // ErrDefer that implements `error` but safe to ignore.
 var ErrDefer = errors.New("deferred")
 
+// TimeoutError is returned when an executor doesn't finish within its timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error the contract for error
+func (te *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", te.Timeout)
+}
+
+// NewTimeoutContext returns a context that is cancelled with a [TimeoutError] as its cause once the timeout elapses
+func NewTimeoutContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, timeout, &TimeoutError{Timeout: timeout})
+}
+
+// TimedOut returns the [TimeoutError] that caused the context to be cancelled, or nil if the context didn't time out
+func TimedOut(ctx context.Context) *TimeoutError {
+	var timeoutError *TimeoutError
+	if errors.As(context.Cause(ctx), &timeoutError) {
+		return timeoutError
+	}
+	return nil
+}
+
+// CancelledError is the cause of a context that was cancelled because another executor failed
+type CancelledError struct {
+	Reason string
+}
+
+// Error the contract for error
+func (ce *CancelledError) Error() string {
+	return fmt.Sprintf("cancelled because %s", ce.Reason)
+}
+
+// Cancelled returns the [CancelledError] that caused the context to be cancelled, or nil if the context wasn't cancelled by one
+func Cancelled(ctx context.Context) *CancelledError {
+	var cancelledError *CancelledError
+	if errors.As(context.Cause(ctx), &cancelledError) {
+		return cancelledError
+	}
+	return nil
+}
+
+// ExitCodeError is returned when a command exits with a non-zero exit code
+type ExitCodeError struct {
+	Code    int
+	Message string
+}
+
+// Error the contract for error
+func (ee *ExitCodeError) Error() string {
+	return fmt.Sprintf("exitcode '%d': %s", ee.Code, ee.Message)
+}
+
+// ExitCode returns the exit code of the command
+func (ee *ExitCodeError) ExitCode() int {
+	return ee.Code
+}
+
+// ExitCode returns the exit code of the command that caused the error, if any
+func ExitCode(err error) (int, bool) {
+	var exitCoder interface{ ExitCode() int }
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode(), true
+	}
+	return 0, false
+}
+
 // Executor define contract for the steps of a workflow
 type Executor func(ctx context.Context) error
 
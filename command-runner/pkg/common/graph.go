@@ -0,0 +1,126 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GraphNode is an [Executor] that participates in a dependency graph
+type GraphNode struct {
+	ID        string          // unique ID of the node
+	Executor  Executor        // executor to run once all dependencies have finished
+	DependsOn func() []string // returns the IDs of the nodes that must finish before this node is run
+}
+
+type graphResult struct {
+	node *GraphNode
+	err  error
+}
+
+// NewGraphExecutor creates a new executor that runs each node as soon as every node it depends on has finished.
+// At most parallel nodes are run at a time. Dependencies on IDs that are not part of the graph are ignored.
+//
+// If a node returns [ErrDefer], its dependencies are evaluated again and the node is dispatched once
+// the newly added dependencies have finished.
+//
+// Once the context is cancelled no more nodes are dispatched. The executor waits for the running nodes to stop
+// and returns the cause of the cancellation.
+func NewGraphExecutor(parallel int, nodes ...*GraphNode) Executor {
+	return func(ctx context.Context) error {
+		if parallel < 1 {
+			parallel = 1
+		}
+		known := make(map[string]bool, len(nodes))
+		for _, node := range nodes {
+			known[node.ID] = true
+		}
+		finished := make(map[string]bool, len(nodes))
+		pendingDependencies := func(node *GraphNode) []string {
+			pending := make([]string, 0)
+			if node.DependsOn == nil {
+				return pending
+			}
+			for _, dependency := range node.DependsOn() {
+				if dependency != node.ID && known[dependency] && !finished[dependency] && !slices.Contains(pending, dependency) {
+					pending = append(pending, dependency)
+				}
+			}
+			return pending
+		}
+
+		waiting := slices.Clone(nodes)
+		ready := make([]*GraphNode, 0)
+		promote := func() {
+			stillWaiting := make([]*GraphNode, 0, len(waiting))
+			for _, node := range waiting {
+				if len(pendingDependencies(node)) == 0 {
+					ready = append(ready, node)
+				} else {
+					stillWaiting = append(stillWaiting, node)
+				}
+			}
+			waiting = stillWaiting
+		}
+		promote()
+
+		results := make(chan graphResult, len(nodes))
+		running := 0
+		var rtnError error
+		for {
+			for ctx.Err() == nil && running < parallel && len(ready) > 0 {
+				node := ready[0]
+				ready = ready[1:]
+				running++
+				log.Ctx(ctx).Debug().Msgf("Graph executor dispatching %s", node.ID)
+				go func(node *GraphNode) {
+					results <- graphResult{node: node, err: node.Executor(ctx)}
+				}(node)
+			}
+			if running == 0 {
+				if ctx.Err() != nil {
+					// the nodes that were running have stopped, report why the graph was cancelled
+					return context.Cause(ctx)
+				}
+				if len(waiting) > 0 {
+					blocked := make([]string, 0, len(waiting))
+					for _, node := range waiting {
+						blocked = append(blocked, fmt.Sprintf("%s (waiting for %s)", node.ID, strings.Join(pendingDependencies(node), ", ")))
+					}
+					rtnError = errors.Join(rtnError, fmt.Errorf("unable to schedule %s", strings.Join(blocked, "; ")))
+				}
+				break
+			}
+
+			result := <-results
+			running--
+			if errors.Is(result.err, ErrDefer) {
+				if pending := pendingDependencies(result.node); len(pending) > 0 {
+					log.Ctx(ctx).Debug().Msgf("Graph executor deferring %s until %s finish", result.node.ID, strings.Join(pending, ", "))
+					waiting = append(waiting, result.node)
+				} else {
+					rtnError = errors.Join(rtnError, fmt.Errorf("%s deferred without any unfinished dependencies", result.node.ID))
+					finished[result.node.ID] = true
+					promote()
+				}
+				continue
+			}
+			finished[result.node.ID] = true
+			switch result.err.(type) {
+			case nil:
+			case Warning:
+				log.Ctx(ctx).Debug().Err(result.err).Msg("Got warning")
+			default:
+				rtnError = errors.Join(rtnError, result.err)
+			}
+			promote()
+		}
+		log.Ctx(ctx).Debug().Err(rtnError).Msg("Graph executor finished")
+
+		return rtnError
+	}
+}
@@ -0,0 +1,193 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGraphExecutor(t *testing.T) {
+	type TestParams struct {
+		TestCase      string
+		Parallel      int
+		DependsOn     map[string][]string
+		Errors        map[string]error
+		ExpectedOrder []string
+		ExpectedError string
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:      "no-dependencies",
+			Parallel:      1,
+			DependsOn:     map[string][]string{"a": nil, "b": nil, "c": nil},
+			ExpectedOrder: []string{"a", "b", "c"},
+		},
+		{
+			TestCase:      "chain",
+			Parallel:      4,
+			DependsOn:     map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil},
+			ExpectedOrder: []string{"c", "b", "a"},
+		},
+		{
+			TestCase:      "unknown-dependency",
+			Parallel:      1,
+			DependsOn:     map[string][]string{"a": {"missing"}, "b": nil},
+			ExpectedOrder: []string{"a", "b"},
+		},
+		{
+			TestCase:      "failed-dependency",
+			Parallel:      1,
+			DependsOn:     map[string][]string{"a": {"b"}, "b": nil},
+			Errors:        map[string]error{"b": fmt.Errorf("fake error")},
+			ExpectedOrder: []string{"b", "a"},
+			ExpectedError: "fake error",
+		},
+		{
+			TestCase:      "warning",
+			Parallel:      1,
+			DependsOn:     map[string][]string{"a": nil},
+			Errors:        map[string]error{"a": NewWarning("fake warning")},
+			ExpectedOrder: []string{"a"},
+		},
+		{
+			TestCase:      "cycle",
+			Parallel:      1,
+			DependsOn:     map[string][]string{"a": {"b"}, "b": {"a"}},
+			ExpectedOrder: []string{},
+			ExpectedError: "unable to schedule a (waiting for b); b (waiting for a)",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx := context.Background()
+
+			order := make([]string, 0)
+			var mu sync.Mutex
+			nodes := make([]*GraphNode, 0)
+			for _, id := range []string{"a", "b", "c"} {
+				if _, ok := tt.DependsOn[id]; !ok {
+					continue
+				}
+				id := id
+				nodes = append(nodes, &GraphNode{
+					ID: id,
+					Executor: func(ctx context.Context) error {
+						mu.Lock()
+						defer mu.Unlock()
+						order = append(order, id)
+						return tt.Errors[id]
+					},
+					DependsOn: func() []string {
+						return tt.DependsOn[id]
+					},
+				})
+			}
+			err := NewGraphExecutor(tt.Parallel, nodes...)(ctx)
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tt.ExpectedOrder, order)
+		})
+	}
+}
+
+func TestNewGraphExecutorParallel(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	activeCount := 0
+	maxCount := 0
+	executor := func(ctx context.Context) error {
+		mu.Lock()
+		activeCount++
+		if activeCount > maxCount {
+			maxCount = activeCount
+		}
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		activeCount--
+		mu.Unlock()
+		return nil
+	}
+
+	err := NewGraphExecutor(2,
+		&GraphNode{ID: "a", Executor: executor},
+		&GraphNode{ID: "b", Executor: executor},
+		&GraphNode{ID: "c", Executor: executor},
+	)(ctx)
+
+	assert.NoError(err)
+	assert.Equal(2, maxCount, "should run at most 2 executors in parallel")
+}
+
+func TestNewGraphExecutorDefer(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+
+	order := make([]string, 0)
+	dependsOn := make([]string, 0)
+	err := NewGraphExecutor(2,
+		&GraphNode{
+			ID: "a",
+			Executor: func(ctx context.Context) error {
+				if len(dependsOn) == 0 {
+					dependsOn = append(dependsOn, "b")
+					return ErrDefer
+				}
+				order = append(order, "a")
+				return nil
+			},
+			DependsOn: func() []string {
+				return dependsOn
+			},
+		},
+		&GraphNode{
+			ID: "b",
+			Executor: func(ctx context.Context) error {
+				time.Sleep(100 * time.Millisecond)
+				order = append(order, "b")
+				return nil
+			},
+		},
+	)(ctx)
+
+	assert.NoError(err)
+	assert.Equal([]string{"b", "a"}, order)
+
+	err = NewGraphExecutor(1, &GraphNode{
+		ID: "a",
+		Executor: func(ctx context.Context) error {
+			return ErrDefer
+		},
+	})(ctx)
+	assert.EqualError(err, "a deferred without any unfinished dependencies")
+}
+
+func TestNewGraphExecutorCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	count := 0
+	err := NewGraphExecutor(2, &GraphNode{
+		ID: "a",
+		Executor: func(ctx context.Context) error {
+			count++
+			return nil
+		},
+	})(ctx)
+	assert.Equal(0, count)
+	assert.ErrorIs(err, context.Canceled)
+}
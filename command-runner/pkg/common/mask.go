@@ -0,0 +1,100 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaskedValue replaces the masked values in the logs
+const MaskedValue = "***"
+
+var masks = new(masker)
+
+// AddMask registers a value, such as the value of a secret, to be replaced with [MaskedValue] by [Mask]. Each line of a
+// multi-line value is masked on its own, since the output of commands is logged line by line.
+func AddMask(value string) {
+	masks.add(value)
+}
+
+// Mask replaces every value registered with [AddMask] in s with [MaskedValue]
+func Mask(s string) string {
+	return masks.mask(s)
+}
+
+// NewMaskWriter returns a writer that masks the values registered with [AddMask] in each write to w. Values that
+// span several writes aren't masked, so each write should be a whole line or log entry.
+func NewMaskWriter(w io.Writer) io.Writer {
+	return &maskWriter{out: w}
+}
+
+type maskWriter struct {
+	out io.Writer
+}
+
+func (mw *maskWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(mw.out, Mask(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type masker struct {
+	values   map[string]bool
+	replacer *strings.Replacer
+	mu       sync.RWMutex
+}
+
+func (m *masker) add(value string) {
+	values := []string{value}
+	if strings.ContainsAny(value, "\r\n") {
+		values = append(values, strings.FieldsFunc(value, func(r rune) bool { return r == '\r' || r == '\n' })...)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.values == nil {
+		m.values = make(map[string]bool)
+	}
+	changed := false
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" || m.values[value] {
+			continue
+		}
+		m.values[value] = true
+		// values are also masked as they appear in JSON, e.g. in the events
+		if escaped, err := json.Marshal(value); err == nil {
+			m.values[string(escaped[1:len(escaped)-1])] = true
+		}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	// longer values are replaced first, so that a value containing another one is masked whole
+	sorted := make([]string, 0, len(m.values))
+	for value := range m.values {
+		sorted = append(sorted, value)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	oldnew := make([]string, 0, len(sorted)*2)
+	for _, value := range sorted {
+		oldnew = append(oldnew, value, MaskedValue)
+	}
+	m.replacer = strings.NewReplacer(oldnew...)
+}
+
+func (m *masker) mask(s string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.replacer == nil {
+		return s
+	}
+	return m.replacer.Replace(s)
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMask(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("nothing to mask", Mask("nothing to mask"))
+	AddMask("")
+	AddMask("  ")
+	assert.Equal("nothing to mask", Mask("nothing to mask"))
+
+	AddMask("mask-test-token")
+	AddMask("mask-test-token-longer")
+	assert.Equal("token=*** other=***", Mask("token=mask-test-token other=mask-test-token-longer"))
+
+	// each line of a multi-line value is masked, as is its JSON form
+	AddMask("mask-test-line1\nmask-test-\"line2\"")
+	assert.Equal("***", Mask("mask-test-line1"))
+	assert.Equal("***", Mask(`mask-test-"line2"`))
+	event, err := json.Marshal(map[string]string{"message": "mask-test-line1\nmask-test-\"line2\""})
+	assert.NoError(err)
+	assert.Equal(`{"message":"***"}`, Mask(string(event)))
+
+	out := new(bytes.Buffer)
+	w := NewMaskWriter(out)
+	n, err := fmt.Fprint(w, "echo mask-test-token")
+	assert.NoError(err)
+	assert.Equal(len("echo mask-test-token"), n)
+	assert.Equal("echo ***", out.String())
+}
@@ -2,8 +2,10 @@ package features
 
 import (
 	"context"
+	"io"
 	"os"
 
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
 
 	"github.com/rs/zerolog"
@@ -15,7 +17,7 @@ func ConsoleLogger() runner.Feature {
 	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
 		log.Ctx(ctx).Debug().Msg("ENTER ConsoleLogger")
 		ctx = log.Logger.
-			Output(zerolog.ConsoleWriter{Out: os.Stdout}).
+			Output(NewConsoleWriter(os.Stdout)).
 			With().
 			Str("id", plan.ID()).
 			Logger().
@@ -25,3 +27,12 @@ func ConsoleLogger() runner.Feature {
 		return err
 	}
 }
+
+// NewConsoleWriter creates a zerolog writer for humans. The stream and group fields that tag the output of commands are
+// hidden, and masked values are replaced.
+func NewConsoleWriter(out io.Writer) zerolog.ConsoleWriter {
+	return zerolog.ConsoleWriter{
+		Out:           common.NewMaskWriter(out),
+		FieldsExclude: []string{"stream", "group"},
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"slices"
-	"strings"
 	"sync"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
@@ -19,16 +18,18 @@ type ProgressHandle interface {
 	Success()                                  // success is called when the handle completes successfully
 	Failure(err error)                         // failure is called when the handle completes unsuccessfully
 	IsReady(dependsOn ...string) (bool, error) // isReady is called to determine if the handle is ready
+	ContinueOnFailure() bool                   // continueOnFailure is true if the plans that depend on the handle run even when it fails
 }
 
-// DependsOn waits for dependencies
+// DependsOn waits for dependencies. A plan is skipped if any of its dependencies failed.
+// If the plan fails and its [ProgressHandle] continues on failure, the failure is returned as a [common.Warning].
 func DependsOn(progressHandle ProgressHandle) runner.Feature {
 	logged := make([]string, 0)
 	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
 		log.Ctx(ctx).Debug().Msg("ENTER DependsOn")
 		for _, dependsOn := range plan.DependsOn() {
 			if ready, err := progressHandle.IsReady(dependsOn); err != nil {
-				log.Ctx(ctx).Err(err).Msgf("❌ aborted while waiting for %s", dependsOn)
+				log.Ctx(ctx).Warn().Msgf("⏭️  SKIPPED because %s failed", dependsOn)
 				progressHandle.Failure(err)
 				return err
 			} else if !ready {
@@ -43,6 +44,10 @@ func DependsOn(progressHandle ProgressHandle) runner.Feature {
 		if err != nil {
 			if !errors.Is(err, common.ErrDefer) {
 				progressHandle.Failure(err)
+				if progressHandle.ContinueOnFailure() {
+					log.Ctx(ctx).Warn().Msg("continuing with the actions that depend on it")
+					return common.NewWarning("%s failed, continuing: %s", plan.ID(), err.Error())
+				}
 			}
 			return err
 		}
@@ -54,9 +59,19 @@ func DependsOn(progressHandle ProgressHandle) runner.Feature {
 
 // PlanTracker provides [ProgressHandle] for each plan and tracks progress across all plans
 type PlanTracker struct {
-	pending []string
-	failed  []string
-	mu      sync.Mutex
+	ContinueOnFailure []string // IDs of the plans whose failure doesn't skip the plans that depend on them
+	pending           []string
+	failed            []string
+	continued         []error
+	mu                sync.Mutex
+}
+
+// Err returns the failures of the plans that continued on failure, or nil if none of them failed. Their failures were
+// returned as a [common.Warning] so the plans that depend on them could run, and are reported once all plans ran.
+func (pt *PlanTracker) Err() error {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return errors.Join(pt.continued...)
 }
 
 type progressHandle struct {
@@ -84,10 +99,15 @@ func (ph *progressHandle) Success() {
 	}
 	ph.pt.pending = newPending
 }
-func (ph *progressHandle) Failure(_ error) {
+func (ph *progressHandle) Failure(err error) {
 	ph.pt.mu.Lock()
 	defer ph.pt.mu.Unlock()
-	ph.pt.failed = append(ph.pt.failed, ph.planID)
+	// a skipped plan produced no outputs, so the plans that depend on it are skipped too
+	if _, skipped := err.(common.Warning); skipped || !slices.Contains(ph.pt.ContinueOnFailure, ph.planID) {
+		ph.pt.failed = append(ph.pt.failed, ph.planID)
+	} else {
+		ph.pt.continued = append(ph.pt.continued, fmt.Errorf("%s failed: %w", ph.planID, err))
+	}
 	newPending := make([]string, 0)
 	for _, p := range ph.pt.pending {
 		if p != ph.planID {
@@ -98,19 +118,14 @@ func (ph *progressHandle) Failure(_ error) {
 }
 func (ph *progressHandle) IsReady(dependsOn ...string) (bool, error) {
 	ready := true
-	var group string
-	if strings.Contains(ph.planID, "@") {
-		parts := strings.Split(ph.planID, "@")
-		group = parts[0]
-	}
 	for _, dependency := range dependsOn {
 		for _, f := range ph.pt.failed {
-			if f == dependency || f == fmt.Sprintf("%s@%s", group, dependency) || strings.HasPrefix(f, fmt.Sprintf("%s@", dependency)) {
-				return false, common.NewWarning("cancelled %s: dependency %s failed", ph.planID, dependency)
+			if runner.MatchesDependency(ph.planID, dependency, f) {
+				return false, common.NewWarning("skipped %s: dependency %s failed", ph.planID, dependency)
 			}
 		}
 		for _, p := range ph.pt.pending {
-			if p == dependency || p == fmt.Sprintf("%s@%s", group, dependency) || strings.HasPrefix(p, fmt.Sprintf("%s@", dependency)) {
+			if runner.MatchesDependency(ph.planID, dependency, p) {
 				ready = false
 				log.Debug().Msgf("DEFER [%s] for dependency [%s]", ph.planID, p)
 				break
@@ -119,3 +134,6 @@ func (ph *progressHandle) IsReady(dependsOn ...string) (bool, error) {
 	}
 	return ready, nil
 }
+func (ph *progressHandle) ContinueOnFailure() bool {
+	return slices.Contains(ph.pt.ContinueOnFailure, ph.planID)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
@@ -17,8 +18,10 @@ func TestDependsOn(t *testing.T) {
 		SuccessDependsOn []string
 		FailureDependsOn []string
 		PendingDependsOn []string
+		Continue         []string
 		Error            string
 		ExpectedError    string
+		ExpectedRunError string // error of the plan tracker once all plans ran
 	}{
 		{
 			TestCase: "no-dependencies",
@@ -30,7 +33,13 @@ func TestDependsOn(t *testing.T) {
 		{
 			TestCase:         "failed-dependencies",
 			FailureDependsOn: []string{"failed-id"},
-			ExpectedError:    "cancelled failed-dependencies: dependency failed-id failed",
+			ExpectedError:    "skipped failed-dependencies: dependency failed-id failed",
+		},
+		{
+			TestCase:         "continued-dependencies",
+			FailureDependsOn: []string{"continued-id"},
+			Continue:         []string{"continued-id"},
+			ExpectedRunError: "continued-id failed: failed",
 		},
 		{
 			TestCase:         "pending-dependencies",
@@ -42,10 +51,17 @@ func TestDependsOn(t *testing.T) {
 			Error:         "mock error",
 			ExpectedError: "mock error",
 		},
+		{
+			TestCase:         "handle-error-continue",
+			Continue:         []string{"handle-error-continue"},
+			Error:            "mock error",
+			ExpectedError:    "handle-error-continue failed, continuing: mock error",
+			ExpectedRunError: "handle-error-continue failed: mock error",
+		},
 	} {
 		t.Run(tt.TestCase, func(t *testing.T) {
 			assert := assert.New(t)
-			planTracker := new(PlanTracker)
+			planTracker := &PlanTracker{ContinueOnFailure: tt.Continue}
 
 			// setup the code under test
 			ctx := context.Background()
@@ -70,8 +86,12 @@ func TestDependsOn(t *testing.T) {
 			m := new(runner.MockPlanExecutor)
 			m.WithPlan(mockPlan)
 
-			// only mock 'Execute()' if all dependencies are met
-			if len(tt.FailureDependsOn)+len(tt.PendingDependsOn) == 0 {
+			// only mock 'Execute()' if all dependencies are met, failed dependencies that continue on failure count as met
+			ready := len(tt.PendingDependsOn) == 0
+			for _, id := range tt.FailureDependsOn {
+				ready = ready && slices.Contains(tt.Continue, id)
+			}
+			if ready {
 				var returnErr error
 				if tt.Error != "" {
 					returnErr = errors.New(tt.Error)
@@ -88,6 +108,11 @@ func TestDependsOn(t *testing.T) {
 			} else {
 				assert.NoError(err, "DependsOn should not return an error")
 			}
+			if tt.ExpectedRunError != "" {
+				assert.EqualError(planTracker.Err(), tt.ExpectedRunError, "PlanTracker should return the continued failures")
+			} else {
+				assert.NoError(planTracker.Err(), "PlanTracker should not return an error")
+			}
 			m.AssertExpectations(t)
 		})
 	}
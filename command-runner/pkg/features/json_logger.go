@@ -0,0 +1,148 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Statuses of a plan in the [runner.EventPlanFinished] event, and of a run in the [runner.EventRunFinished] event
+const (
+	EventStatusSucceeded = "succeeded" // the plan or run succeeded
+	EventStatusFailed    = "failed"    // the plan or run failed
+	EventStatusTimedOut  = "timed_out" // the plan ran for longer than its timeout
+	EventStatusCancelled = "cancelled" // the plan was stopped because the run was cancelled
+	EventStatusSkipped   = "skipped"   // the plan didn't run because a plan it depends on failed
+	EventStatusWarning   = "warning"   // the plan failed, but the plans that depend on it continue
+)
+
+// EventStream writes [runner.Event]s as JSON lines, one event per line
+type EventStream struct {
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewEventStream creates an [EventStream] that writes to w, with masked values replaced
+func NewEventStream(w io.Writer) *EventStream {
+	return &EventStream{
+		encoder: json.NewEncoder(common.NewMaskWriter(w)),
+	}
+}
+
+// Emit writes the event, setting its version and time
+func (es *EventStream) Emit(event *runner.Event) {
+	event.Version = runner.EventSchemaVersion
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if err := es.encoder.Encode(event); err != nil {
+		log.Error().Err(err).Msg("failed to write event")
+	}
+}
+
+// JSONLogger is a Feature to write the progress of a plan and its logs as events to the stream.
+// The plan is queued once the feature is created.
+func JSONLogger(stream *EventStream, planID string) runner.Feature {
+	stream.Emit(&runner.Event{Type: runner.EventPlanQueued, PlanID: planID})
+	sink := &planEventSink{stream: stream, planID: planID}
+	var start time.Time
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		if start.IsZero() {
+			start = time.Now()
+			sink.Emit(&runner.Event{Type: runner.EventPlanStarted})
+		}
+		ctx = runner.WithEventSink(ctx, sink)
+		ctx = log.Logger.Output(&eventLogWriter{sink: sink}).WithContext(ctx)
+		err := e(ctx)
+		if errors.Is(err, common.ErrDefer) {
+			sink.Emit(&runner.Event{Type: runner.EventPlanDeferred})
+			return err
+		}
+		durationMs := time.Since(start).Milliseconds()
+		event := &runner.Event{
+			Type:       runner.EventPlanFinished,
+			Status:     EventStatusSucceeded,
+			DurationMs: &durationMs,
+		}
+		if err != nil {
+			event.Error = err.Error()
+			if _, isWarning := err.(common.Warning); isWarning {
+				event.Status = EventStatusWarning
+				if !sink.commandsRun() {
+					event.Status = EventStatusSkipped
+				}
+			} else if errors.As(err, new(*common.TimeoutError)) {
+				event.Status = EventStatusTimedOut
+			} else if common.Cancelled(ctx) != nil {
+				event.Status = EventStatusCancelled
+			} else {
+				event.Status = EventStatusFailed
+			}
+		}
+		sink.Emit(event)
+		return err
+	}
+}
+
+// planEventSink emits the events of a plan to the stream
+type planEventSink struct {
+	stream   *EventStream
+	planID   string
+	commands bool
+	mu       sync.Mutex
+}
+
+func (pes *planEventSink) Emit(event *runner.Event) {
+	if event.Type == runner.EventCommandStarted {
+		pes.mu.Lock()
+		pes.commands = true
+		pes.mu.Unlock()
+	}
+	event.PlanID = pes.planID
+	pes.stream.Emit(event)
+}
+
+// commandsRun returns true if any command of the plan has started
+func (pes *planEventSink) commandsRun() bool {
+	pes.mu.Lock()
+	defer pes.mu.Unlock()
+	return pes.commands
+}
+
+// eventLogWriter is a zerolog writer that emits each log entry as a [runner.EventLog]
+type eventLogWriter struct {
+	sink runner.EventSink
+}
+
+func (elw *eventLogWriter) Write(p []byte) (int, error) {
+	var entry struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+		Stream  string `json:"stream"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(p, &entry); err != nil {
+		return 0, err
+	}
+	if entry.Stream == "" {
+		entry.Stream = "log"
+	}
+	elw.sink.Emit(&runner.Event{
+		Type:    runner.EventLog,
+		Stream:  entry.Stream,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Error:   entry.Error,
+	})
+	return len(p), nil
+}
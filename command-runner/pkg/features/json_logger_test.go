@@ -0,0 +1,106 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJSONLogger(t *testing.T) {
+	type TestParams struct {
+		TestCase       string
+		Results        []error
+		Commands       bool
+		ExpectedTypes  []runner.EventType
+		ExpectedStatus string
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:       "Success",
+			Results:        []error{nil},
+			Commands:       true,
+			ExpectedTypes:  []runner.EventType{runner.EventPlanQueued, runner.EventPlanStarted, runner.EventCommandStarted, runner.EventLog, runner.EventCommandFinished, runner.EventPlanFinished},
+			ExpectedStatus: EventStatusSucceeded,
+		},
+		{
+			TestCase:       "Deferred then failed",
+			Results:        []error{common.ErrDefer, fmt.Errorf("mock-error")},
+			Commands:       true,
+			ExpectedTypes:  []runner.EventType{runner.EventPlanQueued, runner.EventPlanStarted, runner.EventPlanDeferred, runner.EventCommandStarted, runner.EventLog, runner.EventCommandFinished, runner.EventPlanFinished},
+			ExpectedStatus: EventStatusFailed,
+		},
+		{
+			TestCase:       "Skipped",
+			Results:        []error{common.NewWarning("skipped")},
+			ExpectedTypes:  []runner.EventType{runner.EventPlanQueued, runner.EventPlanStarted, runner.EventPlanFinished},
+			ExpectedStatus: EventStatusSkipped,
+		},
+		{
+			TestCase:       "Continued after failure",
+			Results:        []error{common.NewWarning("failed, continuing")},
+			Commands:       true,
+			ExpectedTypes:  []runner.EventType{runner.EventPlanQueued, runner.EventPlanStarted, runner.EventCommandStarted, runner.EventLog, runner.EventCommandFinished, runner.EventPlanFinished},
+			ExpectedStatus: EventStatusWarning,
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			ctx := context.Background()
+			out := new(bytes.Buffer)
+			feature := JSONLogger(NewEventStream(out), "mock-plan")
+
+			// setup the mock
+			for _, result := range tt.Results {
+				result := result
+				m := new(runner.MockPlanExecutor).WithExecutor(func(ctx context.Context) error {
+					if tt.Commands && result != common.ErrDefer {
+						runner.EmitEvent(ctx, &runner.Event{Type: runner.EventCommandStarted, Command: "echo hello"})
+						log.Ctx(ctx).Info().Str("stream", "stdout").Msg("hello")
+						exitCode := 0
+						runner.EmitEvent(ctx, &runner.Event{Type: runner.EventCommandFinished, Command: "echo hello", ExitCode: &exitCode})
+					}
+					return result
+				})
+				m.OnExecute(mock.Anything).Return(result)
+
+				// run the feature
+				err := m.Execute(ctx, feature)
+				assert.Equal(result, err)
+			}
+
+			// assert the results
+			events := make([]runner.Event, 0)
+			decoder := json.NewDecoder(out)
+			for decoder.More() {
+				var event runner.Event
+				assert.NoError(decoder.Decode(&event))
+				assert.Equal(runner.EventSchemaVersion, event.Version)
+				assert.Equal("mock-plan", event.PlanID)
+				events = append(events, event)
+			}
+			types := make([]runner.EventType, 0)
+			for _, event := range events {
+				types = append(types, event.Type)
+				if event.Type == runner.EventLog {
+					assert.Equal("stdout", event.Stream)
+					assert.Equal("hello", event.Message)
+					assert.Equal("info", event.Level)
+				}
+			}
+			assert.Equal(tt.ExpectedTypes, types)
+			assert.Equal(tt.ExpectedStatus, events[len(events)-1].Status)
+			assert.NotNil(events[len(events)-1].DurationMs)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+)
+
+// JUnitReport collects the result of each plan of a run, to write them as the testcases of a JUnit XML report
+type JUnitReport struct {
+	name      string
+	testCases []*junitTestCase
+	mu        sync.Mutex
+}
+
+// NewJUnitReport creates a [JUnitReport] whose testsuite is named after the run
+func NewJUnitReport(name string) *JUnitReport {
+	return &JUnitReport{
+		name: name,
+	}
+}
+
+type junitTestCase struct {
+	planID   string
+	started  time.Time
+	finished time.Time
+	result   string // one of passed, failure, error or skipped
+	message  string
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+	mu       sync.Mutex
+}
+
+// add registers a plan in the report. A plan that is never run is reported as skipped.
+func (jr *JUnitReport) add(planID string) *junitTestCase {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	for _, tc := range jr.testCases {
+		if tc.planID == planID {
+			return tc
+		}
+	}
+	tc := &junitTestCase{
+		planID:  planID,
+		result:  "skipped",
+		message: "not run",
+	}
+	jr.testCases = append(jr.testCases, tc)
+	return tc
+}
+
+// JUnitRecorder is a Feature to record the result of a plan in the report. It must wrap the features that skip,
+// cancel or time out plans, so that the final error of the plan is recorded.
+func JUnitRecorder(report *JUnitReport, planID string) runner.Feature {
+	tc := report.add(planID)
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER JUnitRecorder")
+		err := e(ctx)
+		if errors.Is(err, common.ErrDefer) {
+			return err
+		}
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		tc.finished = time.Now()
+		tc.message = ""
+		switch _, isWarning := err.(common.Warning); {
+		case err == nil:
+			tc.result = "passed"
+		case isWarning && tc.started.IsZero():
+			// the plan was skipped because a plan it depends on failed
+			tc.result = "skipped"
+			tc.message = err.Error()
+		case errors.As(err, new(*common.TimeoutError)):
+			tc.result = "error"
+			tc.message = err.Error()
+		case common.Cancelled(ctx) != nil:
+			tc.result = "skipped"
+			tc.message = common.Cancelled(ctx).Error()
+		default:
+			tc.result = "failure"
+			tc.message = err.Error()
+		}
+		log.Ctx(ctx).Debug().Msg("EXIT JUnitRecorder")
+		return err
+	}
+}
+
+// JUnitOutputCapturer is a Feature to capture the stdout and stderr of the commands of a plan in the report.
+// It must be wrapped by the features that set the Stdout and Stderr of the plan.
+func JUnitOutputCapturer(report *JUnitReport, planID string) runner.Feature {
+	tc := report.add(planID)
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER JUnitOutputCapturer")
+		tc.mu.Lock()
+		if tc.started.IsZero() {
+			tc.started = time.Now()
+		}
+		tc.mu.Unlock()
+		envCfg := plan.EnvironmentConfiguration()
+		stdout, stderr := envCfg.Stdout, envCfg.Stderr
+		envCfg.Stdout = tc.writer(&tc.stdout, stdout)
+		envCfg.Stderr = tc.writer(&tc.stderr, stderr)
+		err := e(ctx)
+		envCfg.Stdout, envCfg.Stderr = stdout, stderr
+		log.Ctx(ctx).Debug().Msg("EXIT JUnitOutputCapturer")
+		return err
+	}
+}
+
+// writer returns a writer that captures the output in buffer and writes it to out, if any
+func (tc *junitTestCase) writer(buffer *bytes.Buffer, out io.Writer) io.Writer {
+	capture := &lockedWriter{w: buffer, mu: &tc.mu}
+	if out == nil {
+		return capture
+	}
+	return io.MultiWriter(capture, out)
+}
+
+type lockedWriter struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// junitTestSuite is the XML of the report, a single testsuite with a testcase per plan
+type junitTestSuite struct {
+	XMLName   xml.Name           `xml:"testsuite"`
+	Name      string             `xml:"name,attr"`
+	Tests     int                `xml:"tests,attr"`
+	Failures  int                `xml:"failures,attr"`
+	Errors    int                `xml:"errors,attr"`
+	Skipped   int                `xml:"skipped,attr"`
+	Time      string             `xml:"time,attr"`
+	Timestamp string             `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCaseXML `xml:"testcase"`
+}
+
+type junitTestCaseXML struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Write writes the report as JUnit XML, with the testcases in the order the plans were added
+func (jr *JUnitReport) Write(w io.Writer) error {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+	suite := junitTestSuite{
+		Name:      jr.name,
+		Tests:     len(jr.testCases),
+		TestCases: make([]junitTestCaseXML, 0, len(jr.testCases)),
+	}
+	var first, last time.Time
+	for _, tc := range jr.testCases {
+		tc.mu.Lock()
+		var duration time.Duration
+		if !tc.started.IsZero() && !tc.finished.IsZero() {
+			duration = tc.finished.Sub(tc.started)
+			if first.IsZero() || tc.started.Before(first) {
+				first = tc.started
+			}
+			if tc.finished.After(last) {
+				last = tc.finished
+			}
+		}
+		testCase := junitTestCaseXML{
+			Name:      tc.planID,
+			ClassName: jr.name,
+			Time:      junitSeconds(duration),
+			SystemOut: common.Mask(tc.stdout.String()),
+			SystemErr: common.Mask(tc.stderr.String()),
+		}
+		maskedMessage := common.Mask(tc.message)
+		message := &junitMessage{Message: maskedMessage, Text: maskedMessage}
+		switch tc.result {
+		case "failure":
+			testCase.Failure = message
+			suite.Failures++
+		case "error":
+			testCase.Error = message
+			suite.Errors++
+		case "skipped":
+			testCase.Skipped = &junitMessage{Message: maskedMessage}
+			suite.Skipped++
+		}
+		tc.mu.Unlock()
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Time = junitSeconds(last.Sub(first))
+	if !first.IsZero() {
+		suite.Timestamp = first.UTC().Format("2006-01-02T15:04:05")
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteFile writes the report as JUnit XML to path
+func (jr *JUnitReport) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return jr.Write(file)
+}
+
+func junitSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
@@ -0,0 +1,136 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJUnitReport(t *testing.T) {
+	type TestParams struct {
+		TestCase        string
+		Result          error
+		Run             bool
+		Cancel          bool
+		ExpectedFailure string
+		ExpectedError   string
+		ExpectedSkipped string
+		ExpectedStdout  string
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:       "Passed",
+			Run:            true,
+			ExpectedStdout: "hello\n",
+		},
+		{
+			TestCase:        "Failed",
+			Result:          fmt.Errorf("mock-error"),
+			Run:             true,
+			ExpectedFailure: "mock-error",
+			ExpectedStdout:  "hello\n",
+		},
+		{
+			TestCase:       "Timed out",
+			Result:         &common.TimeoutError{},
+			Run:            true,
+			ExpectedError:  (&common.TimeoutError{}).Error(),
+			ExpectedStdout: "hello\n",
+		},
+		{
+			TestCase:        "Skipped",
+			Result:          common.NewWarning("skipped"),
+			ExpectedSkipped: "skipped",
+		},
+		{
+			TestCase:        "Cancelled",
+			Result:          fmt.Errorf("mock-error"),
+			Cancel:          true,
+			ExpectedSkipped: "cancelled because mock-reason",
+		},
+		{
+			TestCase:        "Not run",
+			ExpectedSkipped: "not run",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			ctx := context.Background()
+			if tt.Cancel {
+				var cancel context.CancelCauseFunc
+				ctx, cancel = context.WithCancelCause(ctx)
+				cancel(&common.CancelledError{Reason: "mock-reason"})
+			}
+			report := NewJUnitReport("mock-workflow")
+			recorder := JUnitRecorder(report, "mock-plan")
+			capturer := JUnitOutputCapturer(report, "mock-plan")
+			feature := func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+				return recorder(ctx, plan, func(ctx context.Context) error {
+					if tt.Run {
+						return capturer(ctx, plan, e)
+					}
+					return e(ctx)
+				})
+			}
+
+			// setup the mock
+			plan := new(runner.MockPlan).WithID("mock-plan")
+			plan.EnvironmentConfiguration().Stdout = io.Discard
+			m := new(runner.MockPlanExecutor).WithPlan(plan).WithExecutor(func(ctx context.Context) error {
+				if tt.Run {
+					fmt.Fprintln(plan.EnvironmentConfiguration().Stdout, "hello")
+				}
+				return tt.Result
+			})
+			m.OnExecute(mock.Anything).Return(tt.Result)
+
+			// run the feature
+			if tt.TestCase != "Not run" {
+				err := m.Execute(ctx, feature)
+				assert.Equal(tt.Result, err)
+				m.AssertExpectations(t)
+			}
+
+			// assert the report
+			out := new(bytes.Buffer)
+			assert.NoError(report.Write(out))
+			suite := new(junitTestSuite)
+			assert.NoError(xml.Unmarshal(out.Bytes(), suite))
+			assert.Equal("mock-workflow", suite.Name)
+			assert.Equal(1, suite.Tests)
+			assert.Len(suite.TestCases, 1)
+			testCase := suite.TestCases[0]
+			assert.Equal("mock-plan", testCase.Name)
+			assert.Equal(tt.ExpectedStdout, testCase.SystemOut)
+			if tt.ExpectedFailure != "" {
+				assert.Equal(1, suite.Failures)
+				assert.Equal(tt.ExpectedFailure, testCase.Failure.Message)
+			} else {
+				assert.Nil(testCase.Failure)
+			}
+			if tt.ExpectedError != "" {
+				assert.Equal(1, suite.Errors)
+				assert.Equal(tt.ExpectedError, testCase.Error.Message)
+			} else {
+				assert.Nil(testCase.Error)
+			}
+			if tt.ExpectedSkipped != "" {
+				assert.Equal(1, suite.Skipped)
+				assert.Equal(tt.ExpectedSkipped, testCase.Skipped.Message)
+			} else {
+				assert.Nil(testCase.Skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RetryPolicy describes how a plan that fails is run again
+type RetryPolicy struct {
+	MaxAttempts int           // maximum number of attempts, including the first one
+	Backoff     time.Duration // delay before the second attempt, doubled before each further attempt
+	ExitCodes   []int         // exit codes to retry, any failure is retried if empty
+	LogDir      string        // directory to write the logs of each attempt to, no logs are written if empty
+}
+
+// retryable returns true if the error is a failure that the policy retries
+func (rp *RetryPolicy) retryable(err error) bool {
+	if err == nil || errors.Is(err, common.ErrDefer) {
+		return false
+	}
+	if _, ok := err.(common.Warning); ok {
+		return false
+	}
+	if len(rp.ExitCodes) == 0 {
+		return true
+	}
+	exitCode, ok := common.ExitCode(err)
+	return ok && slices.Contains(rp.ExitCodes, exitCode)
+}
+
+// Retry is a Feature to run a plan again when it fails, up to the maximum attempts of the policy.
+// The runner resumes with the command group that failed, in a new container unless containers are reused.
+// Each attempt is logged with an attempt field and, if the policy has a LogDir, to its own attempt-N.log file.
+func Retry(policy *RetryPolicy) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER Retry")
+		if policy == nil || policy.MaxAttempts <= 1 {
+			err := e(ctx)
+			log.Ctx(ctx).Debug().Msg("EXIT Retry")
+			return err
+		}
+		ctx, attempts := common.WithAttempts(ctx)
+		backoff := policy.Backoff
+		var err error
+		for attempt := 1; ; attempt++ {
+			attempts.Start(attempt, policy.MaxAttempts)
+			attemptCtx, closeLog, logErr := newAttemptContext(ctx, policy.LogDir, attempt)
+			if logErr != nil {
+				log.Ctx(ctx).Warn().Err(logErr).Msgf("unable to write the log of attempt %d", attempt)
+			}
+			err = e(attemptCtx)
+			closeLog()
+			if attempt >= policy.MaxAttempts || !policy.retryable(err) || ctx.Err() != nil {
+				break
+			}
+			log.Ctx(ctx).Warn().Err(err).Msgf("🔁 RETRY attempt %d of %d failed, trying again in %s", attempt, policy.MaxAttempts, backoff)
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			backoff *= 2
+		}
+		log.Ctx(ctx).Debug().Msg("EXIT Retry")
+		return err
+	}
+}
+
+// newAttemptContext returns a context whose logger marks every message with the attempt, and copies them to
+// attempt-N.log in logDir if logDir isn't empty. The returned function closes the log file.
+func newAttemptContext(ctx context.Context, logDir string, attempt int) (context.Context, func(), error) {
+	logger := log.Ctx(ctx).With().Int("attempt", attempt).Logger()
+	closeLog := func() {}
+	if logDir == "" {
+		return logger.WithContext(ctx), closeLog, nil
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return logger.WithContext(ctx), closeLog, err
+	}
+	logFile, err := os.Create(filepath.Join(logDir, fmt.Sprintf("attempt-%d.log", attempt)))
+	if err != nil {
+		return logger.WithContext(ctx), closeLog, err
+	}
+	hook := &attemptLogHook{file: logFile}
+	return logger.Hook(hook).WithContext(ctx), hook.close, nil
+}
+
+// attemptLogHook copies the messages of a logger to the log file of an attempt
+type attemptLogHook struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+func (h *attemptLogHook) Run(_ *zerolog.Event, level zerolog.Level, message string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil || level < zerolog.InfoLevel {
+		return
+	}
+	fmt.Fprintln(h.file, common.Mask(message))
+}
+
+func (h *attemptLogHook) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+}
@@ -0,0 +1,131 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetry(t *testing.T) {
+	type TestParams struct {
+		TestCase         string
+		Policy           *RetryPolicy
+		Errors           []error
+		ExpectedAttempts int
+		ExpectedError    string
+	}
+
+	for _, tt := range []TestParams{
+		{
+			TestCase:         "no policy",
+			Errors:           []error{fmt.Errorf("mock-error")},
+			ExpectedAttempts: 1,
+			ExpectedError:    "mock-error",
+		},
+		{
+			TestCase:         "succeeds on retry",
+			Policy:           &RetryPolicy{MaxAttempts: 3},
+			Errors:           []error{fmt.Errorf("mock-error"), nil},
+			ExpectedAttempts: 2,
+		},
+		{
+			TestCase:         "fails every attempt",
+			Policy:           &RetryPolicy{MaxAttempts: 3},
+			Errors:           []error{fmt.Errorf("mock-error-1"), fmt.Errorf("mock-error-2"), fmt.Errorf("mock-error-3")},
+			ExpectedAttempts: 3,
+			ExpectedError:    "mock-error-3",
+		},
+		{
+			TestCase:         "retries exit code",
+			Policy:           &RetryPolicy{MaxAttempts: 3, ExitCodes: []int{137}},
+			Errors:           []error{&common.ExitCodeError{Code: 137, Message: "failure"}, nil},
+			ExpectedAttempts: 2,
+		},
+		{
+			TestCase:         "ignores other exit codes",
+			Policy:           &RetryPolicy{MaxAttempts: 3, ExitCodes: []int{137}},
+			Errors:           []error{&common.ExitCodeError{Code: 1, Message: "failure"}},
+			ExpectedAttempts: 1,
+			ExpectedError:    "exitcode '1': failure",
+		},
+		{
+			TestCase:         "ignores warnings",
+			Policy:           &RetryPolicy{MaxAttempts: 3},
+			Errors:           []error{common.NewWarning("mock-warning")},
+			ExpectedAttempts: 1,
+			ExpectedError:    "mock-warning",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// setup the code under test
+			ctx, attempts := common.WithAttempts(context.Background())
+			feature := Retry(tt.Policy)
+
+			// setup the mock
+			calls := 0
+			m := new(runner.MockPlanExecutor).WithExecutor(func(ctx context.Context) error {
+				calls++
+				return tt.Errors[calls-1]
+			})
+			m.OnExecute(mock.Anything).Return(nil)
+
+			// run the feature
+			err := m.Execute(ctx, feature)
+
+			// assert the results
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(tt.ExpectedAttempts, calls)
+			if tt.Policy != nil {
+				attempt, maxAttempts := attempts.Get()
+				assert.Equal(tt.ExpectedAttempts, attempt)
+				assert.Equal(tt.Policy.MaxAttempts, maxAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryLogs(t *testing.T) {
+	assert := assert.New(t)
+
+	// setup the code under test
+	logDir := t.TempDir()
+	ctx := zerolog.New(nil).WithContext(context.Background())
+	feature := Retry(&RetryPolicy{MaxAttempts: 2, LogDir: logDir})
+
+	// setup the mock
+	m := new(runner.MockPlanExecutor).WithExecutor(func(ctx context.Context) error {
+		attempt, _ := common.ContextAttempts(ctx).Get()
+		zerolog.Ctx(ctx).Info().Msgf("output of attempt %d", attempt)
+		if attempt == 1 {
+			return fmt.Errorf("mock-error")
+		}
+		return nil
+	})
+	m.OnExecute(mock.Anything).Return(nil)
+
+	// run the feature
+	err := m.Execute(ctx, feature)
+
+	// assert the results
+	assert.NoError(err)
+	for _, attempt := range []int{1, 2} {
+		content, err := os.ReadFile(filepath.Join(logDir, fmt.Sprintf("attempt-%d.log", attempt)))
+		assert.NoError(err)
+		assert.Equal(fmt.Sprintf("output of attempt %d\n", attempt), string(content))
+	}
+}
@@ -3,6 +3,10 @@ package features
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -14,75 +18,286 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-const maxSBOMSize = 10 * 1024 // 10 KB
+// SBOMsDetector is an [ExecutionFeature] to detect SBOMs created by actions.
+// The detected SBOMs, with their components, are appended to the sboms provided.
+func SBOMsDetector(directory string, sboms *[]SBOM) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER SBOMsDetector")
+		if err := e(ctx); err != nil {
+			return err
+		}
+		err := newSBOMDetector(directory, sboms)(ctx)
+		log.Ctx(ctx).Debug().Msg("EXIT SBOMsDetector")
+		return err
+	}
+}
 
 // SBOMDetector is an [ExecutionFeature] to detect SBOMs created by actions.
-// The content of the detected SBOM is loaded into the sbom provided.
+// The content of the last detected SPDX SBOM is loaded into the sbom provided.
+//
+// Deprecated: Use [SBOMsDetector], which detects every SPDX and CycloneDX SBOM along with its components.
 func SBOMDetector(directory string, sbom *SBOM) runner.Feature {
 	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
-		log.Ctx(ctx).Debug().Msg("ENTER SBOMDetector")
-		if err := e(ctx); err != nil {
+		sboms := make([]SBOM, 0)
+		if err := SBOMsDetector(directory, &sboms)(ctx, plan, e); err != nil {
 			return err
 		}
-		err := newSBOMDetector(directory, sbom)(ctx)
-		log.Ctx(ctx).Debug().Msg("EXIT SBOMDetector")
-		return err
+		for _, detected := range sboms {
+			if detected.Type != SBOMTypeSPDX {
+				continue
+			}
+			content, err := os.ReadFile(detected.Path)
+			if err != nil {
+				log.Ctx(ctx).Warn().Msgf("Unable to read SBOM '%s': %s", detected.Path, err.Error())
+				continue
+			}
+			*sbom = detected
+			sbom.Content = content
+		}
+		return nil
 	}
 }
 
-func newSBOMDetector(reportDir string, sbom *SBOM) common.Executor {
+func newSBOMDetector(reportDir string, sboms *[]SBOM) common.Executor {
 	return func(ctx context.Context) error {
 		return filepath.WalkDir(reportDir, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
 				return err
 			}
-			if d.IsDir() {
+			if !d.Type().IsRegular() {
 				return nil
 			}
-			info, err := d.Info()
+			sbom, err := readSBOM(path)
 			if err != nil {
-				return err
-			}
-			if info.Size() > maxSBOMSize {
-				log.Ctx(ctx).Debug().Msgf("Skipping potential SBOM '%s'- too large. %d > %d, path", path, info.Size(), maxSBOMSize)
+				log.Ctx(ctx).Warn().Msgf("Unable to read potential SBOM '%s': %s", path, err.Error())
 				return nil
 			}
+			if sbom != nil {
+				log.Ctx(ctx).Debug().Msgf("Found SBOM '%s' with type %s and %d components", path, sbom.Type, len(sbom.Components))
+				*sboms = append(*sboms, *sbom)
+			}
+			return nil
+		})
+	}
+}
 
-			content, err := os.ReadFile(path)
-			if err != nil {
-				log.Ctx(ctx).Warn().Msgf("Unable to read potential SBOM '%s': %s", path, err.Error())
-				return nil
+// readSBOM reads the SBOM at path, or returns nil if the file isn't an SBOM. The file is decoded as a stream so that
+// files of any size can be considered, and files that aren't JSON or XML are rejected from their first bytes.
+func readSBOM(path string) (*SBOM, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if sbom, err := decodeJSONSBOM(file); err == nil {
+		if sbom != nil {
+			sbom.Path = path
+		}
+		return sbom, nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if sbom, err := decodeXMLSBOM(file); err == nil {
+		if sbom != nil {
+			sbom.Path = path
+		}
+		return sbom, nil
+	}
+	return nil, nil
+}
+
+// decodeJSONSBOM decodes an SPDX or CycloneDX JSON document, skipping the values of the keys that aren't needed
+func decodeJSONSBOM(reader io.Reader) (*SBOM, error) {
+	decoder := json.NewDecoder(reader)
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+	var sbomType SBOMType
+	var components []SBOMComponent
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		switch {
+		case strings.EqualFold(key, "spdxVersion") || strings.EqualFold(key, "SPDXID"):
+			sbomType = SBOMTypeSPDX
+			err = skipJSONValue(decoder)
+		case key == "bomFormat":
+			var bomFormat string
+			if err = decoder.Decode(&bomFormat); err == nil && bomFormat == "CycloneDX" {
+				sbomType = SBOMTypeCycloneDX
 			}
+		case key == "packages" || key == "components":
+			components, err = decodeJSONComponents(decoder, key == "packages")
+		default:
+			err = skipJSONValue(decoder)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if sbomType == "" {
+		return nil, nil
+	}
+	return &SBOM{
+		Type:       sbomType,
+		Components: components,
+	}, nil
+}
 
-			data := make(map[string]interface{})
-			err = json.Unmarshal(content, &data)
-			if err != nil {
-				log.Ctx(ctx).Debug().Msgf("Unable to unmarshal potential SBOM '%s': %s", path, err.Error())
-				return nil
+// decodeJSONComponents decodes the array of SPDX packages or CycloneDX components one element at a time
+func decodeJSONComponents(decoder *json.Decoder, spdx bool) ([]SBOMComponent, error) {
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('[') {
+		return nil, fmt.Errorf("components are not an array")
+	}
+	components := make([]SBOMComponent, 0)
+	for decoder.More() {
+		if spdx {
+			var pkg struct {
+				Name         string `json:"name"`
+				VersionInfo  string `json:"versionInfo"`
+				ExternalRefs []struct {
+					ReferenceType    string `json:"referenceType"`
+					ReferenceLocator string `json:"referenceLocator"`
+				} `json:"externalRefs"`
 			}
-			for key := range data {
-				if strings.EqualFold(key, "spdxVersion") || strings.EqualFold(key, "SPDXID") {
-					log.Ctx(ctx).Debug().Msgf("Found SBOM '%s' with type %s", path, sbom.Type)
-					sbom.Content = content
-					sbom.Type = SBOMTypeSPDX
-					break
+			if err := decoder.Decode(&pkg); err != nil {
+				return nil, err
+			}
+			component := SBOMComponent{Name: pkg.Name, Version: pkg.VersionInfo}
+			for _, ref := range pkg.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					component.PURL = ref.ReferenceLocator
 				}
 			}
+			components = append(components, component)
+		} else {
+			var component cyclonedxComponent
+			if err := decoder.Decode(&component); err != nil {
+				return nil, err
+			}
+			components = component.flatten(components)
+		}
+	}
+	_, err := decoder.Token()
+	return components, err
+}
+
+// skipJSONValue skips the next value of the decoder without holding it in memory
+func skipJSONValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
 			return nil
-		})
+		}
 	}
 }
 
-// SBOMType - Currently only SPDX is supported
+// decodeXMLSBOM decodes a CycloneDX XML document
+func decodeXMLSBOM(reader io.Reader) (*SBOM, error) {
+	decoder := xml.NewDecoder(reader)
+	depth := 0
+	var sbom *SBOM
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return sbom, nil
+		} else if err != nil {
+			return nil, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				if element.Name.Local != "bom" || !strings.HasPrefix(element.Name.Space, "http://cyclonedx.org/schema/bom") {
+					return nil, nil
+				}
+				sbom = &SBOM{Type: SBOMTypeCycloneDX}
+			} else if depth == 2 && element.Name.Local == "components" {
+				var components struct {
+					Components []cyclonedxComponent `xml:"component"`
+				}
+				if err := decoder.DecodeElement(&components, &element); err != nil {
+					return nil, err
+				}
+				depth--
+				for _, component := range components.Components {
+					sbom.Components = component.flatten(sbom.Components)
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+type cyclonedxComponent struct {
+	Type       string               `json:"type" xml:"type,attr"`
+	Name       string               `json:"name" xml:"name"`
+	Version    string               `json:"version" xml:"version"`
+	PURL       string               `json:"purl" xml:"purl"`
+	Components []cyclonedxComponent `json:"components" xml:"components>component"`
+}
+
+// flatten appends the component and its nested components to components
+func (cc *cyclonedxComponent) flatten(components []SBOMComponent) []SBOMComponent {
+	components = append(components, SBOMComponent{
+		Type:    cc.Type,
+		Name:    cc.Name,
+		Version: cc.Version,
+		PURL:    cc.PURL,
+	})
+	for _, nested := range cc.Components {
+		components = nested.flatten(components)
+	}
+	return components
+}
+
+// SBOMType is the type of an SBOM, either SPDX or CycloneDX
 type SBOMType string
 
 const (
 	// SBOMTypeSPDX is the SPDX SBOM type
 	SBOMTypeSPDX SBOMType = "https://spdx.dev/Document"
+	// SBOMTypeCycloneDX is the CycloneDX SBOM type, in either its JSON or XML format
+	SBOMTypeCycloneDX SBOMType = "https://cyclonedx.org/bom"
 )
 
 // SBOM represents a detected SBOM (Software Bill of Materials)
 type SBOM struct {
-	Type    SBOMType // type of SBOM
-	Content []byte   // content of the SBOM
+	Type       SBOMType        // type of SBOM
+	Path       string          // path of the SBOM file
+	Components []SBOMComponent // components listed in the SBOM, including nested components
+	// Content of the SBOM.
+	//
+	// Deprecated: Only loaded by [SBOMDetector]. Read the file at Path instead, since SBOMs can be large.
+	Content []byte
+}
+
+// SBOMComponent is a component listed in an SBOM
+type SBOMComponent struct {
+	Type    string // type of the component, e.g. library. Empty for SPDX packages
+	Name    string // name of the component
+	Version string // version of the component
+	PURL    string // package URL of the component, if any
 }
@@ -2,6 +2,10 @@ package features
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
@@ -13,32 +17,61 @@ func TestSBOMFeature(t *testing.T) {
 	assert := assert.New(t)
 
 	type TestParams struct {
-		TestCase     string
-		ReportDir    string
-		ExpectedType SBOMType
+		TestCase           string
+		ReportDir          string
+		ExpectedTypes      []SBOMType
+		ExpectedComponents []SBOMComponent
 	}
 
 	for _, tt := range []*TestParams{
 		{
-			TestCase:     "Empty report",
-			ReportDir:    "testdata/reports/empty",
-			ExpectedType: "",
+			TestCase:  "Empty report",
+			ReportDir: "testdata/reports/empty",
 		},
 		{
-			TestCase:     "XML report",
-			ReportDir:    "testdata/reports/non-sarif",
-			ExpectedType: "",
+			TestCase:  "Missing report",
+			ReportDir: "testdata/reports/missing",
 		},
 		{
-			TestCase:     "Simple SBOM",
-			ReportDir:    "testdata/reports/spdx",
-			ExpectedType: SBOMTypeSPDX,
+			TestCase:  "XML report",
+			ReportDir: "testdata/reports/non-sarif",
+		},
+		{
+			TestCase:  "SARIF report",
+			ReportDir: "testdata/reports/sarif-high-severity",
+		},
+		{
+			TestCase:      "Simple SBOM",
+			ReportDir:     "testdata/reports/spdx",
+			ExpectedTypes: []SBOMType{SBOMTypeSPDX},
+			ExpectedComponents: []SBOMComponent{
+				{Name: "hello"},
+			},
+		},
+		{
+			TestCase:      "CycloneDX JSON SBOM",
+			ReportDir:     "testdata/reports/cyclonedx-json",
+			ExpectedTypes: []SBOMType{SBOMTypeCycloneDX},
+			ExpectedComponents: []SBOMComponent{
+				{Type: "library", Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"},
+				{Type: "library", Name: "body-parser", Version: "1.20.1", PURL: "pkg:npm/body-parser@1.20.1"},
+				{Type: "library", Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"},
+			},
+		},
+		{
+			TestCase:      "CycloneDX XML SBOM",
+			ReportDir:     "testdata/reports/cyclonedx-xml",
+			ExpectedTypes: []SBOMType{SBOMTypeCycloneDX},
+			ExpectedComponents: []SBOMComponent{
+				{Type: "library", Name: "commons-lang3", Version: "3.12.0", PURL: "pkg:maven/org.apache.commons/commons-lang3@3.12.0"},
+				{Type: "framework", Name: "spring-core", Version: "6.0.11", PURL: "pkg:maven/org.springframework/spring-core@6.0.11"},
+			},
 		},
 	} {
 		// setup the code under test
 		ctx := context.Background()
-		sbom := new(SBOM)
-		feature := SBOMDetector(tt.ReportDir, sbom)
+		sboms := make([]SBOM, 0)
+		feature := SBOMsDetector(tt.ReportDir, &sboms)
 
 		// setup the mock
 		m := new(runner.MockPlanExecutor)
@@ -50,7 +83,60 @@ func TestSBOMFeature(t *testing.T) {
 		// assert the results
 		assert.NoError(err)
 		m.AssertExpectations(t)
-		assert.NoError(err)
-		assert.Equal(tt.ExpectedType, sbom.Type, "%s - Type", tt.TestCase)
+		types := make([]SBOMType, 0)
+		var components []SBOMComponent
+		for _, sbom := range sboms {
+			types = append(types, sbom.Type)
+			components = append(components, sbom.Components...)
+		}
+		if tt.ExpectedTypes == nil {
+			tt.ExpectedTypes = []SBOMType{}
+		}
+		assert.Equal(tt.ExpectedTypes, types, "%s - Types", tt.TestCase)
+		assert.Equal(tt.ExpectedComponents, components, "%s - Components", tt.TestCase)
+	}
+}
+
+func TestSBOMFeatureLargeSBOM(t *testing.T) {
+	assert := assert.New(t)
+
+	// setup an SBOM well over the size of a small file
+	reportDir := t.TempDir()
+	components := make([]string, 0)
+	for i := 0; i < 1000; i++ {
+		components = append(components, fmt.Sprintf(`{"type":"library","name":"lib%d","version":"1.0.%d","description":"%s"}`, i, i, strings.Repeat("x", 100)))
+	}
+	content := fmt.Sprintf(`{"components":[%s],"bomFormat":"CycloneDX","specVersion":"1.5"}`, strings.Join(components, ","))
+	assert.NoError(os.WriteFile(filepath.Join(reportDir, "bom.json"), []byte(content), 0644))
+
+	ctx := context.Background()
+	sboms := make([]SBOM, 0)
+	m := new(runner.MockPlanExecutor)
+	m.OnExecute(ctx).Return(nil)
+
+	err := m.Execute(ctx, SBOMsDetector(reportDir, &sboms))
+
+	assert.NoError(err)
+	if assert.Len(sboms, 1) {
+		assert.Equal(SBOMTypeCycloneDX, sboms[0].Type)
+		assert.Len(sboms[0].Components, 1000)
 	}
 }
+
+func TestSBOMFeatureDeprecated(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	sbom := new(SBOM)
+	m := new(runner.MockPlanExecutor)
+	m.OnExecute(ctx).Return(nil)
+
+	//nolint:staticcheck // the deprecated feature is still supported
+	err := m.Execute(ctx, SBOMDetector("testdata/reports/spdx", sbom))
+
+	assert.NoError(err)
+	content, err := os.ReadFile("testdata/reports/spdx/sbom.json")
+	assert.NoError(err)
+	assert.Equal(SBOMTypeSPDX, sbom.Type)
+	assert.Equal(content, sbom.Content)
+}
@@ -2,6 +2,8 @@ package features
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
@@ -14,19 +16,35 @@ func StatusLogger(contextName string) runner.Feature {
 	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
 		log.Ctx(ctx).Debug().Msg("ENTER StatusLogger")
 		log.Ctx(ctx).Info().Msg("✨ STARTING")
+		ctx, attempts := common.WithAttempts(ctx)
 		err := e(ctx)
 		if err == nil {
-			log.Ctx(ctx).Info().Msg("✅ SUCCESS")
+			log.Ctx(ctx).Info().Msgf("✅ SUCCESS%s", attemptsSuffix(attempts))
 		} else {
-			switch err.(type) {
-			case common.Warning:
+			_, isWarning := err.(common.Warning)
+			cancelled := common.Cancelled(ctx)
+			switch {
+			case isWarning:
 				log.Ctx(ctx).Warn().Msgf("   %s", err.Error())
 				err = nil
+			case errors.As(err, new(*common.TimeoutError)):
+				log.Ctx(ctx).Error().Err(err).Msg("⏰ TIMED OUT")
+			case cancelled != nil:
+				log.Ctx(ctx).Warn().Msgf("🚫 CANCELLED because %s", cancelled.Reason)
+				err = cancelled
 			default:
-				log.Ctx(ctx).Error().Err(err).Msg("❌ FAILED")
+				log.Ctx(ctx).Error().Err(err).Msgf("❌ FAILED%s", attemptsSuffix(attempts))
 			}
 		}
 		log.Ctx(ctx).Debug().Msg("EXIT StatusLogger")
 		return err
 	}
 }
+
+// attemptsSuffix describes the number of attempts if the plan was attempted more than once
+func attemptsSuffix(attempts *common.Attempts) string {
+	if attempt, _ := attempts.Get(); attempt > 1 {
+		return fmt.Sprintf(" after %d attempts", attempt)
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
@@ -74,3 +75,46 @@ func TestStatusLoggerWarn(t *testing.T) {
 	assert.NoError(err)
 	m.AssertExpectations(t)
 }
+
+func TestStatusLoggerTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	// setup the code under test
+	ctx := context.Background()
+	feature := StatusLogger("mock-context")
+
+	// setup the mock
+	m := new(runner.MockPlanExecutor)
+	m.OnExecute(mock.MatchedBy(func(ctx context.Context) bool {
+		return true
+	})).Return(&common.TimeoutError{Timeout: time.Minute})
+
+	// run the feature
+	err := m.Execute(ctx, feature)
+
+	// assert the results
+	assert.EqualError(err, "timed out after 1m0s")
+	m.AssertExpectations(t)
+}
+
+func TestStatusLoggerCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	// setup the code under test
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(&common.CancelledError{Reason: "mock-plan failed"})
+	feature := StatusLogger("mock-context")
+
+	// setup the mock
+	m := new(runner.MockPlanExecutor)
+	m.OnExecute(mock.MatchedBy(func(ctx context.Context) bool {
+		return true
+	})).Return(context.Canceled)
+
+	// run the feature
+	err := m.Execute(ctx, feature)
+
+	// assert the results
+	assert.EqualError(err, "cancelled because mock-plan failed")
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,36 @@
+package features
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Timeout is a Feature to stop the execution of a plan that doesn't finish within the timeout.
+// The plan executor is cancelled once the timeout elapses, and a [common.TimeoutError] is returned once its commands
+// have been stopped.
+// A timeout of zero or less disables the feature.
+func Timeout(timeout time.Duration) runner.Feature {
+	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
+		log.Ctx(ctx).Debug().Msg("ENTER Timeout")
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = common.NewTimeoutContext(ctx, timeout)
+			defer cancel()
+		}
+		ctx, wait := runner.WithPlanWait(ctx)
+		err := e(ctx)
+		if timeoutError := common.TimedOut(ctx); timeoutError != nil {
+			// the plan executor returns as soon as it is cancelled, wait for the commands it started to be stopped
+			wait()
+			log.Ctx(ctx).Debug().Err(err).Msg("plan executor stopped after timeout")
+			err = timeoutError
+		}
+		log.Ctx(ctx).Debug().Msg("EXIT Timeout")
+		return err
+	}
+}
@@ -0,0 +1,98 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTimeout(t *testing.T) {
+	type TestParams struct {
+		TestCase      string
+		Timeout       time.Duration
+		Executor      common.Executor
+		ExpectedError error
+		MinDuration   time.Duration // how long the feature must take to return
+	}
+
+	for _, tt := range []TestParams{
+		{
+			TestCase: "finishes in time",
+			Timeout:  time.Minute,
+			Executor: func(ctx context.Context) error {
+				return nil
+			},
+		},
+		{
+			TestCase: "fails in time",
+			Timeout:  time.Minute,
+			Executor: func(ctx context.Context) error {
+				return fmt.Errorf("mock-error")
+			},
+			ExpectedError: fmt.Errorf("mock-error"),
+		},
+		{
+			TestCase: "disabled",
+			Timeout:  0,
+			Executor: func(ctx context.Context) error {
+				if _, ok := ctx.Deadline(); ok {
+					return fmt.Errorf("unexpected deadline")
+				}
+				return nil
+			},
+		},
+		{
+			TestCase: "times out",
+			Timeout:  10 * time.Millisecond,
+			Executor: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			ExpectedError: &common.TimeoutError{Timeout: 10 * time.Millisecond},
+		},
+		{
+			TestCase: "waits for the plan executor to stop",
+			Timeout:  10 * time.Millisecond,
+			Executor: func(ctx context.Context) error {
+				stopped := runner.TrackPlanExecution(ctx)
+				go func() {
+					// the commands take a while to stop after the executor has returned
+					time.Sleep(50 * time.Millisecond)
+					stopped()
+				}()
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			ExpectedError: &common.TimeoutError{Timeout: 10 * time.Millisecond},
+			MinDuration:   50 * time.Millisecond,
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// setup the code under test
+			ctx := context.Background()
+			feature := Timeout(tt.Timeout)
+
+			// setup the mock
+			m := new(runner.MockPlanExecutor).WithExecutor(tt.Executor)
+			m.OnExecute(mock.Anything).Return(nil)
+
+			// run the feature
+			start := time.Now()
+			err := m.Execute(ctx, feature)
+
+			// assert the results
+			assert.GreaterOrEqual(time.Since(start), tt.MinDuration)
+			assert.Equal(tt.ExpectedError, err)
+			m.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package features
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rivo/tview"
+)
+
+// planLog is the log of a plan in the TUI. The output of the commands between ::group:: and ::endgroup:: is a section
+// that collapses once the group ends, and expands or collapses again when its title is clicked.
+type planLog struct {
+	view     *tview.TextView
+	sections []*logSection
+	toggled  atomic.Bool // set while the view changes because a section was toggled, so that it doesn't scroll
+	mu       sync.Mutex
+}
+
+// logSection is a section of the log, with a title if it is a group
+type logSection struct {
+	id        string
+	title     string
+	content   bytes.Buffer
+	collapsed bool
+}
+
+func newPlanLog(view *tview.TextView) *planLog {
+	pl := &planLog{
+		view:     view,
+		sections: []*logSection{{}},
+	}
+	view.SetHighlightedFunc(func(added, _, _ []string) {
+		if len(added) == 0 {
+			return
+		}
+		for _, id := range added {
+			pl.toggle(id)
+		}
+		// clear the highlight, so that the next click on the title toggles the section again
+		view.Highlight()
+	})
+	return pl
+}
+
+// Write appends to the last section, and to the view unless the section is collapsed
+func (pl *planLog) Write(p []byte) (int, error) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	section := pl.sections[len(pl.sections)-1]
+	section.content.Write(p)
+	if section.collapsed {
+		return len(p), nil
+	}
+	return pl.view.Write(p)
+}
+
+// startGroup starts a new section with the title
+func (pl *planLog) startGroup(title string) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	section := &logSection{
+		id:    fmt.Sprintf("group-%d", len(pl.sections)),
+		title: title,
+	}
+	pl.sections = append(pl.sections, section)
+	fmt.Fprint(pl.view, section.header())
+}
+
+// endGroup collapses the current group, if any, and starts a new section without a title
+func (pl *planLog) endGroup() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if pl.sections[len(pl.sections)-1].title == "" {
+		return
+	}
+	pl.sections[len(pl.sections)-1].collapsed = true
+	pl.sections = append(pl.sections, &logSection{})
+	pl.render()
+}
+
+// toggle expands or collapses the section with the ID
+func (pl *planLog) toggle(id string) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	for _, section := range pl.sections {
+		if section.id == id {
+			section.collapsed = !section.collapsed
+			pl.toggled.Store(true)
+			pl.render()
+			return
+		}
+	}
+}
+
+// scrolls returns false if the last change of the view was a toggle, which shouldn't scroll to the end of the log
+func (pl *planLog) scrolls() bool {
+	return !pl.toggled.Swap(false)
+}
+
+func (pl *planLog) render() {
+	text := new(strings.Builder)
+	for _, section := range pl.sections {
+		if section.title != "" {
+			text.WriteString(section.header())
+		}
+		if !section.collapsed {
+			text.Write(section.content.Bytes())
+		}
+	}
+	pl.view.SetText(text.String())
+}
+
+// header is the title of a group, as a region of the view that can be clicked
+func (ls *logSection) header() string {
+	icon := "▼"
+	if ls.collapsed {
+		icon = "▶"
+	}
+	return fmt.Sprintf("[\"%s\"][::b]%s %s[::-][\"\"]\n", ls.id, icon, tview.Escape(ls.title))
+}
+
+// groupLogWriter is a zerolog writer that starts and ends the groups of the log from the entries with a group field,
+// and writes the other entries to out
+type groupLogWriter struct {
+	log *planLog
+	out io.Writer
+}
+
+func (glw *groupLogWriter) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, []byte(`"group":`)) {
+		return glw.out.Write(p)
+	}
+	var entry struct {
+		Group   string `json:"group"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(p, &entry); err != nil {
+		return glw.out.Write(p)
+	}
+	switch entry.Group {
+	case "start":
+		glw.log.startGroup(strings.TrimPrefix(entry.Message, "▶ "))
+	case "end":
+		glw.log.endGroup()
+	default:
+		return glw.out.Write(p)
+	}
+	return len(p), nil
+}
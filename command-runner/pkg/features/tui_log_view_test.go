@@ -0,0 +1,40 @@
+package features
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rivo/tview"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanLog(t *testing.T) {
+	assert := assert.New(t)
+	view := tview.NewTextView().SetRegions(true).SetDynamicColors(true)
+	planLog := newPlanLog(view)
+	logger := zerolog.New(&groupLogWriter{log: planLog, out: planLog})
+
+	logger.Info().Msg("before")
+	logger.Info().Str("group", "start").Msg("▶ Compile")
+	logger.Info().Msg("compiling")
+	assert.Equal("{\"level\":\"info\",\"message\":\"before\"}\n▼ Compile\n{\"level\":\"info\",\"message\":\"compiling\"}\n", view.GetText(true))
+
+	// the group collapses once it ends
+	logger.Info().Str("group", "end").Msg("◀ Compile")
+	logger.Info().Msg("after")
+	assert.Equal("{\"level\":\"info\",\"message\":\"before\"}\n▶ Compile\n{\"level\":\"info\",\"message\":\"after\"}\n", view.GetText(true))
+
+	// clicking the title of the group expands it, then collapses it again
+	planLog.toggle("group-1")
+	assert.Contains(view.GetText(true), "▼ Compile\n{\"level\":\"info\",\"message\":\"compiling\"}\n")
+	assert.False(planLog.scrolls())
+	assert.True(planLog.scrolls())
+	planLog.toggle("group-1")
+	assert.NotContains(view.GetText(true), "compiling")
+
+	// an end without a group is ignored
+	planLog.endGroup()
+	fmt.Fprintln(planLog, "last")
+	assert.Contains(view.GetText(true), "after\"}\nlast\n")
+}
@@ -16,7 +16,6 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -27,11 +26,19 @@ func TUILogger(planID string) runner.Feature {
 	return func(ctx context.Context, plan runner.Plan, e runner.PlanExecutor) error {
 		planExecution := tuiApp.Add(ctx, planID)
 		ctx = planExecution.Start(ctx)
+		ctx = runner.WithEventSink(ctx, planExecution)
+		ctx, planExecution.attempts = common.WithAttempts(ctx)
 		err := e(ctx)
 		if err == nil {
 			planExecution.Success()
 		} else if errors.Is(err, common.ErrDefer) {
 			planExecution.Defer()
+		} else if errors.As(err, new(*common.TimeoutError)) {
+			planExecution.TimedOut(err)
+		} else if common.Cancelled(ctx) != nil {
+			planExecution.Cancelled(err)
+		} else if _, ok := err.(common.Warning); ok {
+			planExecution.Warning(err)
 		} else {
 			planExecution.Failure(err)
 		}
@@ -44,6 +51,8 @@ type tuiApplication struct {
 	app         *tview.Application
 	actionsView *tview.Table
 	pagesView   *tview.Pages
+	summary     *summaryPanel
+	rootView    *tview.Flex
 	done        chan bool
 	mu          sync.Mutex
 	pending     int
@@ -61,7 +70,9 @@ func (t *tuiApplication) Add(ctx context.Context, planID string) *planExecution
 	i := len(t.executions) - 1
 	t.actionsView.SetCell(i, 0, pe.cell)
 	pe.logView.SetChangedFunc(func() {
-		pe.logView.ScrollToEnd()
+		if pe.log.scrolls() {
+			pe.logView.ScrollToEnd()
+		}
 		t.app.Draw()
 	})
 	t.pagesView.AddPage(strconv.Itoa(i), pe.logView, true, false)
@@ -96,6 +107,12 @@ func (t *tuiApplication) Start(ctx context.Context) {
 		AddItem(t.actionsView, 0, 1, true).
 		AddItem(t.pagesView, 0, 5, false)
 
+	// the summary panel is hidden until an action sets summary messages
+	t.summary = newSummaryPanel()
+	t.rootView = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(flex, 0, 1, true).
+		AddItem(t.summary.view, 0, 0, false)
+
 	t.app = t.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyCtrlC && t.pending > 0 {
 			process, _ := os.FindProcess(os.Getpid())
@@ -107,7 +124,7 @@ func (t *tuiApplication) Start(ctx context.Context) {
 	})
 	t.done = make(chan bool, 1)
 	go func() {
-		if err := t.app.SetRoot(flex, true).EnableMouse(true).Run(); err != nil {
+		if err := t.app.SetRoot(t.rootView, true).EnableMouse(true).Run(); err != nil {
 			log.Error().Err(err).Msg("failed to start TUI app")
 		}
 		t.done <- true
@@ -124,7 +141,7 @@ func (t *tuiApplication) refreshLoadingIcons(ctx context.Context) {
 		redraw := false
 		for _, pe := range t.executions {
 			if pe.running {
-				pe.cell.SetText(fmt.Sprintf("%s %s", icon, pe.id))
+				pe.cell.SetText(fmt.Sprintf("%s %s%s", icon, pe.id, pe.attemptsLabel()))
 				redraw = true
 			}
 		}
@@ -157,6 +174,12 @@ func (t *tuiApplication) HandleStart(planID string) {
 	}
 	t.pending++
 }
+func (t *tuiApplication) HandleSummary(planID string, messages []*runner.SummaryMessage) {
+	height := t.summary.set(planID, messages)
+	t.app.QueueUpdateDraw(func() {
+		t.rootView.ResizeItem(t.summary.view, height, 0)
+	})
+}
 func (t *tuiApplication) HandleSuccess(_ string) {
 	t.decrementPending()
 }
@@ -178,6 +201,7 @@ func (t *tuiApplication) decrementPending() {
 
 type planExecutionEventHandler interface {
 	HandleStart(planID string)
+	HandleSummary(planID string, messages []*runner.SummaryMessage)
 	HandleSuccess(planID string)
 	HandleFailure(planID string)
 }
@@ -188,8 +212,21 @@ type planExecution struct {
 	running      bool
 	cell         *tview.TableCell
 	logView      *tview.TextView
+	log          *planLog
 	logWriter    io.Writer
 	eventHandler planExecutionEventHandler
+	attempts     *common.Attempts
+}
+
+// attemptsLabel returns the attempt count once a plan is attempted more than once
+func (pe *planExecution) attemptsLabel() string {
+	if pe.attempts == nil {
+		return ""
+	}
+	if attempt, maxAttempts := pe.attempts.Get(); attempt > 1 {
+		return fmt.Sprintf(" (%d/%d)", attempt, maxAttempts)
+	}
+	return ""
 }
 
 func newPlanExecution(planID string, eventHandler planExecutionEventHandler) *planExecution {
@@ -204,11 +241,13 @@ func newPlanExecution(planID string, eventHandler planExecutionEventHandler) *pl
 	cell.SetText(fmt.Sprintf("⏸️ %s", planID))
 	cell.SetTextColor(tcell.ColorYellow)
 
+	planLog := newPlanLog(textView)
 	pe := &planExecution{
 		id:           planID,
 		cell:         cell,
 		logView:      textView,
-		logWriter:    tview.ANSIWriter(textView),
+		log:          planLog,
+		logWriter:    common.NewMaskWriter(tview.ANSIWriter(planLog)),
 		eventHandler: eventHandler,
 	}
 	cell.SetReference(pe)
@@ -216,7 +255,7 @@ func newPlanExecution(planID string, eventHandler planExecutionEventHandler) *pl
 }
 
 func (pe *planExecution) Start(ctx context.Context) context.Context {
-	ctx = log.Logger.Output(zerolog.ConsoleWriter{Out: pe.logWriter}).WithContext(ctx)
+	ctx = log.Logger.Output(&groupLogWriter{log: pe.log, out: NewConsoleWriter(pe.logWriter)}).WithContext(ctx)
 	pe.cell.SetText(fmt.Sprintf("%s %s", string(loadingIcons[0]), pe.id))
 	pe.cell.SetTextColor(tcell.ColorWhite)
 	pe.running = true
@@ -228,9 +267,16 @@ func (pe *planExecution) Start(ctx context.Context) context.Context {
 	return ctx
 }
 
+// Emit shows the summary messages set by the plan in the summary panel
+func (pe *planExecution) Emit(event *runner.Event) {
+	if event.Type == runner.EventSummarySet {
+		pe.eventHandler.HandleSummary(pe.id, event.Summary)
+	}
+}
+
 func (pe *planExecution) Success() {
 	pe.running = false
-	pe.cell.SetText(fmt.Sprintf("✅ %s", pe.id))
+	pe.cell.SetText(fmt.Sprintf("✅ %s%s", pe.id, pe.attemptsLabel()))
 	pe.cell.SetTextColor(tcell.ColorGreen)
 	pe.eventHandler.HandleSuccess(pe.id)
 }
@@ -242,10 +288,31 @@ func (pe *planExecution) Defer() {
 }
 
 func (pe *planExecution) Failure(err error) {
+	pe.fail("❌", err)
+}
+
+func (pe *planExecution) TimedOut(err error) {
+	pe.fail("⏰", err)
+}
+
+func (pe *planExecution) Cancelled(err error) {
+	pe.fail("🚫", err)
+}
+
+func (pe *planExecution) Warning(err error) {
+	pe.running = false
+	pe.cell.SetText(fmt.Sprintf("⚠️ %s", pe.id))
+	pe.cell.SetTextColor(tcell.ColorYellow)
+	pe.eventHandler.HandleSuccess(pe.id)
+	logger := log.Logger.Output(NewConsoleWriter(pe.logWriter))
+	logger.Warn().Msg(err.Error())
+}
+
+func (pe *planExecution) fail(icon string, err error) {
 	pe.running = false
-	pe.cell.SetText(fmt.Sprintf("❌ %s", pe.id))
+	pe.cell.SetText(fmt.Sprintf("%s %s%s", icon, pe.id, pe.attemptsLabel()))
 	pe.cell.SetTextColor(tcell.ColorRed)
 	pe.eventHandler.HandleFailure(pe.id)
-	logger := log.Logger.Output(zerolog.ConsoleWriter{Out: pe.logWriter})
+	logger := log.Logger.Output(NewConsoleWriter(pe.logWriter))
 	logger.Error().Msg(err.Error())
 }
@@ -0,0 +1,76 @@
+package features
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+
+	"github.com/rivo/tview"
+)
+
+// maxSummaryLines is the most lines of summary messages the summary panel of the TUI shows before it scrolls
+const maxSummaryLines = 10
+
+// summaryPanel shows the summary messages of the plans in the TUI, grouped by plan
+type summaryPanel struct {
+	view     *tview.TextView
+	planIDs  []string
+	messages map[string][]*runner.SummaryMessage
+	mu       sync.Mutex
+}
+
+func newSummaryPanel() *summaryPanel {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetScrollable(true)
+	view.SetBorder(true).SetTitle("Summary")
+	return &summaryPanel{
+		view:     view,
+		messages: make(map[string][]*runner.SummaryMessage),
+	}
+}
+
+// set sets the summary messages of a plan and renders the panel. It returns the height the panel needs to show them.
+func (sp *summaryPanel) set(planID string, messages []*runner.SummaryMessage) int {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if _, ok := sp.messages[planID]; !ok {
+		sp.planIDs = append(sp.planIDs, planID)
+	}
+	sp.messages[planID] = messages
+
+	text := new(strings.Builder)
+	lines := 0
+	for _, id := range sp.planIDs {
+		if len(sp.messages[id]) == 0 {
+			continue
+		}
+		fmt.Fprintf(text, "[::b]%s[::-]\n", tview.Escape(id))
+		lines++
+		for _, message := range sp.messages[id] {
+			fmt.Fprintf(text, "  [%s]%s[-] %s\n", summaryLevelColor(message.Level), message.Level, tview.Escape(message.String()))
+			lines++
+		}
+	}
+	sp.view.SetText(text.String())
+	if lines == 0 {
+		return 0
+	}
+	// the border takes a line above and below the messages
+	return min(lines, maxSummaryLines) + 2
+}
+
+// summaryLevelColor returns the color of the level of a summary message
+func summaryLevelColor(level string) string {
+	switch level {
+	case "Error":
+		return "red"
+	case "Warning":
+		return "yellow"
+	default:
+		return "blue"
+	}
+}
@@ -0,0 +1,26 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/runner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryPanel(t *testing.T) {
+	assert := assert.New(t)
+	panel := newSummaryPanel()
+
+	height := panel.set("Build", []*runner.SummaryMessage{
+		{Level: "Error", Code: "CUSTOM", Message: "Build failed in [main]"},
+		{Level: "Info", Message: "3 files built"},
+	})
+	assert.Equal(5, height)
+	assert.Equal("Build\n  Error [CUSTOM] Build failed in [main]\n  Info 3 files built\n", panel.view.GetText(true))
+
+	// a later attempt replaces the messages of the plan, and the plans keep their order
+	panel.set("Test", []*runner.SummaryMessage{{Level: "Warning", Message: "1 test skipped"}})
+	height = panel.set("Build", []*runner.SummaryMessage{{Level: "Info", Message: "built"}})
+	assert.Equal(6, height)
+	assert.Equal("Build\n  Info built\nTest\n  Warning 1 test skipped\n", panel.view.GetText(true))
+}
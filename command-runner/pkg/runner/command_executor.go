@@ -2,12 +2,20 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/internal/containers"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/internal/containers/types"
+	"github.com/aws/codecatalyst-runner-cli/command-runner/internal/fs"
 )
 
+// terminationGracePeriod is how long a command has to exit after it is sent SIGTERM before it is killed with SIGKILL
+var terminationGracePeriod = 10 * time.Second
+
 type commandExecutor interface {
 	ExecuteCommand(ctx context.Context, command Command) error
 	Close(isError bool) error
@@ -38,3 +46,20 @@ func newCommandExecutor(ctx context.Context, id string, executionType ExecutionT
 		ContainerService:         containerServiceProvider.NewContainerService(),
 	})
 }
+
+// TmpDir returns the directory for the temporary files of the runner, in the user cache dir. It returns an empty
+// string, i.e. the default directory for temporary files of [os.MkdirTemp], if the directory can't be created.
+func TmpDir() string {
+	return fs.TmpDir()
+}
+
+// nextCollectDir creates the subdirectory of targetPath that a FileMap of type [FileMapTypeCollect] copies the files of
+// a command group to. The subdirectories are numbered in the order the command groups ran.
+func nextCollectDir(targetPath string) (string, error) {
+	entries, err := os.ReadDir(targetPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	collectDir := filepath.Join(targetPath, fmt.Sprintf("%04d", len(entries)+1))
+	return collectDir, os.MkdirAll(collectDir, 0755)
+}
@@ -2,7 +2,9 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -19,11 +21,12 @@ import (
 )
 
 type containerCommandExecutor struct {
-	Container       types.Container
-	ReuseContainers bool
-	CloseExecutors  []common.Executor
-	mceDir          string
-	ctx             context.Context
+	Container        types.Container
+	ReuseContainers  bool
+	CollectExecutors []common.Executor
+	CloseExecutors   []common.Executor
+	mceDir           string
+	ctx              context.Context
 }
 
 type newContainerCommandExecutorParams struct {
@@ -118,7 +121,7 @@ func newContainerCommandExecutor(ctx context.Context, params *newContainerComman
 		Entrypoint: params.Entrypoint,
 	})
 
-	copyExecutors, closeExecutors, err := setupCopyAndCloseExecutors(actionContainer, params.WorkingDir, params.FileMaps)
+	copyExecutors, collectExecutors, closeExecutors, err := setupCopyAndCloseExecutors(actionContainer, params.WorkingDir, params.FileMaps)
 	if err != nil {
 		return nil, err
 	}
@@ -133,23 +136,26 @@ func newContainerCommandExecutor(ctx context.Context, params *newContainerComman
 		actionContainer.Create(nil, nil).TraceRegion("container-create"),
 		common.NewPipelineExecutor(copyExecutors...).TraceRegion("container-copy"),
 		actionContainer.Start(false).TraceRegion("container-start"),
+		resetOutputDir(actionContainer),
 	)(ctx); err != nil {
 		return nil, fmt.Errorf("unable to create container executor: %w", err)
 	}
 
 	return &containerCommandExecutor{
-		Container:       actionContainer,
-		ReuseContainers: params.Reuse,
-		CloseExecutors:  closeExecutors,
-		mceDir:          mceDir,
-		ctx:             ctx,
+		Container:        actionContainer,
+		ReuseContainers:  params.Reuse,
+		CollectExecutors: collectExecutors,
+		CloseExecutors:   closeExecutors,
+		mceDir:           mceDir,
+		ctx:              ctx,
 	}, nil
 }
 
 func (cce *containerCommandExecutor) Close(isError bool) error {
-	var err error
+	// the files are collected even if a command failed, since they may explain the failure
+	err := common.NewPipelineExecutor(cce.CollectExecutors...).TraceRegion("collect-executors")(cce.ctx)
 	if !isError {
-		err = common.NewPipelineExecutor(cce.CloseExecutors...).TraceRegion("close-executors")(cce.ctx)
+		err = errors.Join(err, common.NewPipelineExecutor(cce.CloseExecutors...).TraceRegion("close-executors")(cce.ctx))
 	}
 	if !cce.ReuseContainers {
 		if err := cce.Container.Remove()(context.Background()); err != nil {
@@ -163,7 +169,9 @@ func (cce *containerCommandExecutor) Close(isError bool) error {
 }
 
 func (cce *containerCommandExecutor) ExecuteCommand(ctx context.Context, command Command) error {
-	script := fmt.Sprintf(`cd $(cat /tmp/mce/tmp/dir.txt)
+	scriptName := fmt.Sprintf("script-%d.sh", time.Now().UnixNano())
+	script := fmt.Sprintf(`echo $$ > /tmp/mce/tmp/%s.pid
+cd $(cat /tmp/mce/tmp/dir.txt)
 set -a
 . /tmp/mce/tmp/env.sh
 while read line; do
@@ -173,14 +181,46 @@ done < /tmp/mce/tmp/init.env
 CODEBUILD_LAST_EXIT=$?
 export -p > /tmp/mce/tmp/env.sh
 pwd > /tmp/mce/tmp/dir.txt
-exit $CODEBUILD_LAST_EXIT`, strings.Join(command, " "))
+exit $CODEBUILD_LAST_EXIT`, scriptName, strings.Join(command, " "))
 	log.Ctx(ctx).Debug().Msgf("script: %s", script)
-	scriptName := fmt.Sprintf("script-%d.sh", time.Now().UnixNano())
 	if err := os.WriteFile(filepath.Join(cce.mceDir, "tmp", scriptName), []byte(script), 00755); err != nil /* #nosec G306 */ {
 		return err
 	}
 
-	return cce.Container.Exec([]string{"/bin/sh", fmt.Sprintf("/tmp/mce/tmp/%s", scriptName)}, nil, "", "")(ctx)
+	err := cce.Container.Exec([]string{"/bin/sh", fmt.Sprintf("/tmp/mce/tmp/%s", scriptName)}, nil, "", "")(ctx)
+	if ctx.Err() != nil {
+		cce.terminate(ctx, scriptName)
+	}
+	return err
+}
+
+// terminate sends SIGTERM to the processes of a script that is still running in the container, followed by SIGKILL
+// if they haven't exited after the termination grace period
+func (cce *containerCommandExecutor) terminate(ctx context.Context, scriptName string) {
+	gracePeriod := int(math.Ceil(terminationGracePeriod.Seconds()))
+	script := fmt.Sprintf(`PID=$(cat /tmp/mce/tmp/%s.pid 2>/dev/null) || exit 0
+kill -TERM -$PID 2>/dev/null || kill -TERM $PID 2>/dev/null || exit 0
+i=0
+while kill -0 $PID 2>/dev/null && [ $i -lt %d ]; do
+	sleep 1
+	i=$((i+1))
+done
+kill -KILL -$PID 2>/dev/null || kill -KILL $PID 2>/dev/null
+exit 0`, scriptName, gracePeriod)
+	log.Ctx(ctx).Debug().Msgf("terminating %s", scriptName)
+	// the context is already done, so use a new one that allows for the grace period
+	terminateCtx, cancel := context.WithTimeout(context.Background(), terminationGracePeriod+time.Minute)
+	defer cancel()
+	if err := cce.Container.Exec([]string{"/bin/sh", "-c", script}, nil, "", "")(terminateCtx); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("unable to terminate %s", scriptName)
+	}
+}
+
+// resetOutputDir creates an empty output file in the container, removing the outputs of a previous run of a reused container
+func resetOutputDir(actionContainer types.Container) common.Executor {
+	outputDir := resolvePath(OutputDir, containerSourceDir)
+	script := fmt.Sprintf("rm -rf %s && mkdir -p %s && touch %s", outputDir, outputDir, outputFilePath(containerSourceDir))
+	return actionContainer.Exec([]string{"/bin/sh", "-c", script}, nil, "", "/")
 }
 
 func bindModifiers() string {
@@ -232,23 +272,39 @@ func setupMceDir(env []string, containerDefaultDir string) (string, error) {
 	if err := os.WriteFile(filepath.Join(mceDir, "tmp", "dir.txt"), []byte(containerDefaultDir), 00666); err != nil /* #nosec G306 */ {
 		return "", err
 	}
-	envout := `. /tmp/mce/tmp/env.sh
-env -0 | while IFS='=' read -r -d '' n v; do  printf "::set-output name=%s::%s\n" "$n" "$v"; done`
+	envout := fmt.Sprintf(". /tmp/mce/tmp/env.sh\n%s\n", envOutputScript(containerSourceDir))
 	if err := os.WriteFile(filepath.Join(mceDir, "tmp", "envout.sh"), []byte(envout), 00755); err != nil /* #nosec G306 */ {
 		return "", err
 	}
 	return mceDir, nil
 }
 
-func setupCopyAndCloseExecutors(actionContainer types.Container, workingDir string, filemaps []*FileMap) ([]common.Executor, []common.Executor, error) {
+func setupCopyAndCloseExecutors(actionContainer types.Container, workingDir string, filemaps []*FileMap) ([]common.Executor, []common.Executor, []common.Executor, error) {
 	copyExecutors := []common.Executor{}
-	closeExecutors := []common.Executor{
-		actionContainer.Exec([]string{"/bin/bash", "/tmp/mce/tmp/envout.sh"}, nil, "", "/"),
+	collectExecutors := []common.Executor{
+		actionContainer.Exec([]string{"/bin/sh", "/tmp/mce/tmp/envout.sh"}, nil, "", "/"),
 	}
+	closeExecutors := []common.Executor{}
 	for _, filemap := range filemaps {
 		switch filemap.Type {
 		case FileMapTypeBind:
 			continue
+		case FileMapTypeCollect:
+			srcPath := resolvePath(filemap.SourcePath, containerSourceDir)
+			targetPath := filemap.TargetPath
+			collectExecutors = append(
+				collectExecutors,
+				actionContainer.Exec([]string{"mkdir", "-p", "/extract"}, nil, "", "/"),
+				actionContainer.Exec([]string{"/bin/sh", "-c", fmt.Sprintf("cp -a %s /extract || echo 'nothing to collect' > /dev/null 2>&1", srcPath)}, nil, "", "/"),
+				func(ctx context.Context) error {
+					collectDir, err := nextCollectDir(targetPath)
+					if err != nil {
+						return err
+					}
+					return actionContainer.CopyOut(collectDir, "/extract/.")(ctx)
+				},
+				actionContainer.Exec([]string{"rm", "-rf", "/extract"}, nil, "", "/"),
+			)
 		case FileMapTypeCopyOut:
 			srcPath := resolvePath(filemap.SourcePath, containerSourceDir)
 			closeExecutors = append(
@@ -284,10 +340,10 @@ func setupCopyAndCloseExecutors(actionContainer types.Container, workingDir stri
 				),
 			)
 		default:
-			return nil, nil, fmt.Errorf("unknown filemap Type")
+			return nil, nil, nil, fmt.Errorf("unknown filemap Type")
 		}
 	}
-	return copyExecutors, closeExecutors, nil
+	return copyExecutors, collectExecutors, closeExecutors, nil
 }
 
 func setupEnvironmentVariables(env map[string]string) ([]string, string, error) {
@@ -307,5 +363,6 @@ func setupEnvironmentVariables(env map[string]string) ([]string, string, error)
 		return nil, "", fmt.Errorf("input source or artifact is required")
 	}
 	envVars = append(envVars, fmt.Sprintf("CATALYST_DEFAULT_DIR=%s", containerDefaultDir))
+	envVars = append(envVars, fmt.Sprintf("%s=%s", OutputFileEnv, outputFilePath(containerSourceDir)))
 	return envVars, containerDefaultDir, nil
 }
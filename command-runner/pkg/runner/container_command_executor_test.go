@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -153,6 +155,9 @@ func TestExecutorContainer(t *testing.T) {
 				if !slices.Contains(input.Env, fmt.Sprintf("CATALYST_DEFAULT_DIR=%s", containerWorkingDir)) {
 					return false
 				}
+				if !slices.Contains(input.Env, "CATALYST_OUTPUT=/codecatalyst/output/src/outputs/output") {
+					return false
+				}
 				for k, v := range tt.EnvironmentConfiguration.Env {
 					if !slices.Contains(input.Env, fmt.Sprintf("%s=%s", k, v)) {
 						return false
@@ -178,7 +183,8 @@ func TestExecutorContainer(t *testing.T) {
 			})
 			mockContainer := &cmock.MockContainer{}
 			mockContainer.On("Pull", true).Return(nil)
-			mockContainer.On("Exec", []string{"/bin/bash", "/tmp/mce/tmp/envout.sh"}, emptyMap, "", "/").Return(nil)
+			mockContainer.On("Exec", []string{"/bin/sh", "/tmp/mce/tmp/envout.sh"}, emptyMap, "", "/").Return(nil)
+			mockContainer.On("Exec", []string{"/bin/sh", "-c", "rm -rf /codecatalyst/output/src/outputs && mkdir -p /codecatalyst/output/src/outputs && touch /codecatalyst/output/src/outputs/output"}, emptyMap, "", "/").Return(nil)
 			mockContainer.On("Remove").Return(nil)
 			mockContainer.On("Create", emptyList, emptyList).Return(nil)
 			mockContainer.On("Start", false).Return(nil)
@@ -229,3 +235,31 @@ func TestExecutorContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutorContainerTermination(t *testing.T) {
+	assert := assert.New(t)
+
+	// setup the code under test
+	var emptyMap map[string]string
+	mockContainer := &cmock.MockContainer{}
+	mockContainer.On("Exec", mock.MatchedBy(func(cmd []string) bool {
+		return len(cmd) == 2 && strings.HasPrefix(cmd[1], "/tmp/mce/tmp/script-")
+	}), emptyMap, "", "").Return(context.DeadlineExceeded)
+	mockContainer.On("Exec", mock.MatchedBy(func(cmd []string) bool {
+		return len(cmd) == 3 && cmd[1] == "-c" && strings.Contains(cmd[2], "kill -TERM")
+	}), emptyMap, "", "").Return(nil)
+	executor := &containerCommandExecutor{
+		Container: mockContainer,
+		mceDir:    t.TempDir(),
+	}
+	assert.NoError(os.MkdirAll(filepath.Join(executor.mceDir, "tmp"), 0755))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// run the command
+	err := executor.ExecuteCommand(ctx, Command{"sleep", "30"})
+
+	// assert the results
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	mockContainer.AssertExpectations(t)
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EventSchemaVersion is the version of the schema of [Event]. It is incremented when a field is removed or changes
+// meaning. Fields and event types may be added without changing the version.
+const EventSchemaVersion = 1
+
+// EventType describes what happened in an [Event]
+type EventType string
+
+const (
+	// EventRunStarted is emitted once before any plan runs
+	EventRunStarted EventType = "run_started"
+	// EventPlanQueued is emitted when a plan is handed to the runner
+	EventPlanQueued EventType = "plan_queued"
+	// EventPlanStarted is emitted the first time a plan is run
+	EventPlanStarted EventType = "plan_started"
+	// EventPlanDeferred is emitted when a plan waits for the plans it depends on
+	EventPlanDeferred EventType = "plan_deferred"
+	// EventCommandStarted is emitted before a command of a plan runs
+	EventCommandStarted EventType = "command_started"
+	// EventCommandFinished is emitted once a command of a plan has run, with its exit code and duration
+	EventCommandFinished EventType = "command_finished"
+	// EventLog is emitted for each line logged by a plan, including the output of its commands
+	EventLog EventType = "log"
+	// EventOutputsSet is emitted when a plan sets its output variables
+	EventOutputsSet EventType = "outputs_set"
+	// EventSummarySet is emitted when a plan sets its summary messages
+	EventSummarySet EventType = "summary_set"
+	// EventArtifactProduced is emitted when a plan produces an output artifact
+	EventArtifactProduced EventType = "artifact_produced"
+	// EventPlanFinished is emitted once a plan has run, with its status
+	EventPlanFinished EventType = "plan_finished"
+	// EventRunFinished is emitted once every plan has run, with the status of the run
+	EventRunFinished EventType = "run_finished"
+)
+
+// Event describes something that happened while running plans. Only the fields that apply to the type of the event are set.
+type Event struct {
+	Version    int               `json:"version"`              // version of the schema of the event, see [EventSchemaVersion]
+	Time       time.Time         `json:"time"`                 // time the event was emitted
+	Type       EventType         `json:"type"`                 // type of the event
+	Workflow   string            `json:"workflow,omitempty"`   // name of the workflow that is run
+	RunID      string            `json:"runId,omitempty"`      // ID of the run in the run history
+	PlanID     string            `json:"planId,omitempty"`     // ID of the plan the event is about
+	Status     string            `json:"status,omitempty"`     // status of the plan or the run once finished
+	Command    string            `json:"command,omitempty"`    // command that is run
+	ExitCode   *int              `json:"exitCode,omitempty"`   // exit code of the command, if known
+	DurationMs *int64            `json:"durationMs,omitempty"` // duration of the command, plan or run in milliseconds
+	Stream     string            `json:"stream,omitempty"`     // stream of a log line: stdout, stderr or log for the messages of the runner
+	Level      string            `json:"level,omitempty"`      // level of a log line
+	Message    string            `json:"message,omitempty"`    // message of a log line
+	Error      string            `json:"error,omitempty"`      // error of a failed command, plan or run
+	Outputs    map[string]string `json:"outputs,omitempty"`    // output variables set by the plan
+	Summary    []*SummaryMessage `json:"summary,omitempty"`    // summary messages set by the plan
+	Artifact   string            `json:"artifact,omitempty"`   // name of the artifact produced
+	Path       string            `json:"path,omitempty"`       // path the artifact was produced to
+}
+
+// SummaryMessage is a message of the summary of a plan, for its users
+type SummaryMessage struct {
+	Level   string `json:"level"`          // level of the message: Error, Warning or Info
+	Code    string `json:"code,omitempty"` // status code of the message, if any
+	Message string `json:"message"`        // text of the message
+}
+
+// String returns the message prefixed by its code, if any
+func (sm *SummaryMessage) String() string {
+	if sm.Code == "" || sm.Code == sm.Message {
+		return sm.Message
+	}
+	return fmt.Sprintf("[%s] %s", sm.Code, sm.Message)
+}
+
+// EventSink receives the events emitted while running plans
+type EventSink interface {
+	Emit(event *Event)
+}
+
+type eventSinkContextKey string
+
+const eventSinkContextKeyVal = eventSinkContextKey("eventSink")
+
+// WithEventSink returns a context that sends the events emitted with it to the sink
+func WithEventSink(ctx context.Context, sink EventSink) context.Context {
+	return context.WithValue(ctx, eventSinkContextKeyVal, sink)
+}
+
+// EmitEvent sends the event to the sink of the context, if any
+func EmitEvent(ctx context.Context, event *Event) {
+	if sink, ok := ctx.Value(eventSinkContextKeyVal).(EventSink); ok && sink != nil {
+		sink.Emit(event)
+	}
+}
@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// OutputDir is the directory of the commands that holds the files they set their outputs in. It is relative to the
+	// source dir of a container and to the temporary dir of a shell, like the paths of a [FileMap]. Features read the
+	// outputs with [ReadOutputs] from the copies of it made by a FileMap of type [FileMapTypeCollect].
+	OutputDir = "outputs"
+	// OutputFileEnv is the environment variable with the path of the file the commands append their outputs to, like
+	// $GITHUB_OUTPUT. Each output is a NAME=value line, or a multi-line value between NAME<<DELIMITER and DELIMITER.
+	OutputFileEnv = "CATALYST_OUTPUT"
+
+	outputFileName = "output"
+	envFileName    = "env" // environment variables exported once the commands have run, separated by NUL characters
+)
+
+// outputFilePath returns the path of the output file of the commands with baseDir as their source dir
+func outputFilePath(baseDir string) string {
+	return resolvePath(filepath.Join(OutputDir, outputFileName), baseDir)
+}
+
+// envOutputScript returns a script that writes the environment variables to the output dir of the commands
+func envOutputScript(baseDir string) string {
+	outputDir := resolvePath(OutputDir, baseDir)
+	return fmt.Sprintf(`mkdir -p %s && env -0 > %s`, outputDir, filepath.Join(outputDir, envFileName))
+}
+
+// ReadOutputs reads the outputs from the [OutputDir] of the commands, as collected by a FileMap of type
+// [FileMapTypeCollect]. The outputs of each command group override those of the command groups that ran before it.
+// Within a command group, the environment variables exported by the commands are overridden by the outputs of the
+// output file. A missing dir or file has no outputs.
+func ReadOutputs(dir string) (map[string]string, error) {
+	outputs := make(map[string]string)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return outputs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	// the entries are sorted by name, i.e. in the order the command groups ran
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := readCommandGroupOutputs(filepath.Join(dir, entry.Name()), outputs); err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// readCommandGroupOutputs adds the outputs of a command group in a copy of its [OutputDir] to outputs
+func readCommandGroupOutputs(dir string, outputs map[string]string) error {
+	env, err := os.ReadFile(filepath.Join(dir, envFileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, kv := range bytes.Split(env, []byte{0}) {
+		if name, value, ok := strings.Cut(string(kv), "="); ok && name != "" {
+			outputs[name] = value
+		}
+	}
+	f, err := os.Open(filepath.Join(dir, outputFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := parseOutputFile(f, outputs); err != nil {
+		return fmt.Errorf("unable to parse output file: %w", err)
+	}
+	return nil
+}
+
+// parseOutputFile adds the NAME=value and NAME<<DELIMITER outputs of the output file to outputs
+func parseOutputFile(r io.Reader, outputs map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if name, delimiter, ok := strings.Cut(line, "<<"); ok && !strings.Contains(name, "=") {
+			if name == "" || delimiter == "" {
+				return fmt.Errorf("line %d: expected NAME<<DELIMITER", lineNumber)
+			}
+			start := lineNumber
+			lines := make([]string, 0)
+			closed := false
+			for scanner.Scan() {
+				lineNumber++
+				if line := strings.TrimSuffix(scanner.Text(), "\r"); line != delimiter {
+					lines = append(lines, line)
+				} else {
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				return fmt.Errorf("line %d: no delimiter %s for output %s", start, delimiter, name)
+			}
+			outputs[name] = strings.Join(lines, "\n")
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("line %d: expected NAME=value or NAME<<DELIMITER", lineNumber)
+		}
+		outputs[name] = value
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOutputs(t *testing.T) {
+	type TestParams struct {
+		TestCase        string
+		Env             string
+		Output          string
+		Later           string // output file of a command group that ran later
+		ExpectedOutputs map[string]string
+		ExpectedError   string
+	}
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:        "No files",
+			ExpectedOutputs: map[string]string{},
+		},
+		{
+			TestCase:        "Environment variables",
+			Env:             "FOO=bar\x00MULTI=a\nb=c\x00",
+			ExpectedOutputs: map[string]string{"FOO": "bar", "MULTI": "a\nb=c"},
+		},
+		{
+			TestCase:        "Output file overrides environment variables",
+			Env:             "FOO=bar\x00",
+			Output:          "FOO=baz\nLIST=a,b=c\r\n\nEMPTY=\n",
+			ExpectedOutputs: map[string]string{"FOO": "baz", "LIST": "a,b=c", "EMPTY": ""},
+		},
+		{
+			TestCase:        "Multi-line values",
+			Output:          "NOTES<<EOF\nline 1\n\nNAME=value\nEOF\nFOO<<ghadelimiter_1\nbar\nghadelimiter_1\n",
+			ExpectedOutputs: map[string]string{"NOTES": "line 1\n\nNAME=value", "FOO": "bar"},
+		},
+		{
+			TestCase:        "Later command groups override the outputs",
+			Env:             "FOO=bar\x00",
+			Output:          "NOTES<<EOF\nline 1\nEOF\nIMAGE_TAG=abc123\n",
+			Later:           "IMAGE_TAG=def456\n",
+			ExpectedOutputs: map[string]string{"FOO": "bar", "NOTES": "line 1", "IMAGE_TAG": "def456"},
+		},
+		{
+			TestCase:      "Missing delimiter",
+			Output:        "FOO=bar\nNOTES<<EOF\nline 1\n",
+			ExpectedError: "unable to parse output file: line 2: no delimiter EOF for output NOTES",
+		},
+		{
+			TestCase:      "Invalid line",
+			Output:        "FOO\n",
+			ExpectedError: "unable to parse output file: line 1: expected NAME=value or NAME<<DELIMITER",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			dir := t.TempDir()
+			groupDir, err := nextCollectDir(dir)
+			assert.NoError(err)
+			if tt.Env != "" {
+				assert.NoError(os.WriteFile(filepath.Join(groupDir, envFileName), []byte(tt.Env), 0644))
+			}
+			if tt.Output != "" {
+				assert.NoError(os.WriteFile(filepath.Join(groupDir, outputFileName), []byte(tt.Output), 0644))
+			}
+			laterDir, err := nextCollectDir(dir)
+			assert.NoError(err)
+			if tt.Later != "" {
+				assert.NoError(os.WriteFile(filepath.Join(laterDir, outputFileName), []byte(tt.Later), 0644))
+			}
+			outputs, err := ReadOutputs(dir)
+			if tt.ExpectedError != "" {
+				assert.EqualError(err, tt.ExpectedError)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tt.ExpectedOutputs, outputs)
+			}
+		})
+	}
+}
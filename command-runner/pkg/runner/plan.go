@@ -33,9 +33,34 @@ type EnvironmentConfiguration struct {
 // CommandGroup describes how to run a set of [Command]s.
 // Commands run in a container if Image or BuildContext is set. Otherwise, commands run in a local shell.
 type CommandGroup struct {
-	Image      string    // Image to pull and run commands in. Ignored if BuildContext is set.
-	Entrypoint Command   // Entrypoint to run in container. Only used if BuildContext or Image is set.
-	Commands   []Command // Commands to run
+	Image        string       // Image to pull and run commands in. Ignored if BuildContext is set.
+	Entrypoint   Command      // Entrypoint to run in container. Only used if BuildContext or Image is set.
+	Commands     []Command    // Commands to run
+	RunCondition RunCondition // when to run the commands, based on the command groups before this one. Defaults to RunConditionOnSuccess.
+}
+
+// RunCondition describes when a [CommandGroup] runs. Valid values are on_success, on_failure and always.
+type RunCondition string
+
+const (
+	// RunConditionOnSuccess runs the command group if every command group before it succeeded
+	RunConditionOnSuccess RunCondition = "on_success"
+	// RunConditionOnFailure runs the command group only if a command group before it failed
+	RunConditionOnFailure RunCondition = "on_failure"
+	// RunConditionAlways runs the command group whether or not the command groups before it succeeded
+	RunConditionAlways RunCondition = "always"
+)
+
+// Runs returns true if a command group with this condition runs, given whether a command group before it failed
+func (rc RunCondition) Runs(failed bool) bool {
+	switch rc {
+	case RunConditionAlways:
+		return true
+	case RunConditionOnFailure:
+		return failed
+	default:
+		return !failed
+	}
 }
 
 // Command contains a list of arguments for a given command
@@ -53,6 +78,9 @@ const (
 	FileMapTypeBind FileMapType = "bind"
 	// FileMapTypeCopyOut copies files out of a container
 	FileMapTypeCopyOut FileMapType = "copy_out"
+	// FileMapTypeCollect copies files out of a container after each command group, even one that failed. The files of
+	// each command group are copied to a new numbered subdirectory of the target, so none of them are replaced.
+	FileMapTypeCollect FileMapType = "collect"
 )
 
 // FileMap describes a mapping between a source path and a target path in the command runner
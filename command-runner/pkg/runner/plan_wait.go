@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+// planWait counts the plan executors that are running with a context
+type planWait struct {
+	running int
+	stopped chan struct{} // closed once no plan executor is running
+	mu      sync.Mutex
+}
+
+type planWaitContextKey string
+
+const planWaitContextKeyVal = planWaitContextKey("planWait")
+
+// WithPlanWait returns a context that tracks the plan executors run with it, and a function that waits until none of
+// them is running. A plan executor returns as soon as its context is done, while its commands are still being stopped
+// and the command groups that clean up after it still run, so features that cancel the context wait for them with it.
+func WithPlanWait(ctx context.Context) (context.Context, func()) {
+	pw := new(planWait)
+	return context.WithValue(ctx, planWaitContextKeyVal, pw), pw.wait
+}
+
+// TrackPlanExecution marks a plan executor as running for the [WithPlanWait] of the context, if any, until the
+// returned function is called
+func TrackPlanExecution(ctx context.Context) func() {
+	pw, ok := ctx.Value(planWaitContextKeyVal).(*planWait)
+	if !ok {
+		return func() {}
+	}
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if pw.running == 0 {
+		pw.stopped = make(chan struct{})
+	}
+	pw.running++
+	return func() {
+		pw.mu.Lock()
+		defer pw.mu.Unlock()
+		pw.running--
+		if pw.running == 0 {
+			close(pw.stopped)
+		}
+	}
+}
+
+func (pw *planWait) wait() {
+	pw.mu.Lock()
+	if pw.running == 0 {
+		pw.mu.Unlock()
+		return
+	}
+	stopped := pw.stopped
+	pw.mu.Unlock()
+	<-stopped
+}
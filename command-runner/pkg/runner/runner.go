@@ -8,6 +8,8 @@ import (
 	"math"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/codecatalyst-runner-cli/command-runner/internal/containers"
 	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
@@ -15,30 +17,53 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// cleanupTimeout is how long the command groups that run after a failure have to finish once the plan has been cancelled
+var cleanupTimeout = 5 * time.Minute
+
 func newRunner(namespace string, executionType ExecutionType, plan Plan, features ...Feature) common.Executor {
 	var executor common.Executor
+	// index of the first command group that hasn't succeeded, so that a plan that is run again resumes with the group that failed
+	nextGroup := 0
 	executor = func(ctx context.Context) error {
+		defer TrackPlanExecution(ctx)()
 		logPlan("About to execute plan\n", plan)
-		for _, commandGroup := range plan.CommandGroups() {
-			id := fmt.Sprintf("%s-%s", namespace, plan.ID())
-			executor, err := newCommandExecutor(ctx, id, executionType, commandGroup, plan.EnvironmentConfiguration())
-			if err != nil {
-				return err
+		commandGroups := plan.CommandGroups()
+		if nextGroup > 0 && nextGroup < len(commandGroups) {
+			log.Ctx(ctx).Debug().Msgf("resuming with command group %d of %d", nextGroup+1, len(commandGroups))
+		}
+		id := fmt.Sprintf("%s-%s", namespace, plan.ID())
+		groupCtx := ctx
+		var failure error
+		failedGroup := 0
+		for i := nextGroup; i < len(commandGroups); i++ {
+			commandGroup := commandGroups[i]
+			if !commandGroup.RunCondition.Runs(failure != nil) {
+				log.Ctx(ctx).Debug().Msgf("skipping command group %d of %d with run condition %s", i+1, len(commandGroups), commandGroup.RunCondition)
+				continue
 			}
-			for _, command := range commandGroup.Commands {
-				log.Ctx(ctx).Info().Msgf("⚡️ %s", strings.Join(command, " "))
-				err := executor.ExecuteCommand(ctx, command)
-				if err != nil {
-					if closeErr := executor.Close(true); closeErr != nil {
-						return errors.Join(err, closeErr)
-					}
-					return err
-				}
+			if failure != nil && ctx.Err() != nil && groupCtx == ctx {
+				// the plan was cancelled, give the command groups that clean up after it time to finish
+				var cancel context.CancelFunc
+				groupCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+				defer cancel()
+			}
+			err := runCommandGroup(groupCtx, id, executionType, commandGroup, plan.EnvironmentConfiguration())
+			if err == nil {
+				continue
 			}
-			if err := executor.Close(false); err != nil {
-				return err
+			if failure != nil {
+				// report the failure without masking the original error
+				log.Ctx(ctx).Error().Err(err).Msgf("command group %d of %d failed while running after a failure", i+1, len(commandGroups))
+				continue
 			}
+			failure = err
+			failedGroup = i
+		}
+		if failure != nil {
+			nextGroup = failedGroup
+			return failure
 		}
+		nextGroup = 0
 		return nil
 	}
 	executor = executor.CatchPanic()
@@ -48,6 +73,48 @@ func newRunner(namespace string, executionType ExecutionType, plan Plan, feature
 	return executor
 }
 
+// runCommandGroup runs the commands of a command group in a new command executor, stopping at the first command that fails
+func runCommandGroup(ctx context.Context, id string, executionType ExecutionType, commandGroup *CommandGroup, environmentConfiguration *EnvironmentConfiguration) error {
+	executor, err := newCommandExecutor(ctx, id, executionType, commandGroup, environmentConfiguration)
+	if err != nil {
+		return err
+	}
+	for _, command := range commandGroup.Commands {
+		log.Ctx(ctx).Info().Msgf("⚡️ %s", strings.Join(command, " "))
+		EmitEvent(ctx, &Event{Type: EventCommandStarted, Command: strings.Join(command, " ")})
+		start := time.Now()
+		err := executor.ExecuteCommand(ctx, command)
+		emitCommandFinished(ctx, command, start, err)
+		if err != nil {
+			if closeErr := executor.Close(true); closeErr != nil {
+				return errors.Join(err, closeErr)
+			}
+			return err
+		}
+	}
+	return executor.Close(false)
+}
+
+// emitCommandFinished emits the event of a command that has run, with its exit code if it is known
+func emitCommandFinished(ctx context.Context, command Command, start time.Time, err error) {
+	durationMs := time.Since(start).Milliseconds()
+	event := &Event{
+		Type:       EventCommandFinished,
+		Command:    strings.Join(command, " "),
+		DurationMs: &durationMs,
+	}
+	if err == nil {
+		exitCode := 0
+		event.ExitCode = &exitCode
+	} else {
+		if exitCode, ok := common.ExitCode(err); ok {
+			event.ExitCode = &exitCode
+		}
+		event.Error = err.Error()
+	}
+	EmitEvent(ctx, event)
+}
+
 func newFeatureWrapper(feature Feature, plan Plan) common.Wrapper {
 	return func(ctx context.Context, e common.Executor) error {
 		return feature(ctx, plan, PlanExecutor(e))
@@ -57,7 +124,7 @@ func newFeatureWrapper(feature Feature, plan Plan) common.Wrapper {
 func logPlan(message string, plan Plan) {
 	if log.Debug().Enabled() {
 		planJSON, _ := json.Marshal(plan)
-		log.Debug().Msgf("%s%s", message, planJSON)
+		log.Debug().Msgf("%s%s", message, common.Mask(string(planJSON)))
 	}
 }
 
@@ -68,6 +135,7 @@ type RunAllParams struct {
 	Features      FeaturesProvider // provider for the features to apply to each plan
 	Concurrency   int              // number of plans to run concurrently
 	ExecutionType ExecutionType    // executor to use for running commands
+	FailFast      bool             // cancel the running plans and stop scheduling new ones once any plan fails
 }
 
 // PlansProvider returns a list of [Plan]s
@@ -80,7 +148,10 @@ type FeaturesProvider interface {
 	Features(Plan) ([]Feature, error)
 }
 
-// RunAll executes all plans and features in parallel
+// RunAll executes all plans and features in parallel.
+//
+// With FailFast, the first failing plan cancels the context of every running plan with a [common.CancelledError]
+// and the plans that haven't started are not run. The errors of the failed plans are returned.
 func RunAll(ctx context.Context, params *RunAllParams) error {
 	if params.Plans == nil {
 		return fmt.Errorf("plannables provider cannot be nil")
@@ -90,7 +161,15 @@ func RunAll(ctx context.Context, params *RunAllParams) error {
 		return fmt.Errorf("unable to get plans from provider: %w", err)
 	}
 
-	executors := make([]common.Executor, 0)
+	var failFast *failFastTracker
+	if params.FailFast {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		failFast = &failFastTracker{cancel: cancel, finished: make(map[string]bool)}
+	}
+
+	nodes := make([]*common.GraphNode, 0, len(plans))
 	for _, plan := range plans {
 		var features []Feature
 		if params.Features != nil {
@@ -100,11 +179,90 @@ func RunAll(ctx context.Context, params *RunAllParams) error {
 			}
 		}
 		executor := newRunner(params.Namespace, params.ExecutionType, plan, features...)
-		executors = append(executors, executor)
+		if failFast != nil {
+			executor = failFast.wrap(plan.ID(), executor)
+		}
+		nodes = append(nodes, &common.GraphNode{
+			ID:        plan.ID(),
+			Executor:  executor,
+			DependsOn: planDependencies(plan, plans),
+		})
 	}
 
 	concurrency := int(math.Max(1, float64(params.Concurrency)))
-	return common.NewParallelExecutor(concurrency, executors...).TraceRegion("actions-runall")(ctx)
+	err = common.NewGraphExecutor(concurrency, nodes...).TraceRegion("actions-runall")(ctx)
+	if failFast != nil && len(failFast.failures) > 0 {
+		for _, plan := range plans {
+			if !failFast.finished[plan.ID()] {
+				log.Ctx(ctx).Warn().Msgf("🚫 CANCELLED %s because %s", plan.ID(), cancelledReason(ctx))
+			}
+		}
+		return errors.Join(failFast.failures...)
+	}
+	return err
+}
+
+// cancelledReason returns why the context was cancelled. The parent context may have been cancelled before the first
+// failure, e.g. by a timeout or an interrupt, in which case its cause is returned.
+func cancelledReason(ctx context.Context) string {
+	if cancelledError := common.Cancelled(ctx); cancelledError != nil {
+		return cancelledError.Reason
+	}
+	return context.Cause(ctx).Error()
+}
+
+// failFastTracker cancels the run once any plan fails
+type failFastTracker struct {
+	cancel   context.CancelCauseFunc
+	finished map[string]bool
+	failures []error
+	mu       sync.Mutex
+}
+
+func (ff *failFastTracker) wrap(planID string, executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		err := executor(ctx)
+		if errors.Is(err, common.ErrDefer) {
+			return err
+		}
+		ff.mu.Lock()
+		defer ff.mu.Unlock()
+		ff.finished[planID] = true
+		if _, ok := err.(common.Warning); ok || err == nil || ctx.Err() != nil {
+			return err
+		}
+		ff.failures = append(ff.failures, err)
+		ff.cancel(&common.CancelledError{Reason: fmt.Sprintf("%s failed", planID)})
+		return err
+	}
+}
+
+// planDependencies resolves the dependencies declared by a plan to the IDs of the plans that satisfy them
+func planDependencies(plan Plan, plans []Plan) func() []string {
+	return func() []string {
+		dependencies := make([]string, 0)
+		for _, dependency := range plan.DependsOn() {
+			for _, candidate := range plans {
+				if candidate.ID() != plan.ID() && MatchesDependency(plan.ID(), dependency, candidate.ID()) {
+					dependencies = append(dependencies, candidate.ID())
+				}
+			}
+		}
+		return dependencies
+	}
+}
+
+// MatchesDependency returns true if the plan with ID candidateID satisfies a dependency declared by the plan with ID planID.
+// A dependency matches a plan with the same ID, a plan with the same name in the group of planID,
+// or every plan in the group named by the dependency.
+func MatchesDependency(planID string, dependency string, candidateID string) bool {
+	if candidateID == dependency || strings.HasPrefix(candidateID, fmt.Sprintf("%s@", dependency)) {
+		return true
+	}
+	if group, _, found := strings.Cut(planID, "@"); found {
+		return candidateID == fmt.Sprintf("%s@%s", group, dependency)
+	}
+	return false
 }
 
 // ExecutionType allows the caller to force shell or docker execution of the action
@@ -2,8 +2,13 @@ package runner
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/aws/codecatalyst-runner-cli/command-runner/pkg/common"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -18,6 +23,8 @@ func TestRunAll(t *testing.T) {
 		TestCase      string
 		Plans         []Plan
 		Features      []Feature
+		Concurrency   int
+		FailFast      bool
 		ExpectedError string
 	}
 
@@ -48,6 +55,99 @@ func TestRunAll(t *testing.T) {
 				testLogFeature(),
 			},
 		},
+		{
+			TestCase: "DependsOn",
+			Plans: []Plan{
+				&MockPlan{
+					id:        "second",
+					dependsOn: []string{"first"},
+					environmentConfiguration: EnvironmentConfiguration{
+						WorkingDir: "testdata/workingdir/basic",
+						Env: map[string]string{
+							"MARKER": filepath.Join(os.TempDir(), "ccr-runall-dependson"),
+						},
+					},
+					commandGroups: []*CommandGroup{
+						{
+							Commands: []Command{
+								{"test -e $MARKER"},
+								{"rm $MARKER"},
+							},
+						},
+					},
+				},
+				&MockPlan{
+					id: "first",
+					environmentConfiguration: EnvironmentConfiguration{
+						WorkingDir: "testdata/workingdir/basic",
+						Env: map[string]string{
+							"MARKER": filepath.Join(os.TempDir(), "ccr-runall-dependson"),
+						},
+					},
+					commandGroups: []*CommandGroup{
+						{
+							Commands: []Command{
+								{"sleep 1 && touch $MARKER"},
+							},
+						},
+					},
+				},
+			},
+			Features: []Feature{
+				testLogFeature(),
+			},
+		},
+		{
+			TestCase: "FailFast",
+			Plans: []Plan{
+				&MockPlan{
+					id: "slow",
+					environmentConfiguration: EnvironmentConfiguration{
+						WorkingDir: "testdata/workingdir/basic",
+					},
+					commandGroups: []*CommandGroup{
+						{
+							Commands: []Command{
+								{"sleep 30"},
+							},
+						},
+					},
+				},
+				&MockPlan{
+					id: "fails",
+					environmentConfiguration: EnvironmentConfiguration{
+						WorkingDir: "testdata/workingdir/basic",
+					},
+					commandGroups: []*CommandGroup{
+						{
+							Commands: []Command{
+								{"exit 1"},
+							},
+						},
+					},
+				},
+				&MockPlan{
+					id:        "after-slow",
+					dependsOn: []string{"slow"},
+					environmentConfiguration: EnvironmentConfiguration{
+						WorkingDir: "testdata/workingdir/basic",
+					},
+					commandGroups: []*CommandGroup{
+						{
+							Commands: []Command{
+								{"exit 1"},
+							},
+						},
+					},
+				},
+			},
+			Features: []Feature{
+				testLogFeature(),
+			},
+			Concurrency:   2,
+			FailFast:      true,
+			ExpectedError: "exit status 1",
+		},
 	} {
 		// setup the code under test
 		ctx := context.Background()
@@ -62,6 +162,8 @@ func TestRunAll(t *testing.T) {
 			Plans:         plansProvider,
 			Features:      featuresProvider,
 			ExecutionType: ExecutionTypeShell,
+			Concurrency:   tt.Concurrency,
+			FailFast:      tt.FailFast,
 		})
 
 		if tt.ExpectedError != "" {
@@ -72,6 +174,225 @@ func TestRunAll(t *testing.T) {
 	}
 }
 
+func TestRunAllFailFastParentCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	// the parent context times out before any plan fails
+	parent, cancelParent := context.WithCancelCause(context.Background())
+	cancelParent(&common.TimeoutError{Timeout: time.Second})
+
+	// the fail fast cancellation is then a no-op
+	ctx, cancel := context.WithCancelCause(parent)
+	cancel(&common.CancelledError{Reason: "fails failed"})
+	assert.Equal("timed out after 1s", cancelledReason(ctx))
+
+	// the plans of a run that was cancelled by its parent are not failures
+	plansProvider := &MockPlansProvider{}
+	plansProvider.On("Plans").Return([]Plan{
+		&MockPlan{
+			id: "fails",
+			environmentConfiguration: EnvironmentConfiguration{
+				WorkingDir: "testdata/workingdir/basic",
+			},
+			commandGroups: []*CommandGroup{
+				{
+					Commands: []Command{{"exit 1"}},
+				},
+			},
+		},
+	}, nil)
+	featuresProvider := &MockFeaturesProvider{}
+	featuresProvider.On("Features", mock.Anything).Return([]Feature{}, nil)
+	assert.NotPanics(func() {
+		assert.Error(RunAll(parent, &RunAllParams{
+			Namespace:     "mockns",
+			Plans:         plansProvider,
+			Features:      featuresProvider,
+			ExecutionType: ExecutionTypeShell,
+			FailFast:      true,
+		}))
+	})
+}
+
+func TestRunnerResume(t *testing.T) {
+	assert := assert.New(t)
+
+	// setup the code under test
+	tempDir := t.TempDir()
+	plan := &MockPlan{
+		id: "resume",
+		environmentConfiguration: EnvironmentConfiguration{
+			WorkingDir: "testdata/workingdir/basic",
+			Env: map[string]string{
+				"TEMP_DIR": tempDir,
+			},
+		},
+		commandGroups: []*CommandGroup{
+			{
+				Commands: []Command{
+					{"echo first >> $TEMP_DIR/first.txt"},
+				},
+			},
+			{
+				Commands: []Command{
+					{"test -e $TEMP_DIR/second.txt || (touch $TEMP_DIR/second.txt && exit 1)"},
+				},
+			},
+		},
+	}
+	executor := newRunner("mockns", ExecutionTypeShell, plan)
+
+	// run the plan until it succeeds
+	ctx := context.Background()
+	assert.Error(executor(ctx), "the second command group fails the first time")
+	assert.NoError(executor(ctx), "the plan resumes with the second command group")
+	assert.NoError(executor(ctx), "the plan starts over once it succeeded")
+
+	// assert the results
+	content, err := os.ReadFile(filepath.Join(tempDir, "first.txt"))
+	assert.NoError(err)
+	assert.Equal("first\nfirst\n", string(content))
+}
+
+func TestRunnerRunCondition(t *testing.T) {
+	for _, tt := range []struct {
+		TestCase      string
+		Cancel        bool
+		MainCommand   string
+		ExpectedError string
+		ExpectedFiles []string
+	}{
+		{
+			TestCase:      "success",
+			MainCommand:   "true",
+			ExpectedError: "exit status 2",
+			ExpectedFiles: []string{"always.txt", "on_success.txt"},
+		},
+		{
+			TestCase:      "failure",
+			MainCommand:   "exit 1",
+			ExpectedError: "exit status 1",
+			ExpectedFiles: []string{"on_failure.txt", "always.txt"},
+		},
+		{
+			TestCase:      "cancelled",
+			Cancel:        true,
+			MainCommand:   "true",
+			ExpectedError: "context canceled",
+			ExpectedFiles: []string{"on_failure.txt", "always.txt"},
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// setup the code under test
+			tempDir := t.TempDir()
+			plan := &MockPlan{
+				id: "run-condition",
+				environmentConfiguration: EnvironmentConfiguration{
+					WorkingDir: "testdata/workingdir/basic",
+					Env: map[string]string{
+						"TEMP_DIR": tempDir,
+					},
+				},
+				commandGroups: []*CommandGroup{
+					{
+						Commands: []Command{{tt.MainCommand}},
+					},
+					{
+						Commands: []Command{{"touch $TEMP_DIR/on_success.txt"}},
+					},
+					{
+						Commands:     []Command{{"touch $TEMP_DIR/on_failure.txt"}},
+						RunCondition: RunConditionOnFailure,
+					},
+					{
+						Commands:     []Command{{"touch $TEMP_DIR/always.txt && exit 2"}},
+						RunCondition: RunConditionAlways,
+					},
+				},
+			}
+			executor := newRunner("mockns", ExecutionTypeShell, plan)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.Cancel {
+				cancel()
+			}
+
+			// run the plan
+			err := executor(ctx)
+
+			// assert the results
+			assert.EqualError(err, tt.ExpectedError)
+			entries, _ := os.ReadDir(tempDir)
+			files := make([]string, 0)
+			for _, entry := range entries {
+				files = append(files, entry.Name())
+			}
+			assert.ElementsMatch(tt.ExpectedFiles, files)
+		})
+	}
+}
+
+func TestRunnerOutputs(t *testing.T) {
+	assert := assert.New(t)
+
+	// setup the code under test
+	outputDir := t.TempDir()
+	plan := &MockPlan{
+		id: "outputs",
+		environmentConfiguration: EnvironmentConfiguration{
+			WorkingDir: "testdata/workingdir/basic",
+			FileMaps: []*FileMap{
+				{
+					SourcePath: fmt.Sprintf("%s/.", OutputDir),
+					TargetPath: outputDir,
+					Type:       FileMapTypeCollect,
+				},
+			},
+		},
+		commandGroups: []*CommandGroup{
+			{
+				Commands: []Command{{"true"}},
+			},
+			{
+				Commands: []Command{{`echo "Foo=bar" >> $CATALYST_OUTPUT`}},
+			},
+			{
+				Commands:     []Command{{"true"}},
+				RunCondition: RunConditionAlways,
+			},
+		},
+	}
+	executor := newRunner("mockns", ExecutionTypeShell, plan)
+
+	// run the pre, main and post command groups
+	assert.NoError(executor(context.Background()))
+
+	// assert the outputs of main are kept after post ran
+	outputs, err := ReadOutputs(outputDir)
+	assert.NoError(err)
+	assert.Equal("bar", outputs["Foo"])
+}
+
+func TestMatchesDependency(t *testing.T) {
+	for _, tt := range []struct {
+		PlanID      string
+		Dependency  string
+		CandidateID string
+		Expected    bool
+	}{
+		{PlanID: "Build", Dependency: "Test", CandidateID: "Test", Expected: true},
+		{PlanID: "Build", Dependency: "Test", CandidateID: "Tests", Expected: false},
+		{PlanID: "Build", Dependency: "Test", CandidateID: "Test@Unit", Expected: true},
+		{PlanID: "Group@Build", Dependency: "Test", CandidateID: "Group@Test", Expected: true},
+		{PlanID: "Group@Build", Dependency: "Test", CandidateID: "Other@Test", Expected: false},
+		{PlanID: "Build", Dependency: "Test", CandidateID: "Group@Test", Expected: false},
+	} {
+		assert.Equal(t, tt.Expected, MatchesDependency(tt.PlanID, tt.Dependency, tt.CandidateID), "%s depends on %s satisfied by %s", tt.PlanID, tt.Dependency, tt.CandidateID)
+	}
+}
+
 type MockPlansProvider struct {
 	mock.Mock
 }
@@ -1,8 +1,8 @@
 package runner
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -22,13 +22,14 @@ import (
 )
 
 type shellCommandExecutor struct {
-	Stdout         io.Writer
-	Stderr         io.Writer
-	Env            []string
-	WorkingDir     string
-	CloseExecutors []common.Executor
-	ctx            context.Context
-	mceDir         string
+	Stdout           io.Writer
+	Stderr           io.Writer
+	Env              []string
+	WorkingDir       string
+	CollectExecutors []common.Executor
+	CloseExecutors   []common.Executor
+	ctx              context.Context
+	mceDir           string
 }
 
 type newShellCommandExecutorParams struct {
@@ -40,11 +41,14 @@ func newShellCommandExecutor(ctx context.Context, params *newShellCommandExecuto
 	if err != nil {
 		return nil, err
 	}
+	collectExecutors := []common.Executor{}
 	closeExecutors := []common.Executor{}
 	for _, filemap := range params.FileMaps {
 		switch filemap.Type {
 		case FileMapTypeCopyOut:
 			closeExecutors = append(closeExecutors, copyOut(ctx, mceDir, params.WorkingDir, filemap))
+		case FileMapTypeCollect:
+			collectExecutors = append(collectExecutors, collect(ctx, mceDir, params.WorkingDir, filemap))
 		case FileMapTypeBind:
 			// treat bind mount as symlink
 			if err := symlink(mceDir, params.WorkingDir, filemap); err != nil {
@@ -73,7 +77,6 @@ func newShellCommandExecutor(ctx context.Context, params *newShellCommandExecuto
 		}
 	}
 
-	closeExecutors = append(closeExecutors, setOutputs(mceDir, params.Stdout))
 	closeExecutors = append(closeExecutors, func(ctx context.Context) error {
 		log.Debug().Msgf("close() is removing %s", mceDir)
 		return os.RemoveAll(mceDir)
@@ -97,6 +100,10 @@ func newShellCommandExecutor(ctx context.Context, params *newShellCommandExecuto
 	}
 	env = append(env, fmt.Sprintf("PATH=%s", os.Getenv("PATH")))
 	env = append(env, fmt.Sprintf("CATALYST_DEFAULT_DIR=%s", defaultDir))
+	env = append(env, fmt.Sprintf("%s=%s", OutputFileEnv, outputFilePath(mceDir)))
+
+	// the environment variables must be in the output dir before it is collected
+	collectExecutors = append([]common.Executor{writeEnvOutputs(mceDir, env)}, collectExecutors...)
 
 	if err := os.WriteFile(filepath.Join(mceDir, "env.sh"), []byte(""), 00666); err != nil /* #nosec G306 */ {
 		return nil, err
@@ -106,21 +113,30 @@ func newShellCommandExecutor(ctx context.Context, params *newShellCommandExecuto
 		return nil, err
 	}
 
+	if err := os.MkdirAll(resolvePath(OutputDir, mceDir), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(outputFilePath(mceDir), []byte(""), 00666); err != nil /* #nosec G306 */ {
+		return nil, err
+	}
+
 	return &shellCommandExecutor{
-		Stdout:         params.Stdout,
-		Stderr:         params.Stderr,
-		WorkingDir:     defaultDir,
-		Env:            env,
-		CloseExecutors: closeExecutors,
-		mceDir:         mceDir,
-		ctx:            ctx,
+		Stdout:           params.Stdout,
+		Stderr:           params.Stderr,
+		WorkingDir:       defaultDir,
+		Env:              env,
+		CollectExecutors: collectExecutors,
+		CloseExecutors:   closeExecutors,
+		mceDir:           mceDir,
+		ctx:              ctx,
 	}, nil
 }
 
 func (sce *shellCommandExecutor) Close(isError bool) error {
-	var err error
+	// the files are collected even if a command failed, since they may explain the failure
+	err := common.NewPipelineExecutor(sce.CollectExecutors...).TraceRegion("collect-executors")(sce.ctx)
 	if !isError {
-		err = common.NewPipelineExecutor(sce.CloseExecutors...).TraceRegion("close-executors")(sce.ctx)
+		err = errors.Join(err, common.NewPipelineExecutor(sce.CloseExecutors...).TraceRegion("close-executors")(sce.ctx))
 	}
 	return err
 }
@@ -146,17 +162,24 @@ func (sce *shellCommandExecutor) ExecuteCommand(ctx context.Context, command Com
 	cmd.Stdin = nil
 	cmd.Dir = sce.WorkingDir
 	cmd.Env = sce.Env
+	setProcessGroup(cmd)
+	var killTimer *time.Timer
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		log.Ctx(ctx).Debug().Msgf("terminating process group %d", pgid)
+		killTimer = time.AfterFunc(terminationGracePeriod, func() {
+			log.Ctx(ctx).Debug().Msgf("killing process group %d", pgid)
+			_ = killProcessGroup(cmd)
+		})
+		return terminateProcessGroup(cmd)
+	}
+	cmd.WaitDelay = terminationGracePeriod
 
 	log.Debug().Msgf("ExecuteCommand: path=%s args=%s dir=%s env=%#v script=%s", cmd.Path, cmd.Args, cmd.Dir, cmd.Env, script)
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return err
-	}
+	// let exec copy the output so that Wait returns once all of it has been written
+	cmd.Stdout = sce.Stdout
+	cmd.Stderr = sce.Stderr
 
 	log.Ctx(ctx).Debug().Msgf("%s shell run command=%+v workdir=%s", logPrefix, cmd, sce.WorkingDir)
 	if common.Dryrun(ctx) {
@@ -167,22 +190,21 @@ func (sce *shellCommandExecutor) ExecuteCommand(ctx context.Context, command Com
 	if err := cmd.Start(); err != nil {
 		return err
 	}
-	if sce.Stdout != nil {
-		go streamPipe(sce.Stdout, stdout)
+	err := cmd.Wait()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// the script succeeded but left a background process holding its output open
+		log.Ctx(ctx).Debug().Err(err).Msg("ignoring output of background processes")
+		err = nil
 	}
-	if sce.Stderr != nil {
-		go streamPipe(sce.Stderr, stderr)
+	if killTimer != nil && killTimer.Stop() {
+		// the script exited within the grace period, don't leave any of its processes behind
+		_ = killProcessGroup(cmd)
 	}
-	return cmd.Wait()
+	return err
 }
 
 const logPrefix = "  \U0001F4BB  "
 
-func streamPipe(dst io.Writer, src io.ReadCloser) {
-	reader := bufio.NewReader(src)
-	_, _ = io.Copy(dst, reader)
-}
-
 func copyDir(ctx context.Context, destdir string, sourcedir string, useGitIgnore bool) error {
 	if sourcedir == destdir {
 		return fmt.Errorf("unable to copyDir when sourcedir==destdir")
@@ -214,23 +236,16 @@ func copyDir(ctx context.Context, destdir string, sourcedir string, useGitIgnore
 	return filepath.Walk(sourcedir, fc.CollectFiles(ctx, []string{}))
 }
 
-func setOutputs(mceDir string, stdout io.Writer) common.Executor {
-	return func(context.Context) error {
-		f, err := os.Open(filepath.Join(mceDir, "env.sh"))
-		if err != nil {
-			return nil
-		}
-		defer f.Close()
-		scanner := bufio.NewScanner(f)
-		pattern := regexp.MustCompile(`^export (.+)="(.+)"$`)
-		for scanner.Scan() {
-			line := scanner.Text()
-			kv := pattern.FindStringSubmatch(line)
-			if len(kv) == 3 {
-				fmt.Fprintf(stdout, "::set-output name=%s::%s\n", kv[1], kv[2])
-			}
+// writeEnvOutputs writes the environment variables exported by the commands to the output dir
+func writeEnvOutputs(mceDir string, env []string) common.Executor {
+	return func(ctx context.Context) error {
+		script := fmt.Sprintf(". %s && %s", filepath.Join(mceDir, "env.sh"), envOutputScript(mceDir))
+		cmd := exec.CommandContext(ctx, "/bin/sh", "-c", script) //#nosec G204
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("unable to write environment outputs: %w\n%s", err, out)
 		}
-		return scanner.Err()
+		return nil
 	}
 }
 
@@ -261,6 +276,29 @@ func copyOut(ctx context.Context, mceDir string, workingDir string, filemap *Fil
 	}
 }
 
+// collect copies the files of the source of a FileMap of type [FileMapTypeCollect] to a new subdirectory of its target
+func collect(ctx context.Context, mceDir string, workingDir string, filemap *FileMap) common.Executor {
+	sourcePath := resolvePath(filemap.SourcePath, mceDir)
+	targetPath := resolvePath(filemap.TargetPath, workingDir)
+	return func(context.Context) error {
+		sources, err := filepath.Glob(sourcePath)
+		if err != nil {
+			return err
+		}
+		collectDir, err := nextCollectDir(targetPath)
+		if err != nil {
+			return err
+		}
+		log.Debug().Msgf("collecting %v (%s) to %s", sources, sourcePath, collectDir)
+		for _, source := range sources {
+			if err := copyDir(ctx, collectDir, source, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 func symlink(mceDir string, workingDir string, filemap *FileMap) error {
 	if resolvePath(filemap.SourcePath, workingDir) != resolvePath(".", workingDir) {
 		sourcePath := resolvePath(filemap.SourcePath, workingDir)
@@ -3,7 +3,10 @@ package runner
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kballard/go-shellquote"
 	"github.com/stretchr/testify/assert"
@@ -121,6 +124,57 @@ func TestExecutorShell(t *testing.T) {
 	}
 }
 
+func TestExecutorShellTermination(t *testing.T) {
+	type TestParams struct {
+		TestCase       string
+		Command        string
+		ExpectedOutput string
+	}
+
+	defer func(gracePeriod time.Duration) {
+		terminationGracePeriod = gracePeriod
+	}(terminationGracePeriod)
+	terminationGracePeriod = 500 * time.Millisecond
+
+	for _, tt := range []*TestParams{
+		{
+			TestCase:       "SIGTERM",
+			Command:        "trap 'echo terminated; exit 1' TERM; echo started; sleep 30 & wait",
+			ExpectedOutput: "started\nterminated\n",
+		},
+		{
+			TestCase:       "SIGKILL",
+			Command:        "trap '' TERM; echo started; sleep 30",
+			ExpectedOutput: "started\n",
+		},
+	} {
+		t.Run(tt.TestCase, func(t *testing.T) {
+			assert := assert.New(t)
+			// setup the code under test
+			stdout := new(bytes.Buffer)
+			executor, err := newShellCommandExecutor(context.Background(), &newShellCommandExecutorParams{
+				EnvironmentConfiguration: &EnvironmentConfiguration{
+					WorkingDir: "testdata/workingdir/basic",
+					Stdout:     stdout,
+				},
+			})
+			assert.NoError(err)
+			defer executor.Close(true)
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			// run the command
+			start := time.Now()
+			err = executor.ExecuteCommand(ctx, strings.Split(tt.Command, " "))
+
+			// assert the results
+			assert.Error(err)
+			assert.Less(time.Since(start), 5*time.Second)
+			assert.Equal(tt.ExpectedOutput, stdout.String())
+		})
+	}
+}
+
 func TestInterpolate(t *testing.T) {
 	type TestParams struct {
 		TestCase       string
@@ -173,3 +227,55 @@ func TestInterpolate(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutorShellOutputs(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	outputDir := t.TempDir()
+	newExecutor := func() commandExecutor {
+		executor, err := newShellCommandExecutor(ctx, &newShellCommandExecutorParams{
+			EnvironmentConfiguration: &EnvironmentConfiguration{
+				WorkingDir: "testdata/workingdir/basic",
+				Stdout:     new(bytes.Buffer),
+				FileMaps: []*FileMap{
+					{
+						SourcePath: fmt.Sprintf("%s/.", OutputDir),
+						TargetPath: outputDir,
+						Type:       FileMapTypeCollect,
+					},
+				},
+			},
+		})
+		assert.NoError(err)
+		return executor
+	}
+	executor := newExecutor()
+
+	for _, cmd := range []string{
+		`export IMAGE_TAG="a,b=c"`,
+		`echo "IMAGE_TAG=abc123" >> $CATALYST_OUTPUT`,
+		`printf 'NOTES<<EOF\nline 1\nline 2\nEOF\n' >> $CATALYST_OUTPUT`,
+		`export REGION="us-west-2"`,
+	} {
+		assert.NoError(executor.ExecuteCommand(ctx, Command{cmd}))
+	}
+	assert.NoError(executor.Close(false))
+
+	// a later command group that sets no outputs keeps those of the groups before it
+	executor = newExecutor()
+	assert.NoError(executor.ExecuteCommand(ctx, Command{"true"}))
+	assert.NoError(executor.Close(false))
+
+	// the outputs of a command group that fails are collected too
+	executor = newExecutor()
+	assert.NoError(executor.ExecuteCommand(ctx, Command{`echo "STATUS=failed" >> $CATALYST_OUTPUT`}))
+	assert.Error(executor.ExecuteCommand(ctx, Command{"exit 1"}))
+	assert.NoError(executor.Close(true))
+
+	outputs, err := ReadOutputs(outputDir)
+	assert.NoError(err)
+	assert.Equal("abc123", outputs["IMAGE_TAG"])
+	assert.Equal("line 1\nline 2", outputs["NOTES"])
+	assert.Equal("us-west-2", outputs["REGION"])
+	assert.Equal("failed", outputs["STATUS"])
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package runner
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing on Windows, where the processes started by the command are not tracked
+func setProcessGroup(_ *exec.Cmd) {}
+
+// terminateProcessGroup kills the command, as Windows has no SIGTERM
+func terminateProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcessGroup kills the command
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}